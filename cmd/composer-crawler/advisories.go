@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// 一次请求最多查询多少个包的安全公告，太多的话 URL 会超长
+const advisoriesBatchSize = 50
+
+var advisoriesCommand = &command{
+	name:        "advisories",
+	args:        "[package...]",
+	description: "List security advisories of packages, or all advisories updated since a date",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		since := flags.String("since", "", "list advisories updated since this date (2006-01-02 or RFC 3339) when no package is given")
+		return func(ctx context.Context, env *environment, args []string) error {
+			var result *response.AdvisoriesResponse
+			var err error
+			switch {
+			case len(args) > 0:
+				result, err = env.repository.ListAdvisoriesForPackages(ctx, args)
+			case *since != "":
+				updatedSince, parseErr := parseSince(*since)
+				if parseErr != nil {
+					return usageError(parseErr.Error())
+				}
+				result, err = env.repository.ListSecurityAdvisories(ctx, updatedSince)
+			default:
+				return usageError("either packages or --since is required")
+			}
+			if err != nil {
+				return err
+			}
+
+			advisories := make([]*response.Advisory, 0)
+			for name, packageAdvisories := range result.Advisories {
+				for _, advisory := range packageAdvisories {
+					if advisory.PackageName == "" {
+						advisory.PackageName = name
+					}
+					advisories = append(advisories, advisory)
+				}
+			}
+			response.SortAdvisories(advisories)
+			rows := make([][]string, 0, len(advisories))
+			for _, advisory := range advisories {
+				rows = append(rows, []string{advisory.PackageName, advisory.AdvisoryID, advisory.Cve, string(advisory.Severity), advisory.AffectedVersions, advisory.Title})
+			}
+			return env.printer.print(advisories, []string{"package", "advisory", "cve", "severity", "affected", "title"}, rows)
+		}
+	},
+}
+
+func parseSince(since string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, since); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected 2006-01-02 or RFC 3339", since)
+}
+
+// finding 表示审计发现的一个受影响的包
+type finding struct {
+	Package   string             `json:"package"`
+	Installed string             `json:"installed"`
+	Dev       bool               `json:"dev"`
+	Advisory  *response.Advisory `json:"advisory"`
+
+	// 安全公告没有严重程度或者严重程度无法识别，--min-severity 没法判断，保留下来由人来确认
+	SeverityUnknown bool `json:"severityUnknown,omitempty"`
+}
+
+var auditCommand = &command{
+	name:        "audit",
+	args:        "<composer.lock>",
+	description: "Check the locked packages against known security advisories",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		noDev := flags.Bool("no-dev", false, "skip packages-dev")
+		minSeverity := flags.String("min-severity", "", "only report advisories at least this severe: low, medium, high or critical, advisories without a known severity are always reported")
+		return func(ctx context.Context, env *environment, args []string) error {
+			if len(args) != 1 {
+				return usageError("audit takes exactly one composer.lock path")
+			}
+			threshold := response.Severity(strings.ToLower(*minSeverity))
+			if *minSeverity != "" && threshold.Rank() == 0 {
+				return usageError(fmt.Sprintf("invalid --min-severity %q", *minSeverity))
+			}
+			lock, err := manifest.ReadComposerLock(args[0])
+			if err != nil {
+				return err
+			}
+			packages := lock.Packages
+			if !*noDev {
+				packages = lock.AllPackages()
+			}
+
+			findings := make([]*finding, 0)
+			for start := 0; start < len(packages); start += advisoriesBatchSize {
+				end := start + advisoriesBatchSize
+				if end > len(packages) {
+					end = len(packages)
+				}
+				names := make([]string, 0, end-start)
+				for _, lockPackage := range packages[start:end] {
+					names = append(names, lockPackage.Name)
+				}
+				result, err := env.repository.ListAdvisoriesForPackages(ctx, names)
+				if err != nil {
+					return fmt.Errorf("list advisories: %w", err)
+				}
+				for _, lockPackage := range packages[start:end] {
+					for name, advisories := range result.Advisories {
+						if !strings.EqualFold(name, lockPackage.Name) {
+							continue
+						}
+						for _, advisory := range advisories {
+							if !affects(advisory, lockPackage.Version) {
+								continue
+							}
+							rank := advisory.Severity.Rank()
+							if threshold != "" && rank > 0 && rank < threshold.Rank() {
+								continue
+							}
+							findings = append(findings, &finding{
+								Package:         lockPackage.Name,
+								Installed:       lockPackage.Version,
+								Dev:             lock.IsDev(lockPackage.Name),
+								Advisory:        advisory,
+								SeverityUnknown: rank == 0,
+							})
+						}
+					}
+				}
+			}
+
+			rows := make([][]string, 0, len(findings))
+			for _, f := range findings {
+				rows = append(rows, []string{f.Package, f.Installed, f.Advisory.AdvisoryID, f.Advisory.Cve, severityLabel(f.Advisory.Severity), f.Advisory.AffectedVersions, f.Advisory.Title})
+			}
+			if err := env.printer.print(findings, []string{"package", "installed", "advisory", "cve", "severity", "affected", "title"}, rows); err != nil {
+				return err
+			}
+			if len(findings) > 0 {
+				fmt.Fprintf(env.stderr, "found %d advisories affecting %s\n", len(findings), args[0])
+				return errFindings
+			}
+			return nil
+		}
+	},
+}
+
+// severityLabel 返回表格中显示的严重程度，没有或者无法识别的严重程度标记为 unknown
+func severityLabel(severity response.Severity) string {
+	switch {
+	case severity.Rank() > 0:
+		return string(severity)
+	case severity == "":
+		return "unknown"
+	default:
+		return string(severity) + " (unknown)"
+	}
+}
+
+// 判断安全公告是否影响安装的版本，无法解析的时候保守一点，认为受影响
+func affects(advisory *response.Advisory, version string) bool {
+	constraint, err := semver.ParseConstraint(advisory.AffectedVersions)
+	if err != nil {
+		return true
+	}
+	return constraint.CheckString(version)
+}
@@ -0,0 +1,181 @@
+// composer-crawler 是 composer 仓库爬虫的命令行工具，不需要写 Go 代码就能查询包信息、审计依赖和镜像仓库
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+)
+
+// 命令执行成功但是发现了问题（比如审计发现了漏洞）时返回的错误，只影响退出码，不输出错误信息
+var errFindings = errors.New("findings reported")
+
+// 退出码
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitFindings = 3
+)
+
+// 所有子命令共用的参数，既可以放在子命令前面，也可以放在子命令后面
+type globalOptions struct {
+	server   string
+	proxy    string
+	authFile string
+	format   string
+}
+
+func (x *globalOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&x.server, "server", x.server, "composer repository URL")
+	flags.StringVar(&x.proxy, "proxy", x.proxy, "HTTP proxy URL")
+	flags.StringVar(&x.authFile, "auth", x.authFile, "path to an auth.json with repository credentials")
+	flags.StringVar(&x.format, "format", x.format, "output format: table, json or csv")
+}
+
+// 子命令执行时的环境
+type environment struct {
+	repository *repository.Repository
+	printer    *printer
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+// command 表示一个子命令，setup 注册子命令自己的参数，返回真正执行的函数
+type command struct {
+	name        string
+	args        string
+	description string
+	setup       func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error
+}
+
+var commands = []*command{
+	listCommand,
+	statsCommand,
+	showCommand,
+	advisoriesCommand,
+	downloadIndexCommand,
+	auditCommand,
+	mirrorCommand,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	global := &globalOptions{server: repository.DefaultServerUrl, format: formatTable}
+	flags := flag.NewFlagSet("composer-crawler", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	global.register(flags)
+	flags.Usage = func() {
+		usage(stderr, flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		usage(stderr, flags)
+		return exitUsage
+	}
+
+	name := flags.Arg(0)
+	var selected *command
+	for _, c := range commands {
+		if c.name == name {
+			selected = c
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr, flags)
+		return exitUsage
+	}
+
+	commandFlags := flag.NewFlagSet(selected.name, flag.ContinueOnError)
+	commandFlags.SetOutput(stderr)
+	global.register(commandFlags)
+	execute := selected.setup(commandFlags)
+	commandFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: composer-crawler %s [flags] %s\n\n%s\n\nFlags:\n", selected.name, selected.args, selected.description)
+		commandFlags.PrintDefaults()
+	}
+	if err := commandFlags.Parse(flags.Args()[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	printer, err := newPrinter(global.format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	options := &repository.Options{ServerUrl: strings.TrimRight(global.server, "/"), Proxy: global.proxy}
+	if global.authFile != "" {
+		auth, err := repository.ReadAuthJSON(global.authFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "read auth file: %v\n", err)
+			return exitError
+		}
+		options.Auth = auth
+	}
+	env := &environment{
+		repository: repository.NewRepository(options),
+		printer:    printer,
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+
+	err = execute(ctx, env, commandFlags.Args())
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errFindings):
+		return exitFindings
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%v\n\n", err)
+		commandFlags.Usage()
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+}
+
+// usageError 表示参数不对，会同时输出子命令的用法
+type usageError string
+
+func (x usageError) Error() string {
+	return string(x)
+}
+
+func usage(writer io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintf(writer, "Usage: composer-crawler [flags] <command> [command flags] [args]\n\nCommands:\n")
+	sorted := append([]*command(nil), commands...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	for _, c := range sorted {
+		fmt.Fprintf(writer, "  %-16s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(writer, "\nGlobal flags:\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(writer, "\nRun 'composer-crawler <command> -h' for the flags of a command.\n")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer serves just enough of the Packagist API for the commands
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/list.json":
+			w.Write([]byte(`{"packageNames": ["monolog/monolog", "symfony/console", "symfony/http-kernel"]}`))
+		case "/statistics.json":
+			w.Write([]byte(`{"totals": {"downloads": 1000, "packages": 3, "versions": 30}}`))
+		case "/packages/monolog/monolog/stats.json":
+			w.Write([]byte(`{"downloads": {"total": 900, "monthly": 90, "daily": 9}}`))
+		case "/packages/monolog/monolog.json":
+			w.Write([]byte(`{"package": {"name": "monolog/monolog", "description": "Logging for PHP", "maintainers": [{"name": "Seldaek"}],
+				"downloads": {"total": 900, "monthly": 90},
+				"versions": {
+					"dev-main": {"version": "dev-main"},
+					"3.5.0": {"version": "3.5.0", "license": ["MIT"], "time": "2023-10-27T15:32:31+00:00", "dist": {"type": "zip", "reference": "c915e2634718dbc8a4a15c61b0e62e7a44e14448"}},
+					"3.6.0-RC1": {"version": "3.6.0-RC1"},
+					"2.9.2": {"version": "2.9.2", "license": ["MIT"]}
+				}}}`))
+		case "/metadata/changes.json":
+			w.Write([]byte(`{"actions": [], "timestamp": 16000000000000}`))
+		case "/api/security-advisories/":
+			if r.URL.Query().Get("updatedSince") != "" || len(r.URL.Query()["packages[]"]) > 0 {
+				w.Write([]byte(`{"advisories": {"symfony/http-kernel": [
+					{"advisoryId": "PKSA-1", "packageName": "symfony/http-kernel", "title": "Cookie leak", "cve": "CVE-2022-24894", "affectedVersions": ">=5.0.0,<5.4.20", "severity": "medium"},
+					{"advisoryId": "PKSA-2", "packageName": "symfony/http-kernel", "title": "Old issue", "affectedVersions": ">=2.0.0,<2.0.1", "severity": "low"}
+				]}}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":"error","message":"Package not found"}`))
+		}
+	}))
+}
+
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(context.Background(), args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := runCommand(t)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "download-index")
+
+	code, _, stderr = runCommand(t, "nope")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+
+	code, _, stderr = runCommand(t, "--format", "yaml", "list")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown format "yaml"`)
+
+	code, _, stderr = runCommand(t, "show")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage: composer-crawler show [flags] <package>")
+}
+
+func TestRun_List(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	// Global flags work before and after the command
+	code, stdout, _ := runCommand(t, "--server", server.URL, "list", "--vendor", "symfony")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "NAME\nsymfony/console\nsymfony/http-kernel\n", stdout)
+
+	code, stdout, _ = runCommand(t, "list", "--server", server.URL, "--format", "json", "--filter", "log")
+	assert.Equal(t, exitOK, code)
+	assert.JSONEq(t, `["monolog/monolog"]`, stdout)
+}
+
+func TestRun_Stats(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	code, stdout, _ := runCommand(t, "--server", server.URL, "--format", "csv", "stats")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "field,value\ndownloads,1000\npackages,3\nversions,30\n", stdout)
+
+	code, stdout, _ = runCommand(t, "--server", server.URL, "stats", "monolog/monolog")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "monthly  90")
+
+	code, _, stderr := runCommand(t, "--server", server.URL, "stats", "not/exists")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "package not found")
+}
+
+func TestRun_Show(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	code, stdout, _ := runCommand(t, "--server", server.URL, "show", "monolog/monolog")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "latest             3.5.0\n")
+	assert.Contains(t, stdout, "maintainers        Seldaek\n")
+
+	code, stdout, _ = runCommand(t, "--server", server.URL, "--format", "csv", "show", "--versions", "monolog/monolog")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "version,released,license,dist,reference\n"+
+		"3.6.0-RC1,,,,\n"+
+		"3.5.0,2023-10-27,MIT,zip,c915e2634718dbc8a4a15c61b0e62e7a44e14448\n"+
+		"2.9.2,,MIT,,\n"+
+		"dev-main,,,,\n", stdout)
+}
+
+func TestRun_Advisories(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	code, stdout, _ := runCommand(t, "--server", server.URL, "--format", "csv", "advisories", "symfony/http-kernel")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "package,advisory,cve,severity,affected,title\n"+
+		"symfony/http-kernel,PKSA-1,CVE-2022-24894,medium,\">=5.0.0,<5.4.20\",Cookie leak\n"+
+		"symfony/http-kernel,PKSA-2,,low,\">=2.0.0,<2.0.1\",Old issue\n", stdout)
+
+	code, _, _ = runCommand(t, "--server", server.URL, "advisories", "--since", "2023-01-01")
+	assert.Equal(t, exitOK, code)
+
+	// --since is sent to Packagist as a Unix timestamp in seconds
+	var updatedSince string
+	sinceServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		updatedSince = r.URL.Query().Get("updatedSince")
+		w.Write([]byte(`{"advisories": {}}`))
+	}))
+	defer sinceServer.Close()
+	code, _, _ = runCommand(t, "--server", sinceServer.URL, "advisories", "--since", "2023-01-01")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "1672531200", updatedSince)
+
+	code, _, stderr := runCommand(t, "--server", server.URL, "advisories")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "either packages or --since is required")
+}
+
+func TestRun_Audit(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	lockFile := filepath.Join(t.TempDir(), "composer.lock")
+	assert.NoError(t, os.WriteFile(lockFile, []byte(`{
+		"packages": [{"name": "symfony/http-kernel", "version": "v5.4.19"}, {"name": "monolog/monolog", "version": "3.5.0"}],
+		"packages-dev": []
+	}`), 0o644))
+
+	code, stdout, stderr := runCommand(t, "--server", server.URL, "--format", "json", "audit", lockFile)
+	assert.Equal(t, exitFindings, code)
+	assert.Contains(t, stderr, "found 1 advisories")
+	var findings []*finding
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &findings))
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, "PKSA-1", findings[0].Advisory.AdvisoryID)
+		assert.Equal(t, "v5.4.19", findings[0].Installed)
+	}
+
+	code, _, _ = runCommand(t, "--server", server.URL, "audit", "--min-severity", "high", lockFile)
+	assert.Equal(t, exitOK, code)
+
+	code, _, _ = runCommand(t, "--server", server.URL, "audit", "--min-severity", "urgent", lockFile)
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRun_AuditUnknownSeverity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"advisories": {"symfony/http-kernel": [
+			{"advisoryId": "PKSA-1", "packageName": "symfony/http-kernel", "title": "Cookie leak", "affectedVersions": ">=5.0.0,<5.4.20", "severity": "low"},
+			{"advisoryId": "PKSA-2", "packageName": "symfony/http-kernel", "title": "No severity", "affectedVersions": ">=5.0.0,<5.4.20"},
+			{"advisoryId": "PKSA-3", "packageName": "symfony/http-kernel", "title": "Odd severity", "affectedVersions": ">=5.0.0,<5.4.20", "severity": "important"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	lockFile := filepath.Join(t.TempDir(), "composer.lock")
+	assert.NoError(t, os.WriteFile(lockFile, []byte(`{"packages": [{"name": "symfony/http-kernel", "version": "v5.4.19"}]}`), 0o644))
+
+	// Advisories without a known severity survive --min-severity and are labelled
+	code, stdout, _ := runCommand(t, "--server", server.URL, "--format", "json", "audit", "--min-severity", "high", lockFile)
+	assert.Equal(t, exitFindings, code)
+	var findings []*finding
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &findings))
+	if assert.Len(t, findings, 2) {
+		assert.Equal(t, "PKSA-2", findings[0].Advisory.AdvisoryID)
+		assert.True(t, findings[0].SeverityUnknown)
+		assert.Equal(t, "PKSA-3", findings[1].Advisory.AdvisoryID)
+		assert.True(t, findings[1].SeverityUnknown)
+	}
+
+	code, stdout, _ = runCommand(t, "--server", server.URL, "--format", "csv", "audit", "--min-severity", "high", lockFile)
+	assert.Equal(t, exitFindings, code)
+	assert.Contains(t, stdout, ",unknown,")
+	assert.Contains(t, stdout, ",important (unknown),")
+	assert.NotContains(t, stdout, "PKSA-1")
+}
+
+func TestRun_DownloadIndex(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	code, stdout, _ := runCommand(t, "--server", server.URL, "download-index")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "symfony/console")
+
+	output := filepath.Join(t.TempDir(), "list.json")
+	code, _, stderr := runCommand(t, "--server", server.URL, "download-index", "-o", output)
+	assert.Equal(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(stderr, "wrote "))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "monolog/monolog")
+}
+
+func TestRun_Mirror(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	db := filepath.Join(dir, "mirror.db")
+
+	// The first run has no changes timestamp yet and falls back to a full crawl
+	code, stdout, stderr := runCommand(t, "--server", server.URL, "--format", "json", "mirror", "--db", db, "--workers", "2")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stderr, "crawling all packages")
+	stats := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &stats))
+	assert.Equal(t, float64(3), stats["Total"])
+	assert.Equal(t, float64(1), stats["Created"])
+	// Packages that no longer exist upstream are removed from the mirror
+	assert.Equal(t, float64(2), stats["Deleted"])
+	assert.FileExists(t, db+".checkpoint.json")
+
+	// Only the given packages
+	code, stdout, _ = runCommand(t, "--server", server.URL, "mirror", "--db", db, "monolog/monolog")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "total      1")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/crawler"
+	"github.com/scagogogo/composer-crawler/pkg/store"
+)
+
+var mirrorCommand = &command{
+	name:        "mirror",
+	args:        "[package...]",
+	description: "Mirror package metadata into a local store, incrementally after the first run",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		db := flags.String("db", "composer-crawler.db", "path of the bbolt database file")
+		mongoURI := flags.String("mongo-uri", "", "store packages in MongoDB instead of a bbolt file")
+		checkpoint := flags.String("checkpoint", "", "checkpoint file used to resume (default <db>.checkpoint.json)")
+		workers := flags.Int("workers", crawler.DefaultWorkers, "number of concurrent workers")
+		full := flags.Bool("full", false, "crawl every package instead of only the changed ones")
+		verbose := flags.Bool("v", false, "print every failed package while crawling")
+		return func(ctx context.Context, env *environment, args []string) error {
+			var packageStore store.PackageStore
+			var err error
+			if *mongoURI != "" {
+				packageStore, err = store.NewMongoStore(ctx, &store.MongoStoreOptions{URI: *mongoURI})
+			} else {
+				packageStore, err = store.OpenBoltStore(*db)
+			}
+			if err != nil {
+				return fmt.Errorf("open store: %w", err)
+			}
+			defer packageStore.Close()
+
+			checkpointFile := *checkpoint
+			if checkpointFile == "" {
+				name := *db
+				if *mongoURI != "" {
+					name = "composer-crawler-mongo"
+				}
+				checkpointFile = name + ".checkpoint.json"
+			}
+			c, err := crawler.NewCrawler(&crawler.Options{
+				Repository:     env.repository,
+				Store:          packageStore,
+				Workers:        *workers,
+				CheckpointFile: checkpointFile,
+				OnResult: func(result *crawler.Result) {
+					if *verbose && result.Err != nil {
+						fmt.Fprintf(env.stderr, "%s (attempt %d): %v\n", result.PackageName, result.Attempt, result.Err)
+					}
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			var stats *crawler.Stats
+			switch {
+			case len(args) > 0:
+				stats, err = c.Crawl(ctx, args)
+			case *full:
+				stats, err = c.CrawlAll(ctx)
+			default:
+				stats, err = c.CrawlChanges(ctx)
+				if errors.Is(err, crawler.ErrNoChangesTimestamp) || errors.Is(err, crawler.ErrResyncRequired) {
+					fmt.Fprintf(env.stderr, "%v, crawling all packages\n", err)
+					stats, err = c.CrawlAll(ctx)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := make([]string, 0, len(stats.Failures))
+			for _, failure := range stats.Failures {
+				failed = append(failed, failure.PackageName)
+			}
+			if err := env.printer.printKeyValues(stats, [][2]string{
+				{"total", strconv.Itoa(stats.Total)},
+				{"succeeded", strconv.Itoa(stats.Succeeded)},
+				{"created", strconv.Itoa(stats.Created)},
+				{"changed", strconv.Itoa(stats.Changed)},
+				{"deleted", strconv.Itoa(stats.Deleted)},
+				{"errors", strconv.Itoa(stats.Errors)},
+				{"failures", strings.Join(failed, ", ")},
+			}); err != nil {
+				return err
+			}
+			if len(stats.Failures) > 0 {
+				return errFindings
+			}
+			return nil
+		}
+	},
+}
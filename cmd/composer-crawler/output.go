@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// 支持的输出格式
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// printer 按照 --format 输出结果，json 格式输出原始的结构，table 和 csv 格式输出表格
+type printer struct {
+	format string
+	writer io.Writer
+}
+
+func newPrinter(format string, writer io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{format: format, writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected table, json or csv", format)
+	}
+}
+
+// print 输出结果，value 用于 json 格式，header 和 rows 用于 table 和 csv 格式
+func (x *printer) print(value interface{}, header []string, rows [][]string) error {
+	switch x.format {
+	case formatJSON:
+		encoder := json.NewEncoder(x.writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case formatCSV:
+		writer := csv.NewWriter(x.writer)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		writer := tabwriter.NewWriter(x.writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				// 表格中不能有换行和制表符
+				cells[i] = strings.NewReplacer("\n", " ", "\r", " ", "\t", " ").Replace(cell)
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+// printKeyValues 输出单个对象，table 和 csv 格式下每个字段一行
+func (x *printer) printKeyValues(value interface{}, pairs [][2]string) error {
+	rows := make([][]string, 0, len(pairs))
+	for _, pair := range pairs {
+		rows = append(rows, []string{pair[0], pair[1]})
+	}
+	return x.print(value, []string{"field", "value"}, rows)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+var listCommand = &command{
+	name:        "list",
+	description: "List package names in the repository",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		vendor := flags.String("vendor", "", "only list packages of this vendor")
+		filter := flags.String("filter", "", "only list packages whose name contains this string")
+		return func(ctx context.Context, env *environment, args []string) error {
+			packages, err := env.repository.List(ctx)
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(packages))
+			for _, p := range packages {
+				name := strings.ToLower(p.Name)
+				if *vendor != "" && !strings.HasPrefix(name, strings.ToLower(*vendor)+"/") {
+					continue
+				}
+				if *filter != "" && !strings.Contains(name, strings.ToLower(*filter)) {
+					continue
+				}
+				names = append(names, p.Name)
+			}
+			rows := make([][]string, 0, len(names))
+			for _, name := range names {
+				rows = append(rows, []string{name})
+			}
+			return env.printer.print(names, []string{"name"}, rows)
+		}
+	},
+}
+
+var statsCommand = &command{
+	name:        "stats",
+	args:        "[package]",
+	description: "Show repository totals, or download statistics of a package",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		return func(ctx context.Context, env *environment, args []string) error {
+			switch len(args) {
+			case 0:
+				statistics, err := env.repository.Statistics(ctx)
+				if err != nil {
+					return err
+				}
+				return env.printer.printKeyValues(statistics, [][2]string{
+					{"downloads", strconv.FormatInt(statistics.Totals.Downloads, 10)},
+					{"packages", strconv.Itoa(statistics.Totals.Packages)},
+					{"versions", strconv.Itoa(statistics.Totals.Versions)},
+				})
+			case 1:
+				statistics, err := env.repository.GetPackageStatistics(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				return env.printer.printKeyValues(statistics, [][2]string{
+					{"total", strconv.FormatInt(statistics.Downloads.Total, 10)},
+					{"monthly", strconv.FormatInt(statistics.Downloads.Monthly, 10)},
+					{"daily", strconv.FormatInt(statistics.Downloads.Daily, 10)},
+				})
+			default:
+				return usageError("stats takes at most one package name")
+			}
+		}
+	},
+}
+
+var showCommand = &command{
+	name:        "show",
+	args:        "<package>",
+	description: "Show package metadata",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		versions := flags.Bool("versions", false, "list all versions instead of the package summary")
+		return func(ctx context.Context, env *environment, args []string) error {
+			if len(args) != 1 {
+				return usageError("show takes exactly one package name")
+			}
+			info, err := env.repository.GetPackage(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			if *versions {
+				return printVersions(env, info)
+			}
+
+			p := info.Package
+			maintainers := make([]string, 0, len(p.Maintainers))
+			for _, maintainer := range p.Maintainers {
+				maintainers = append(maintainers, maintainer.Name)
+			}
+			abandoned := "no"
+			if p.Abandoned.IsAbandoned {
+				abandoned = "yes"
+				if p.Abandoned.Replacement != "" {
+					abandoned = "use " + p.Abandoned.Replacement
+				}
+			}
+			latest := ""
+			if version := latestStable(p.Versions); version != nil {
+				latest = version.Version
+			}
+			return env.printer.printKeyValues(info, [][2]string{
+				{"name", p.Name},
+				{"description", p.Description},
+				{"type", p.Type},
+				{"repository", p.Repository},
+				{"maintainers", strings.Join(maintainers, ", ")},
+				{"latest", latest},
+				{"versions", strconv.Itoa(len(p.Versions))},
+				{"downloads", strconv.Itoa(p.Downloads.Total)},
+				{"monthly downloads", strconv.Itoa(p.Downloads.Monthly)},
+				{"favers", strconv.Itoa(p.Favers)},
+				{"abandoned", abandoned},
+			})
+		}
+	},
+}
+
+// 按照版本从新到旧输出所有版本
+func printVersions(env *environment, info *composer_crawler.ComposerPackageInfo) error {
+	versions := sortedVersions(info.Package.Versions)
+	rows := make([][]string, 0, len(versions))
+	for _, version := range versions {
+		released := ""
+		if !version.Time.IsZero() {
+			released = version.Time.UTC().Format("2006-01-02")
+		}
+		rows = append(rows, []string{version.Version, released, strings.Join(version.License, ", "), version.Dist.Type, version.Dist.Reference})
+	}
+	return env.printer.print(versions, []string{"version", "released", "license", "dist", "reference"}, rows)
+}
+
+// 按照版本号从新到旧排序，分支版本放在最后
+func sortedVersions(versions map[string]*composer_crawler.Version) []*composer_crawler.Version {
+	result := make([]*composer_crawler.Version, 0, len(versions))
+	parsed := make(map[*composer_crawler.Version]*semver.Version, len(versions))
+	for name, version := range versions {
+		if version.Version == "" {
+			version.Version = name
+		}
+		if v, err := semver.Parse(version.Version); err == nil {
+			parsed[version] = v
+		}
+		result = append(result, version)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := parsed[result[i]], parsed[result[j]]
+		switch {
+		case a == nil || b == nil:
+			if (a == nil) != (b == nil) {
+				return b == nil
+			}
+			return result[i].Version < result[j].Version
+		case a.IsBranch() != b.IsBranch():
+			return !a.IsBranch()
+		default:
+			if c := semver.Compare(a, b); c != 0 {
+				return c > 0
+			}
+			return result[i].Version < result[j].Version
+		}
+	})
+	return result
+}
+
+func latestStable(versions map[string]*composer_crawler.Version) *composer_crawler.Version {
+	for _, version := range sortedVersions(versions) {
+		if semver.StabilityOf(version.Version) == semver.StabilityStable {
+			return version
+		}
+	}
+	return nil
+}
+
+var downloadIndexCommand = &command{
+	name:        "download-index",
+	description: "Download the package name index (packages/list.json)",
+	setup: func(flags *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+		output := flags.String("o", "", "write the index to this file instead of stdout")
+		return func(ctx context.Context, env *environment, args []string) error {
+			data, err := env.repository.DownloadIndex(ctx)
+			if err != nil {
+				return err
+			}
+			if *output == "" {
+				_, err = env.stdout.Write(data)
+				return err
+			}
+			if err := os.WriteFile(*output, data, 0o644); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(env.stderr, "wrote %d bytes to %s\n", len(data), *output)
+			return err
+		}
+	},
+}
@@ -1,6 +1,13 @@
 package composer_crawler
 
-import "time"
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+)
 
 type ComposerPackageInfo struct {
 
@@ -54,6 +61,9 @@ type ComposerPackageInfo struct {
 			Daily int `json:"daily" bson:"daily"`
 		} `json:"downloads" bson:"downloads"`
 		Favers int `json:"favers" bson:"favers"`
+
+		// 包是否被废弃，可能是 true，也可能是推荐的替代包的名字
+		Abandoned manifest.Abandoned `json:"abandoned" bson:"abandoned"`
 	} `json:"package" bson:"package"`
 
 	// 包相关信息的md5，用来识别信息是否较之前发生了变化
@@ -65,6 +75,28 @@ type ComposerPackageInfo struct {
 	ChangeTime *time.Time `json:"change_time" bson:"change_time"`
 }
 
+// ComputePackageInfoMd5 计算包信息的md5，下载量、star 数这些每天都在变的统计数字不参与计算，
+// 所以只有包的元数据（描述、维护者、版本等）变化的时候md5才会变化
+func (x *ComposerPackageInfo) ComputePackageInfoMd5() (string, error) {
+	pkg := x.Package
+	pkg.GithubStars = 0
+	pkg.GithubWatchers = 0
+	pkg.GithubForks = 0
+	pkg.GithubOpenIssues = 0
+	pkg.Dependents = 0
+	pkg.Suggesters = 0
+	pkg.Favers = 0
+	pkg.Downloads.Total = 0
+	pkg.Downloads.Monthly = 0
+	pkg.Downloads.Daily = 0
+	bytes, err := json.Marshal(pkg)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // Maintainers 表示一个包维护者相关的信息
 type Maintainers struct {
 	// 维护者的名字
@@ -119,10 +151,22 @@ type Version struct {
 
 	// 这个版本所依赖的其它包的其它版本
 	Require map[string]string `json:"require" bson:"require"`
-
-	// 2022-5-29 20:57: 我他妈都不清楚这些字段是啥意思，也不想去弄清楚了，直接Interface吧，后面用到的话再来搞明白啥意思
-	Autoload interface{} `json:"autoload" bson:"autoload"`
-	Extra    interface{} `json:"extra" bson:"extra"`
-	Suggest  interface{} `json:"suggest" bson:"suggest"`
-	Provide  interface{} `json:"provide" bson:"provide"`
+	// 开发时才需要的依赖
+	RequireDev manifest.Links `json:"require-dev,omitempty" bson:"require-dev,omitempty"`
+	// 不能和这个版本同时安装的包
+	Conflict manifest.Links `json:"conflict,omitempty" bson:"conflict,omitempty"`
+	// 这个版本替代了的包，安装了它之后就不会再安装这些包
+	Replace manifest.Links `json:"replace,omitempty" bson:"replace,omitempty"`
+	// 这个版本提供了实现的包，通常是 psr/log-implementation 这种虚拟包
+	Provide manifest.Links `json:"provide" bson:"provide"`
+	// 建议安装的包，值是一段说明而不是版本约束
+	Suggest manifest.Links `json:"suggest" bson:"suggest"`
+
+	// 自动加载的规则：psr-4、psr-0、classmap、files 以及 exclude-from-classmap
+	Autoload *manifest.Autoload `json:"autoload" bson:"autoload"`
+
+	// extra 的内容由各个插件自行约定，只保留原始的 JSON，使用 ExtraValue、BranchAliases 按需解析
+	Extra json.RawMessage `json:"extra" bson:"extra"`
+
+	Scripts interface{} `json:"scripts,omitempty" bson:"scripts,omitempty"`
 }
@@ -0,0 +1,31 @@
+package composer_crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposerPackageInfo_ComputePackageInfoMd5(t *testing.T) {
+	info := &ComposerPackageInfo{}
+	info.Package.Name = "vendor/package"
+	info.Package.Description = "description"
+	md5, err := info.ComputePackageInfoMd5()
+	assert.NoError(t, err)
+	assert.Len(t, md5, 32)
+
+	// Statistics do not affect the md5
+	info.Package.Downloads.Daily = 10
+	info.Package.GithubStars = 20
+	info.Package.Favers = 30
+	statisticsChanged, err := info.ComputePackageInfoMd5()
+	assert.NoError(t, err)
+	assert.Equal(t, md5, statisticsChanged)
+	assert.Equal(t, 10, info.Package.Downloads.Daily)
+
+	// Metadata does
+	info.Package.Description = "changed"
+	descriptionChanged, err := info.ComputePackageInfoMd5()
+	assert.NoError(t, err)
+	assert.NotEqual(t, md5, descriptionChanged)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/scagogogo/composer-crawler/pkg/repository"
@@ -24,22 +25,23 @@ func main() {
 
 	// 步骤 2: 初始化一个仓库客户端
 	// --------------------------
-	// 直接创建 Repository 实例
-	repo := &repository.Repository{
-		// 使用选项初始化
-		// 注意: 实际使用中，repository.Repository 的内部字段可能不对外导出
-		// 此处仅作示例，实际使用请参考代码库中可能提供的构造函数
-	}
+	// 使用 NewRepository 创建仓库客户端，options 为 nil 或者没有设置 ServerUrl 的时候使用官方仓库
+	repo := repository.NewRepository(options)
 
-	// 由于访问不到 Repository 的内部字段，我们先打印 options 来演示
+	// 步骤 3: 使用客户端发起一个简单的请求，确认可以正常访问仓库
+	// ---------------------------------------------
 	fmt.Println("仓库客户端初始化示例")
 	fmt.Printf("仓库 URL: %s\n", options.ServerUrl)
 
-	// 注意: 在实际应用中，应使用更合适的方式初始化仓库对象
-	// 这里仅用于演示类型的创建
-	_ = repo // 防止未使用变量警告
+	stats, err := repo.Statistics(context.Background())
+	if err != nil {
+		fmt.Printf("访问仓库失败: %v\n", err)
+		return
+	}
+	fmt.Printf("仓库中共有 %d 个包\n", stats.Totals.Packages)
 
 	// 输出示例：
 	// 仓库客户端初始化示例
 	// 仓库 URL: https://packagist.org
+	// 仓库中共有 400000 个包
 }
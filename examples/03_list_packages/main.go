@@ -21,17 +21,8 @@ func main() {
 	}
 
 	// 创建仓库客户端
-	// 注意: 由于 Repository 结构体的内部字段可能不对外导出，
-	// 这里我们需要假设有一个可用的初始化方法
 	fmt.Printf("使用服务器 URL: %s\n", options.ServerUrl)
-
-	// 在真实代码中，通常会有一个如下的初始化方法：
-	// repo := repository.NewRepository(options)
-	// 但由于库的设计不同，这里我们直接创建示例实例
-	repo := &repository.Repository{}
-
-	// 仅为了避免未使用变量的警告
-	_ = options
+	repo := repository.NewRepository(options)
 
 	// 步骤 2: 列出所有包
 	// ---------------
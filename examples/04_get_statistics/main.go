@@ -21,11 +21,7 @@ func main() {
 	}
 
 	// 创建仓库客户端
-	// 在真实代码中可能会有专门的构造函数
-	repo := &repository.Repository{}
-
-	// 仅为了避免未使用变量的警告
-	_ = options
+	repo := repository.NewRepository(options)
 
 	// 步骤 2: 获取统计数据
 	// -----------------
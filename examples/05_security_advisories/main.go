@@ -23,10 +23,7 @@ func main() {
 	}
 
 	// 创建仓库客户端
-	repo := &repository.Repository{}
-
-	// 仅为了避免未使用变量的警告
-	_ = options
+	repo := repository.NewRepository(options)
 
 	// 创建上下文
 	ctx := context.Background()
@@ -0,0 +1,240 @@
+package abandoned
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+const (
+	// SourceLock 废弃信息来自 composer.lock
+	SourceLock = "lock"
+
+	// SourceRepository 废弃信息来自仓库中最新的包信息
+	SourceRepository = "repository"
+)
+
+// Options 扫描的配置
+type Options struct {
+
+	// 用来获取最新的废弃状态以及替代包的信息，为空的时候只使用 composer.lock 中记录的废弃状态，也不检查替代包
+	Repository *repository.Repository
+}
+
+// Finding 表示一个被废弃的依赖
+type Finding struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dev     bool   `json:"dev"`
+
+	// 推荐的替代包，可能为空
+	Replacement string `json:"replacement,omitempty"`
+
+	// 废弃信息的来源，SourceLock 或者 SourceRepository
+	Source string `json:"source"`
+
+	// 被废弃的包对外承诺的契约：包名本身以及它的 provide、replace
+	Contracts []string `json:"contracts"`
+
+	// 用来检查的替代包的版本，一般是最新的稳定版本
+	ReplacementVersion string `json:"replacementVersion,omitempty"`
+
+	// 替代包同样满足的契约和不满足的契约
+	Satisfied []string `json:"satisfied,omitempty"`
+	Missing   []string `json:"missing,omitempty"`
+
+	// 替代包通过 replace 或者 provide 直接声明了可以替代被废弃的包
+	DropIn bool `json:"dropIn"`
+
+	// 获取包信息失败时的错误
+	Error string `json:"error,omitempty"`
+}
+
+// SatisfiesContracts 替代包是否满足被废弃的包除了包名之外的所有 provide、replace 契约
+func (x *Finding) SatisfiesContracts() bool {
+	if x.Replacement == "" || x.ReplacementVersion == "" {
+		return false
+	}
+	for _, missing := range x.Missing {
+		if !strings.EqualFold(missing, x.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Report 表示一个项目的废弃依赖扫描结果
+type Report struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Findings    []*Finding `json:"findings"`
+}
+
+// Scan 扫描 composer.lock 中被废弃的依赖，给出替代包的建议以及替代包是否满足相同的 provide、replace 契约
+func Scan(ctx context.Context, lock *manifest.ComposerLock, options *Options) (*Report, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	report := &Report{GeneratedAt: time.Now(), Findings: make([]*Finding, 0)}
+	replacements := make(map[string]*composer_crawler.ComposerPackageInfo)
+
+	for _, lockPackage := range lock.AllPackages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		finding := &Finding{
+			Name:      lockPackage.Name,
+			Version:   lockPackage.Version,
+			Dev:       lock.IsDev(lockPackage.Name),
+			Source:    SourceLock,
+			Contracts: contractsOf(lockPackage),
+		}
+		isAbandoned := false
+		if lockPackage.Abandoned != nil && lockPackage.Abandoned.IsAbandoned {
+			isAbandoned = true
+			finding.Replacement = lockPackage.Abandoned.Replacement
+		}
+
+		if options.Repository != nil {
+			info, err := options.Repository.GetPackage(ctx, lockPackage.Name)
+			switch {
+			case err == nil:
+				// 仓库中的废弃状态比 composer.lock 中记录的更新
+				if info.Package.Abandoned.IsAbandoned {
+					isAbandoned = true
+					finding.Source = SourceRepository
+					finding.Replacement = info.Package.Abandoned.Replacement
+				}
+			case ctx.Err() != nil:
+				return nil, ctx.Err()
+			default:
+				finding.Error = err.Error()
+			}
+		}
+		if !isAbandoned {
+			continue
+		}
+		report.Findings = append(report.Findings, finding)
+
+		if options.Repository == nil || finding.Replacement == "" {
+			continue
+		}
+		replacementKey := strings.ToLower(finding.Replacement)
+		replacement, ok := replacements[replacementKey]
+		if !ok {
+			info, err := options.Repository.GetPackage(ctx, finding.Replacement)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				if !errors.Is(err, repository.ErrPackageNotFound) {
+					finding.Error = err.Error()
+					continue
+				}
+			}
+			replacement = info
+			replacements[replacementKey] = replacement
+		}
+		checkReplacement(finding, replacement)
+	}
+	return report, nil
+}
+
+// 检查替代包最新的版本是否满足被废弃的包的契约
+func checkReplacement(finding *Finding, replacement *composer_crawler.ComposerPackageInfo) {
+	if replacement == nil {
+		finding.Error = "replacement " + finding.Replacement + " not found"
+		return
+	}
+	name, version := latestVersion(replacement.Package.Versions)
+	if version == nil {
+		finding.Error = "replacement " + finding.Replacement + " has no versions"
+		return
+	}
+	finding.ReplacementVersion = name
+
+	provided := make(map[string]bool)
+	provided[strings.ToLower(finding.Replacement)] = true
+	for _, link := range append(linkNames(version.Provide), linkNames(version.Replace)...) {
+		provided[strings.ToLower(link)] = true
+	}
+	finding.DropIn = provided[strings.ToLower(finding.Name)]
+	for _, contract := range finding.Contracts {
+		if provided[strings.ToLower(contract)] {
+			finding.Satisfied = append(finding.Satisfied, contract)
+		} else {
+			finding.Missing = append(finding.Missing, contract)
+		}
+	}
+}
+
+// 包名本身以及 provide、replace 中声明的包，平台包除外
+func contractsOf(lockPackage *manifest.LockPackage) []string {
+	contracts := []string{lockPackage.Name}
+	names := make([]string, 0)
+	for name := range lockPackage.Provide {
+		names = append(names, name)
+	}
+	for name := range lockPackage.Replace {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !manifest.IsPlatformPackage(name) && !strings.EqualFold(name, lockPackage.Name) {
+			contracts = append(contracts, name)
+		}
+	}
+	return contracts
+}
+
+// 最新的稳定版本，没有稳定版本的时候使用最新的非 dev 版本，都没有的时候使用任意一个版本
+func latestVersion(versions map[string]*composer_crawler.Version) (string, *composer_crawler.Version) {
+	var latestName string
+	var latest *semver.Version
+	for name, version := range versions {
+		v, err := semver.Parse(name)
+		if err != nil || version == nil {
+			continue
+		}
+		if latest == nil || rank(latest) < rank(v) || (rank(latest) == rank(v) && latest.LessThan(v)) {
+			latest, latestName = v, name
+		}
+	}
+	if latest == nil {
+		for name, version := range versions {
+			if version != nil && (latestName == "" || name < latestName) {
+				latestName = name
+			}
+		}
+	}
+	return latestName, versions[latestName]
+}
+
+// 稳定版本优先于预发布版本，预发布版本优先于 dev 版本
+func rank(v *semver.Version) int {
+	switch v.Stability() {
+	case semver.StabilityStable:
+		return 2
+	case semver.StabilityDev:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// 取出 provide、replace 中的包名，按字母顺序排列
+func linkNames(links manifest.Links) []string {
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package abandoned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLock = `{
+	"packages": [
+		{"name": "swiftmailer/swiftmailer", "version": "v6.3.0", "abandoned": "symfony/mailer"},
+		{"name": "php-http/guzzle6-adapter", "version": "v2.0.2", "provide": {"php-http/client-implementation": "1.0", "psr/http-client-implementation": "1.0"}},
+		{"name": "monolog/monolog", "version": "2.9.1", "provide": {"psr/log-implementation": "1.0.0 || 2.0.0 || 3.0.0"}}
+	],
+	"packages-dev": [
+		{"name": "fzaninotto/faker", "version": "v1.9.2", "abandoned": true}
+	]
+}`
+
+var testPackages = map[string]string{
+	"swiftmailer/swiftmailer":  `{"package": {"name": "swiftmailer/swiftmailer", "abandoned": "symfony/mailer"}}`,
+	"php-http/guzzle6-adapter": `{"package": {"name": "php-http/guzzle6-adapter", "abandoned": "guzzlehttp/guzzle"}}`,
+	"monolog/monolog":          `{"package": {"name": "monolog/monolog"}}`,
+	"fzaninotto/faker":         `{"package": {"name": "fzaninotto/faker", "abandoned": "fakerphp/faker"}}`,
+	"symfony/mailer": `{"package": {"name": "symfony/mailer", "versions": {
+		"v6.3.0": {"version": "v6.3.0"},
+		"v7.0.0-RC1": {"version": "v7.0.0-RC1"}
+	}}}`,
+	"guzzlehttp/guzzle": `{"package": {"name": "guzzlehttp/guzzle", "versions": {
+		"7.8.0": {"version": "7.8.0", "provide": {"psr/http-client-implementation": "1.0"}},
+		"dev-master": {"version": "dev-master", "provide": {"psr/http-client-implementation": "1.0", "php-http/client-implementation": "1.0"}}
+	}}}`,
+	"fakerphp/faker": `{"package": {"name": "fakerphp/faker", "versions": {
+		"v1.23.0": {"version": "v1.23.0", "replace": {"fzaninotto/faker": "*"}}
+	}}}`,
+}
+
+func createPackagesServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, body := range testPackages {
+			if r.URL.Path == "/packages/"+name+".json" {
+				w.Write([]byte(body))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":"error","message":"Package not found"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScan(t *testing.T) {
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+
+	server := createPackagesServer(t)
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	report, err := Scan(context.Background(), lock, &Options{Repository: repo})
+	assert.NoError(t, err)
+	if !assert.Len(t, report.Findings, 3) {
+		return
+	}
+
+	swiftmailer := report.Findings[0]
+	assert.Equal(t, "symfony/mailer", swiftmailer.Replacement)
+	assert.Equal(t, SourceRepository, swiftmailer.Source)
+	assert.Equal(t, "v6.3.0", swiftmailer.ReplacementVersion)
+	assert.Equal(t, []string{"swiftmailer/swiftmailer"}, swiftmailer.Missing)
+	assert.False(t, swiftmailer.DropIn)
+	assert.True(t, swiftmailer.SatisfiesContracts())
+
+	// Only abandoned in the repository, and the stable replacement misses one of the provided contracts
+	adapter := report.Findings[1]
+	assert.Equal(t, "php-http/guzzle6-adapter", adapter.Name)
+	assert.Equal(t, SourceRepository, adapter.Source)
+	assert.Equal(t, "7.8.0", adapter.ReplacementVersion)
+	assert.Equal(t, []string{"php-http/guzzle6-adapter", "php-http/client-implementation", "psr/http-client-implementation"}, adapter.Contracts)
+	assert.Equal(t, []string{"psr/http-client-implementation"}, adapter.Satisfied)
+	assert.Equal(t, []string{"php-http/guzzle6-adapter", "php-http/client-implementation"}, adapter.Missing)
+	assert.False(t, adapter.SatisfiesContracts())
+
+	faker := report.Findings[2]
+	assert.True(t, faker.Dev)
+	assert.Equal(t, "fakerphp/faker", faker.Replacement)
+	assert.True(t, faker.DropIn)
+	assert.Empty(t, faker.Missing)
+	assert.True(t, faker.SatisfiesContracts())
+}
+
+func TestScan_LockOnly(t *testing.T) {
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+
+	report, err := Scan(context.Background(), lock, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Findings, 2) {
+		assert.Equal(t, "swiftmailer/swiftmailer", report.Findings[0].Name)
+		assert.Equal(t, SourceLock, report.Findings[0].Source)
+		assert.Equal(t, "symfony/mailer", report.Findings[0].Replacement)
+		assert.Empty(t, report.Findings[0].ReplacementVersion)
+		assert.False(t, report.Findings[0].SatisfiesContracts())
+
+		assert.Equal(t, "fzaninotto/faker", report.Findings[1].Name)
+		assert.Empty(t, report.Findings[1].Replacement)
+	}
+}
+
+func TestScan_ReplacementNotFound(t *testing.T) {
+	lock, err := manifest.ParseComposerLock([]byte(`{"packages": [{"name": "acme/old", "version": "1.0.0", "abandoned": "acme/missing"}]}`))
+	assert.NoError(t, err)
+
+	server := createPackagesServer(t)
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	report, err := Scan(context.Background(), lock, &Options{Repository: repo})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Findings, 1) {
+		assert.Equal(t, SourceLock, report.Findings[0].Source)
+		assert.Contains(t, report.Findings[0].Error, "acme/missing")
+	}
+}
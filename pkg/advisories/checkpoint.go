@@ -0,0 +1,67 @@
+package advisories
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint 记录监控的进度，用于程序重启之后从上次的位置继续
+type Checkpoint struct {
+
+	// 下一次轮询时使用的 updatedSince
+	UpdatedSince time.Time `json:"updatedSince"`
+
+	// 已经见过的公告，key 是 AdvisoryID，value 是当时受影响的版本范围
+	Seen map[string]string `json:"seen"`
+}
+
+// NewCheckpoint 创建一个从给定时间开始的检查点
+func NewCheckpoint(updatedSince time.Time) *Checkpoint {
+	return &Checkpoint{
+		UpdatedSince: updatedSince,
+		Seen:         make(map[string]string),
+	}
+}
+
+// LoadCheckpoint 从文件中读取检查点，文件不存在的时候返回 nil 和 nil
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(bytes, checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.Seen == nil {
+		checkpoint.Seen = make(map[string]string)
+	}
+	return checkpoint, nil
+}
+
+// Save 把检查点保存到文件，先写临时文件再重命名，避免写一半的时候进程退出把检查点写坏
+func (x *Checkpoint) Save(path string) error {
+	bytes, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,30 @@
+package advisories
+
+import (
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// EventType 表示监控到的公告变化类型
+type EventType string
+
+const (
+	// EventTypeNew 第一次看到的公告
+	EventTypeNew EventType = "new"
+
+	// EventTypeChanged 之前见过，但是受影响的版本范围发生了变化的公告
+	EventTypeChanged EventType = "changed"
+)
+
+// Event 表示一条需要通知出去的公告变化
+type Event struct {
+	Type     EventType          `json:"type"`
+	Advisory *response.Advisory `json:"advisory"`
+
+	// 变化之前受影响的版本范围，只有 EventTypeChanged 的时候才有值
+	PreviousAffectedVersions string `json:"previousAffectedVersions,omitempty"`
+
+	// 发现这个变化的时间
+	DetectedAt time.Time `json:"detectedAt"`
+}
@@ -0,0 +1,107 @@
+package advisories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Sink 接收监控到的公告变化，Watcher 每轮轮询会把这一轮的所有变化一次性交给 Sink
+type Sink interface {
+	Send(ctx context.Context, events []*Event) error
+}
+
+// ChannelSink 把变化发送到 Go channel 中，channel 满的时候会阻塞直到 ctx 结束
+type ChannelSink chan<- *Event
+
+var _ Sink = ChannelSink(nil)
+
+func (x ChannelSink) Send(ctx context.Context, events []*Event) error {
+	for _, event := range events {
+		select {
+		case x <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WebhookSink 把变化以 JSON 数组的形式 POST 到给定的地址
+type WebhookSink struct {
+	URL string
+
+	// 额外的请求头，比如鉴权用的 token
+	Headers map[string]string
+
+	// 为空的时候使用 http.DefaultClient
+	Client *http.Client
+}
+
+var _ Sink = &WebhookSink{}
+
+func (x *WebhookSink) Send(ctx context.Context, events []*Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, x.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range x.Headers {
+		request.Header.Set(key, value)
+	}
+	client := x.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", x.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// FileSink 把变化以 JSON lines 的格式追加到文件末尾，每个变化一行
+type FileSink struct {
+	Path string
+
+	lock sync.Mutex
+}
+
+var _ Sink = &FileSink{}
+
+func (x *FileSink) Send(ctx context.Context, events []*Event) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	buff := &bytes.Buffer{}
+	encoder := json.NewEncoder(buff)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(x.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buff.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,85 @@
+package advisories
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEvents() []*Event {
+	return []*Event{
+		{Type: EventTypeNew, Advisory: &response.Advisory{AdvisoryID: "PKSA-1", PackageName: "vendor/a", AffectedVersions: "<1.0.0"}},
+		{Type: EventTypeChanged, Advisory: &response.Advisory{AdvisoryID: "PKSA-2", PackageName: "vendor/b", AffectedVersions: "<2.0.1"}, PreviousAffectedVersions: "<2.0.0"},
+	}
+}
+
+func TestChannelSink(t *testing.T) {
+	events := make(chan *Event, 2)
+	assert.NoError(t, ChannelSink(events).Send(context.Background(), testEvents()))
+	assert.Equal(t, "PKSA-1", (<-events).Advisory.AdvisoryID)
+	assert.Equal(t, "PKSA-2", (<-events).Advisory.AdvisoryID)
+
+	// A full channel gives up when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, ChannelSink(make(chan *Event)).Send(ctx, testEvents()), context.Canceled)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []*Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	assert.NoError(t, sink.Send(context.Background(), testEvents()))
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, EventTypeChanged, received[1].Type)
+		assert.Equal(t, "<2.0.0", received[1].PreviousAffectedVersions)
+	}
+
+	unauthorized := &WebhookSink{URL: server.URL}
+	assert.Error(t, unauthorized.Send(context.Background(), testEvents()))
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := &FileSink{Path: path}
+	assert.NoError(t, sink.Send(context.Background(), testEvents()))
+	assert.NoError(t, sink.Send(context.Background(), testEvents()[:1]))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	ids := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := &Event{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), event))
+		ids = append(ids, event.Advisory.AdvisoryID)
+	}
+	assert.Equal(t, []string{"PKSA-1", "PKSA-2", "PKSA-1"}, ids)
+}
+
+func writeFile(path, content string) error {
+	return os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,188 @@
+package advisories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// DefaultInterval 默认的轮询间隔
+const DefaultInterval = 10 * time.Minute
+
+// WatcherOptions 监控的配置
+type WatcherOptions struct {
+
+	// 用来查询公告的仓库，为空的时候使用官方仓库
+	Repository *repository.Repository
+
+	// 轮询间隔，为空的时候使用 DefaultInterval
+	Interval time.Duration
+
+	// 检查点文件的路径，为空的时候检查点只保存在内存中
+	CheckpointFile string
+
+	// 没有检查点的时候从哪个时间开始查询，为空的时候从当前时间开始
+	Since time.Time
+
+	// 接收变化的 Sink，按顺序投递
+	Sinks []Sink
+
+	// Run 的时候单轮轮询出错不会退出，而是把错误交给这个回调，为空的时候忽略错误
+	OnError func(err error)
+}
+
+// Watcher 定时轮询 Packagist 的安全公告，按 AdvisoryID 去重，把新出现的公告和受影响版本发生变化的公告投递给 Sink
+//
+// 只有所有 Sink 都投递成功之后才会推进检查点，所以投递失败的变化会在下一轮重新投递（至少一次）
+type Watcher struct {
+	options    *WatcherOptions
+	repository *repository.Repository
+
+	lock       sync.Mutex
+	checkpoint *Checkpoint
+}
+
+// NewWatcher 创建一个监控，如果配置了检查点文件并且文件存在则从检查点继续
+func NewWatcher(options *WatcherOptions) (*Watcher, error) {
+	if options == nil {
+		options = &WatcherOptions{}
+	}
+	watcher := &Watcher{
+		options:    options,
+		repository: options.Repository,
+	}
+	if watcher.repository == nil {
+		watcher.repository = repository.NewRepository(nil)
+	}
+
+	if options.CheckpointFile != "" {
+		checkpoint, err := LoadCheckpoint(options.CheckpointFile)
+		if err != nil {
+			return nil, fmt.Errorf("load checkpoint %s: %w", options.CheckpointFile, err)
+		}
+		watcher.checkpoint = checkpoint
+	}
+	if watcher.checkpoint == nil {
+		since := options.Since
+		if since.IsZero() {
+			since = time.Now()
+		}
+		watcher.checkpoint = NewCheckpoint(since)
+	}
+	return watcher, nil
+}
+
+// Checkpoint 返回当前检查点的副本
+func (x *Watcher) Checkpoint() *Checkpoint {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	checkpoint := NewCheckpoint(x.checkpoint.UpdatedSince)
+	for id, affectedVersions := range x.checkpoint.Seen {
+		checkpoint.Seen[id] = affectedVersions
+	}
+	return checkpoint
+}
+
+// Poll 执行一轮轮询，返回这一轮发现的变化
+func (x *Watcher) Poll(ctx context.Context) ([]*Event, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	startTime := time.Now()
+	advisories, err := x.repository.ListSecurityAdvisories(ctx, x.checkpoint.UpdatedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	events := x.diff(advisories, startTime)
+	for _, sink := range x.options.Sinks {
+		if len(events) == 0 {
+			break
+		}
+		if err := sink.Send(ctx, events); err != nil {
+			return events, fmt.Errorf("deliver %d events: %w", len(events), err)
+		}
+	}
+
+	// 投递全部成功之后再推进检查点
+	for _, event := range events {
+		x.checkpoint.Seen[event.Advisory.AdvisoryID] = event.Advisory.AffectedVersions
+	}
+	x.checkpoint.UpdatedSince = startTime
+	if x.options.CheckpointFile != "" {
+		if err := x.checkpoint.Save(x.options.CheckpointFile); err != nil {
+			return events, fmt.Errorf("save checkpoint %s: %w", x.options.CheckpointFile, err)
+		}
+	}
+	return events, nil
+}
+
+// Run 按照间隔持续轮询，直到 ctx 结束，启动时会立刻执行一轮；ctx 被取消时返回 nil，超时返回对应的错误
+func (x *Watcher) Run(ctx context.Context) error {
+	interval := x.options.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := x.Poll(ctx); err != nil && ctx.Err() == nil {
+			if x.options.OnError != nil {
+				x.options.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// 和检查点比较找出新的和变化了的公告，结果按包名和 AdvisoryID 排序，保证投递顺序稳定
+func (x *Watcher) diff(advisories *response.AdvisoriesResponse, detectedAt time.Time) []*Event {
+	events := make([]*Event, 0)
+	if advisories == nil {
+		return events
+	}
+	current := make(map[string]bool)
+	for _, packageAdvisories := range advisories.Advisories {
+		for _, advisory := range packageAdvisories {
+			if advisory == nil || advisory.AdvisoryID == "" || current[advisory.AdvisoryID] {
+				continue
+			}
+			current[advisory.AdvisoryID] = true
+
+			previous, seen := x.checkpoint.Seen[advisory.AdvisoryID]
+			switch {
+			case !seen:
+				events = append(events, &Event{Type: EventTypeNew, Advisory: advisory, DetectedAt: detectedAt})
+			case previous != advisory.AffectedVersions:
+				events = append(events, &Event{
+					Type:                     EventTypeChanged,
+					Advisory:                 advisory,
+					PreviousAffectedVersions: previous,
+					DetectedAt:               detectedAt,
+				})
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Advisory.PackageName != events[j].Advisory.PackageName {
+			return events[i].Advisory.PackageName < events[j].Advisory.PackageName
+		}
+		return events[i].Advisory.AdvisoryID < events[j].Advisory.AdvisoryID
+	})
+	return events
+}
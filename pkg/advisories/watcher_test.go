@@ -0,0 +1,217 @@
+package advisories
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/packagisttest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+// advisoryServer serves the security advisories endpoint with a response that can be swapped between polls
+type advisoryServer struct {
+	*httptest.Server
+
+	lock         sync.Mutex
+	response     string
+	updatedSince []string
+}
+
+func newAdvisoryServer(t *testing.T, response string) *advisoryServer {
+	server := &advisoryServer{response: response}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.lock.Lock()
+		defer server.lock.Unlock()
+		server.updatedSince = append(server.updatedSince, r.URL.Query().Get("updatedSince"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(server.response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (x *advisoryServer) setResponse(response string) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.response = response
+}
+
+const firstResponse = `{"advisories": {
+	"vendor/a": [
+		{"advisoryId": "PKSA-2", "packageName": "vendor/a", "affectedVersions": "<1.0.0"},
+		{"advisoryId": "PKSA-1", "packageName": "vendor/a", "affectedVersions": "<2.0.0"}
+	],
+	"vendor/b": [
+		{"advisoryId": "PKSA-3", "packageName": "vendor/b", "affectedVersions": ">=3.0.0,<3.1.0"}
+	]
+}}`
+
+const secondResponse = `{"advisories": {
+	"vendor/a": [
+		{"advisoryId": "PKSA-1", "packageName": "vendor/a", "affectedVersions": "<2.0.0"}
+	],
+	"vendor/b": [
+		{"advisoryId": "PKSA-3", "packageName": "vendor/b", "affectedVersions": ">=3.0.0,<3.1.2"},
+		{"advisoryId": "PKSA-4", "packageName": "vendor/b", "affectedVersions": "<0.9.0"}
+	]
+}}`
+
+func TestWatcher_Poll(t *testing.T) {
+	server := newAdvisoryServer(t, firstResponse)
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.json")
+	since := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := make(chan *Event, 10)
+	watcher, err := NewWatcher(&WatcherOptions{
+		Repository:     repository.NewRepository(&repository.Options{ServerUrl: server.URL}),
+		CheckpointFile: checkpointFile,
+		Since:          since,
+		Sinks:          []Sink{ChannelSink(events)},
+	})
+	assert.NoError(t, err)
+
+	// First poll reports everything as new, ordered by package and id
+	got, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new PKSA-1", "new PKSA-2", "new PKSA-3"}, describe(got))
+	assert.Len(t, events, 3)
+	// Packagist reads updatedSince as a Unix timestamp in seconds
+	assert.Equal(t, "1672531200", server.updatedSince[0])
+
+	// Second poll reports only the changed and the new advisory
+	server.setResponse(secondResponse)
+	got, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"changed PKSA-3", "new PKSA-4"}, describe(got))
+	assert.Equal(t, ">=3.0.0,<3.1.0", got[0].PreviousAffectedVersions)
+	assert.NotEqual(t, server.updatedSince[0], server.updatedSince[1])
+
+	// Polling the same data again reports nothing
+	got, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	// A new watcher resumes from the persisted checkpoint
+	resumed, err := NewWatcher(&WatcherOptions{
+		Repository:     repository.NewRepository(&repository.Options{ServerUrl: server.URL}),
+		CheckpointFile: checkpointFile,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, watcher.Checkpoint().Seen, resumed.Checkpoint().Seen)
+	assert.True(t, watcher.Checkpoint().UpdatedSince.Equal(resumed.Checkpoint().UpdatedSince))
+	assert.Equal(t, ">=3.0.0,<3.1.2", resumed.Checkpoint().Seen["PKSA-3"])
+}
+
+func TestWatcher_PollPackagist(t *testing.T) {
+	server := packagisttest.NewServer(nil)
+	defer server.Close()
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-1", PackageName: "vendor/a", ReportedAt: "2020-01-01 00:00:00", AffectedVersions: "<1.0.0"})
+
+	watcher, err := NewWatcher(&WatcherOptions{
+		Repository: repository.NewRepository(&repository.Options{ServerUrl: server.URL}),
+		Since:      time.Now().Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+
+	got, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new PKSA-1"}, describe(got))
+
+	// An advisory reported long ago but changed after the last poll is picked up again
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-1", PackageName: "vendor/a", ReportedAt: "2020-01-01 00:00:00", AffectedVersions: "<1.0.1"})
+	got, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"changed PKSA-1"}, describe(got))
+	assert.Equal(t, "<1.0.0", got[0].PreviousAffectedVersions)
+
+	got, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+// failingSink fails the first n deliveries
+type failingSink struct {
+	failures int
+	received [][]*Event
+}
+
+func (x *failingSink) Send(ctx context.Context, events []*Event) error {
+	if x.failures > 0 {
+		x.failures--
+		return errors.New("sink unavailable")
+	}
+	x.received = append(x.received, events)
+	return nil
+}
+
+func TestWatcher_PollRedeliversAfterSinkFailure(t *testing.T) {
+	server := newAdvisoryServer(t, firstResponse)
+	since := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink := &failingSink{failures: 1}
+	watcher, err := NewWatcher(&WatcherOptions{
+		Repository: repository.NewRepository(&repository.Options{ServerUrl: server.URL}),
+		Since:      since,
+		Sinks:      []Sink{sink},
+	})
+	assert.NoError(t, err)
+
+	_, err = watcher.Poll(context.Background())
+	assert.Error(t, err)
+	assert.True(t, watcher.Checkpoint().UpdatedSince.Equal(since))
+	assert.Empty(t, watcher.Checkpoint().Seen)
+
+	got, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Len(t, sink.received, 1)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	server := newAdvisoryServer(t, firstResponse)
+	events := make(chan *Event, 10)
+	watcher, err := NewWatcher(&WatcherOptions{
+		Repository: repository.NewRepository(&repository.Options{ServerUrl: server.URL}),
+		Interval:   10 * time.Millisecond,
+		Sinks:      []Sink{ChannelSink(events)},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestNewWatcher_InvalidCheckpoint(t *testing.T) {
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.json")
+	assert.NoError(t, writeFile(checkpointFile, "{invalid"))
+	_, err := NewWatcher(&WatcherOptions{CheckpointFile: checkpointFile})
+	assert.Error(t, err)
+}
+
+func describe(events []*Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, string(event.Type)+" "+event.Advisory.AdvisoryID)
+	}
+	return result
+}
@@ -0,0 +1,414 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+)
+
+// ErrUnknownFormat 无法识别的压缩包格式
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// ErrTooLarge 压缩包本身或者解压之后的内容超过了 Options.MaxSize
+var ErrTooLarge = errors.New("archive too large")
+
+// ErrTooManyEntries 压缩包中的条目数超过了 Options.MaxEntries
+var ErrTooManyEntries = errors.New("archive has too many entries")
+
+// Format 表示压缩包的格式
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTar   Format = "tar"
+	FormatTarGz Format = "tar.gz"
+)
+
+// DefaultMaxScanSize 默认只扫描每个文件开头这么多字节的内容，哈希总是基于完整的内容计算
+const DefaultMaxScanSize = 4 * 1024 * 1024
+
+// DefaultMaxSize 默认最多读取的字节数，压缩包本身和解压之后所有文件的总大小都不能超过它，防止压缩炸弹
+const DefaultMaxSize = 512 * 1024 * 1024
+
+// DefaultMaxEntries 默认最多处理的条目数，包括目录和链接
+const DefaultMaxEntries = 100000
+
+// Options 检查压缩包的配置
+type Options struct {
+
+	// 元数据中的版本信息，用来和压缩包中的 composer.json 对比，为空的时候不对比
+	Version *composer_crawler.Version
+
+	// 每个文件最多扫描的字节数，为空的时候使用 DefaultMaxScanSize
+	MaxScanSize int64
+
+	// 最多读取的字节数，压缩包本身以及解压之后所有文件的总大小超过它的时候返回 ErrTooLarge，为空的时候使用 DefaultMaxSize
+	MaxSize int64
+
+	// 最多处理的条目数，超过的时候返回 ErrTooManyEntries，为空的时候使用 DefaultMaxEntries
+	MaxEntries int
+}
+
+func (x *Options) maxSize() int64 {
+	if x != nil && x.MaxSize > 0 {
+		return x.MaxSize
+	}
+	return DefaultMaxSize
+}
+
+func (x *Options) maxEntries() int {
+	if x != nil && x.MaxEntries > 0 {
+		return x.MaxEntries
+	}
+	return DefaultMaxEntries
+}
+
+// File 表示压缩包中的一个文件
+type File struct {
+	// 去掉公共的顶层目录之后的路径
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Binary bool   `json:"binary"`
+	// 是否在 composer.json 声明的自动加载路径中，只对 PHP 文件有意义
+	Autoloaded bool `json:"autoloaded"`
+}
+
+// Report 表示一个压缩包的检查结果
+type Report struct {
+	Format Format `json:"format"`
+
+	// 压缩包中所有文件共同的顶层目录，比如 GitHub zipball 中的 vendor-package-sha，没有的时候为空
+	Root string `json:"root,omitempty"`
+
+	// 按路径排序的文件列表，不包括目录
+	Files []*File `json:"files"`
+
+	// 压缩包根目录下的 composer.json，没有的时候为空
+	ComposerJSON *manifest.ComposerJSON `json:"composerJson,omitempty"`
+
+	// 压缩包中的 composer.json 和元数据不一致的地方
+	Mismatches []*Mismatch `json:"mismatches,omitempty"`
+
+	Findings []*Finding `json:"findings,omitempty"`
+}
+
+// HasFindings 是否有需要人工确认的问题
+func (x *Report) HasFindings() bool {
+	return len(x.Findings) > 0 || len(x.Mismatches) > 0
+}
+
+// File 根据路径查找文件，找不到的时候返回 nil
+func (x *Report) File(filePath string) *File {
+	for _, file := range x.Files {
+		if file.Path == filePath {
+			return file
+		}
+	}
+	return nil
+}
+
+// Inspect 检查压缩包，根据开头的魔数自动识别 zip、tar、tar.gz 格式。
+// zip 需要随机访问，会先把内容读到内存中，已经有 io.ReaderAt 的时候可以直接使用 InspectZip。
+// 读入的内容以及解压之后的内容都受 Options.MaxSize 的限制
+func Inspect(reader io.Reader, options *Options) (*Report, error) {
+	maxSize := options.maxSize()
+	buffered := bufio.NewReader(io.LimitReader(reader, maxSize+1))
+	magic, _ := buffered.Peek(512)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		data, err := io.ReadAll(buffered)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxSize {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, maxSize)
+		}
+		return InspectZip(bytes.NewReader(data), int64(len(data)), options)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return InspectTarGz(buffered, options)
+	case len(magic) > 262 && string(magic[257:262]) == "ustar":
+		return InspectTar(buffered, options)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// InspectZip 检查 zip 格式的压缩包
+func InspectZip(reader io.ReaderAt, size int64, options *Options) (*Report, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+	inspector := newInspector(FormatZip, options)
+	// 条目数在中央目录中，不用解压就可以检查
+	if len(zipReader.File) > inspector.options.maxEntries() {
+		return nil, fmt.Errorf("%w: %d entries", ErrTooManyEntries, len(zipReader.File))
+	}
+	for _, entry := range zipReader.File {
+		if err := inspector.countEntry(); err != nil {
+			return nil, err
+		}
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		file, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", entry.Name, err)
+		}
+		err = inspector.add(entry.Name, file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inspector.finish()
+}
+
+// InspectTarGz 检查 tar.gz 格式的压缩包
+func InspectTarGz(reader io.Reader, options *Options) (*Report, error) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	report, err := inspectTar(tar.NewReader(gzipReader), options)
+	if err != nil {
+		return nil, err
+	}
+	report.Format = FormatTarGz
+	return report, nil
+}
+
+// InspectTar 检查不压缩的 tar 格式的压缩包，比如 path 类型的 dist 下载下来的内容
+func InspectTar(reader io.Reader, options *Options) (*Report, error) {
+	return inspectTar(tar.NewReader(reader), options)
+}
+
+func inspectTar(reader *tar.Reader, options *Options) (*Report, error) {
+	inspector := newInspector(FormatTar, options)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := inspector.countEntry(); err != nil {
+			return nil, err
+		}
+		// 只关心普通文件，目录、链接等都跳过
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		// GitHub 的 tarball 会带一个 pax_global_header
+		if header.Name == "pax_global_header" {
+			continue
+		}
+		if err := inspector.add(header.Name, reader); err != nil {
+			return nil, err
+		}
+	}
+	return inspector.finish()
+}
+
+type inspector struct {
+	format      Format
+	options     *Options
+	files       []*File
+	findings    []*Finding
+	composerRaw map[string][]byte
+
+	// 已经处理的条目数以及解压出来的字节数
+	entries int
+	size    int64
+}
+
+func newInspector(format Format, options *Options) *inspector {
+	if options == nil {
+		options = &Options{}
+	}
+	return &inspector{
+		format:      format,
+		options:     options,
+		composerRaw: make(map[string][]byte),
+	}
+}
+
+// 每遇到一个条目调用一次，超过 Options.MaxEntries 的时候返回错误
+func (x *inspector) countEntry() error {
+	x.entries++
+	if x.entries > x.options.maxEntries() {
+		return fmt.Errorf("%w: more than %d entries", ErrTooManyEntries, x.options.maxEntries())
+	}
+	return nil
+}
+
+func (x *inspector) maxScanSize() int64 {
+	if x.options.MaxScanSize > 0 {
+		return x.options.MaxScanSize
+	}
+	return DefaultMaxScanSize
+}
+
+// 读取一个文件的内容，计算哈希并扫描开头的内容，不会把整个文件留在内存中
+func (x *inspector) add(name string, reader io.Reader) error {
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	hash := sha256.New()
+	head := &limitedBuffer{limit: x.maxScanSize()}
+	// 每个文件最多只能读到剩下的额度，多读一个字节用来判断是否超过了限制
+	remain := x.options.maxSize() - x.size
+	size, err := io.Copy(io.MultiWriter(hash, head), io.LimitReader(reader, remain+1))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	x.size += size
+	if size > remain {
+		return fmt.Errorf("%w: more than %d bytes after decompression", ErrTooLarge, x.options.maxSize())
+	}
+
+	file := &File{
+		Path:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}
+	content := head.Bytes()
+	if kind, ok := detectBinary(content); ok {
+		file.Binary = true
+		x.findings = append(x.findings, &Finding{Kind: FindingBinary, Path: name, Detail: kind})
+	} else if isPHP(name, content) {
+		for _, pattern := range detectObfuscation(content) {
+			x.findings = append(x.findings, &Finding{Kind: FindingObfuscatedPHP, Path: name, Detail: pattern})
+		}
+	}
+	// composer.json 只可能在根目录或者顶层目录下，先保存下来，等确定了根目录之后再解析
+	if path.Base(name) == "composer.json" && strings.Count(name, "/") <= 1 {
+		x.composerRaw[name] = append([]byte(nil), content...)
+	}
+	x.files = append(x.files, file)
+	return nil
+}
+
+func (x *inspector) finish() (*Report, error) {
+	report := &Report{
+		Format: x.format,
+		Root:   commonRoot(x.files),
+		Files:  x.files,
+	}
+	if report.Root != "" {
+		prefix := report.Root + "/"
+		for _, file := range x.files {
+			file.Path = strings.TrimPrefix(file.Path, prefix)
+		}
+		for _, finding := range x.findings {
+			finding.Path = strings.TrimPrefix(finding.Path, prefix)
+		}
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+
+	if raw, ok := x.composerRaw[path.Join(report.Root, "composer.json")]; ok {
+		composerJSON, err := manifest.ParseComposerJSON(raw)
+		if err != nil {
+			x.findings = append(x.findings, &Finding{Kind: FindingInvalidComposerJSON, Path: "composer.json", Detail: err.Error()})
+		} else {
+			report.ComposerJSON = composerJSON
+		}
+	}
+
+	var autoload *manifest.Autoload
+	var bin []string
+	if report.ComposerJSON != nil {
+		autoload = report.ComposerJSON.Autoload
+		bin = report.ComposerJSON.Bin
+	} else if x.options.Version != nil {
+		autoload = x.options.Version.Autoload
+	}
+	if !autoload.IsEmpty() {
+		for _, file := range report.Files {
+			if !strings.HasSuffix(strings.ToLower(file.Path), ".php") {
+				continue
+			}
+			file.Autoloaded = inPaths(file.Path, autoload.Paths())
+			if !file.Autoloaded && !inPaths(file.Path, bin) {
+				x.findings = append(x.findings, &Finding{Kind: FindingOutsideAutoload, Path: file.Path})
+			}
+		}
+	}
+
+	if x.options.Version != nil {
+		report.Mismatches = Compare(report.ComposerJSON, x.options.Version)
+	}
+
+	sort.SliceStable(x.findings, func(i, j int) bool {
+		if x.findings[i].Path != x.findings[j].Path {
+			return x.findings[i].Path < x.findings[j].Path
+		}
+		return x.findings[i].Kind < x.findings[j].Kind
+	})
+	report.Findings = x.findings
+	return report, nil
+}
+
+// 所有文件都在同一个顶层目录下的时候返回这个目录，composer 解压的时候也会去掉这一层
+func commonRoot(files []*File) string {
+	root := ""
+	for _, file := range files {
+		index := strings.Index(file.Path, "/")
+		if index < 0 {
+			return ""
+		}
+		if root == "" {
+			root = file.Path[:index]
+		} else if root != file.Path[:index] {
+			return ""
+		}
+	}
+	return root
+}
+
+// 判断文件是否在给定的路径下，路径可能是目录也可能是文件
+func inPaths(filePath string, paths []string) bool {
+	for _, p := range paths {
+		p = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+		if p == "" {
+			return true
+		}
+		if filePath == p || strings.HasPrefix(filePath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// 只保留开头 limit 个字节的 io.Writer
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int64
+}
+
+func (x *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := x.limit - int64(x.Len()); remain > 0 {
+		if int64(len(p)) > remain {
+			x.Buffer.Write(p[:remain])
+		} else {
+			x.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,196 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"testing"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testComposerJSON = `{
+	"name": "acme/logger",
+	"type": "library",
+	"license": "MIT",
+	"require": {"php": ">=8.1"},
+	"autoload": {"psr-4": {"Acme\\Logger\\": "src/"}},
+	"bin": ["bin/logger.php"]
+}`
+
+var testArchiveFiles = map[string]string{
+	"composer.json":     testComposerJSON,
+	"src/Logger.php":    "<?php\nnamespace Acme\\Logger;\nclass Logger {}\n",
+	"bin/logger.php":    "<?php\nrequire __DIR__.'/../vendor/autoload.php';\n",
+	"install.php":       "<?php eval(base64_decode('ZWNobyAiaGkiOw=='));\n",
+	"tools/helper":      "\x7fELF\x02\x01\x01\x00\x00\x00",
+	"README.md":         "# Logger\n",
+	"tests/TestCase.md": "not php",
+}
+
+func sortedNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newZip builds a zipball with a top-level directory, like the ones GitHub serves
+func newZip(t *testing.T, root string, files map[string]string) []byte {
+	buff := &bytes.Buffer{}
+	writer := zip.NewWriter(buff)
+	_, err := writer.Create(root + "/")
+	assert.NoError(t, err)
+	for _, name := range sortedNames(files) {
+		file, err := writer.Create(root + "/" + name)
+		assert.NoError(t, err)
+		file.Write([]byte(files[name]))
+	}
+	assert.NoError(t, writer.Close())
+	return buff.Bytes()
+}
+
+func newTarGz(t *testing.T, files map[string]string) []byte {
+	buff := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buff)
+	writer := tar.NewWriter(gzipWriter)
+	for _, name := range sortedNames(files) {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		writer.Write([]byte(files[name]))
+	}
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return buff.Bytes()
+}
+
+func newTestVersion() *composer_crawler.Version {
+	return &composer_crawler.Version{
+		Name:     "acme/logger",
+		Type:     "library",
+		License:  []string{"MIT"},
+		Require:  map[string]string{"php": ">=8.1"},
+		Autoload: &manifest.Autoload{Psr4: map[string]manifest.StringList{"Acme\\Logger\\": {"src/"}}},
+	}
+}
+
+func TestInspectZip(t *testing.T) {
+	data := newZip(t, "acme-logger-1a2b3c4", testArchiveFiles)
+	report, err := InspectZip(bytes.NewReader(data), int64(len(data)), &Options{Version: newTestVersion()})
+	assert.NoError(t, err)
+
+	assert.Equal(t, FormatZip, report.Format)
+	assert.Equal(t, "acme-logger-1a2b3c4", report.Root)
+	assert.Len(t, report.Files, len(testArchiveFiles))
+	assert.Equal(t, "README.md", report.Files[0].Path)
+	if assert.NotNil(t, report.ComposerJSON) {
+		assert.Equal(t, "acme/logger", report.ComposerJSON.Name)
+	}
+	assert.Empty(t, report.Mismatches)
+
+	logger := report.File("src/Logger.php")
+	sum := sha256.Sum256([]byte(testArchiveFiles["src/Logger.php"]))
+	assert.Equal(t, hex.EncodeToString(sum[:]), logger.SHA256)
+	assert.Equal(t, int64(len(testArchiveFiles["src/Logger.php"])), logger.Size)
+	assert.True(t, logger.Autoloaded)
+	assert.True(t, report.File("tools/helper").Binary)
+
+	assert.Equal(t, []*Finding{
+		{Kind: FindingObfuscatedPHP, Path: "install.php", Detail: "eval of decoded payload"},
+		{Kind: FindingOutsideAutoload, Path: "install.php"},
+		{Kind: FindingBinary, Path: "tools/helper", Detail: "ELF executable"},
+	}, report.Findings)
+	assert.True(t, report.HasFindings())
+}
+
+func TestInspect_DetectsFormat(t *testing.T) {
+	files := map[string]string{"composer.json": `{"name": "acme/evil", "require": {"php": ">=7"}}`, "src/A.php": "<?php class A {}"}
+
+	t.Run("tar.gz", func(t *testing.T) {
+		report, err := Inspect(bytes.NewReader(newTarGz(t, files)), &Options{Version: newTestVersion()})
+		assert.NoError(t, err)
+		assert.Equal(t, FormatTarGz, report.Format)
+		assert.Empty(t, report.Root)
+		assert.Equal(t, []*Mismatch{
+			{Field: "name", Metadata: "acme/logger", Archive: "acme/evil"},
+			{Field: "license", Metadata: "MIT", Archive: ""},
+			{Field: "require.php", Metadata: ">=8.1", Archive: ">=7"},
+			{Field: "autoload", Metadata: `{"psr-4":{"Acme\\Logger\\":["src/"]}}`, Archive: ""},
+		}, report.Mismatches)
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		report, err := Inspect(bytes.NewReader(newZip(t, "root", files)), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, FormatZip, report.Format)
+		assert.False(t, report.HasFindings())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := Inspect(bytes.NewReader([]byte("not an archive")), nil)
+		assert.True(t, errors.Is(err, ErrUnknownFormat))
+	})
+}
+
+func TestInspect_MaxScanSize(t *testing.T) {
+	// The payload sits after the scanned prefix, but the hash still covers the whole file
+	content := "<?php\n" + string(bytes.Repeat([]byte(" "), 100)) + "eval(base64_decode('x'));"
+	report, err := InspectTar(bytes.NewReader(newTar(t, map[string]string{"a.php": content})), &Options{MaxScanSize: 50})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Findings)
+	assert.Equal(t, int64(len(content)), report.Files[0].Size)
+}
+
+func newTar(t *testing.T, files map[string]string) []byte {
+	buff := &bytes.Buffer{}
+	writer := tar.NewWriter(buff)
+	for _, name := range sortedNames(files) {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		writer.Write([]byte(files[name]))
+	}
+	assert.NoError(t, writer.Close())
+	return buff.Bytes()
+}
+
+func TestInspect_Limits(t *testing.T) {
+	// A small zip that inflates to much more than it weighs
+	bomb := map[string]string{"a.txt": string(bytes.Repeat([]byte("0"), 64*1024)), "b.txt": string(bytes.Repeat([]byte("0"), 64*1024))}
+	zipData := newZip(t, "root", bomb)
+	assert.Less(t, len(zipData), 4096)
+
+	tests := []struct {
+		name    string
+		inspect func(options *Options) (*Report, error)
+	}{
+		{"zip", func(options *Options) (*Report, error) { return Inspect(bytes.NewReader(zipData), options) }},
+		{"tar.gz", func(options *Options) (*Report, error) {
+			return Inspect(bytes.NewReader(newTarGz(t, bomb)), options)
+		}},
+		{"tar", func(options *Options) (*Report, error) { return InspectTar(bytes.NewReader(newTar(t, bomb)), options) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.inspect(&Options{MaxSize: 100 * 1024})
+			assert.ErrorIs(t, err, ErrTooLarge)
+
+			_, err = tt.inspect(&Options{MaxEntries: 1})
+			assert.ErrorIs(t, err, ErrTooManyEntries)
+
+			report, err := tt.inspect(nil)
+			assert.NoError(t, err)
+			assert.Len(t, report.Files, 2)
+		})
+	}
+
+	// The compressed input itself is limited before it is buffered
+	_, err := Inspect(bytes.NewReader(zipData), &Options{MaxSize: 1024})
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
@@ -0,0 +1,85 @@
+package archive
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+)
+
+// Mismatch 表示压缩包中的 composer.json 和元数据中的版本信息不一致的字段
+type Mismatch struct {
+	// 字段名，依赖的差异是 require.vendor/package 的形式
+	Field string `json:"field"`
+	// 元数据中的值
+	Metadata string `json:"metadata"`
+	// 压缩包中 composer.json 的值
+	Archive string `json:"archive"`
+}
+
+// Compare 对比压缩包中的 composer.json 和元数据中的版本信息，composerJSON 为空的时候表示压缩包中没有 composer.json
+func Compare(composerJSON *manifest.ComposerJSON, version *composer_crawler.Version) []*Mismatch {
+	if version == nil {
+		return nil
+	}
+	if composerJSON == nil {
+		return []*Mismatch{{Field: "composer.json", Metadata: "present", Archive: "missing"}}
+	}
+
+	mismatches := make([]*Mismatch, 0)
+	add := func(field, metadata, archive string) {
+		if metadata != archive {
+			mismatches = append(mismatches, &Mismatch{Field: field, Metadata: metadata, Archive: archive})
+		}
+	}
+
+	// 私有仓库或者 path 类型的 composer.json 中可能没有 name
+	if composerJSON.Name != "" {
+		add("name", strings.ToLower(version.Name), strings.ToLower(composerJSON.Name))
+	}
+	add("type", typeOrDefault(version.Type), typeOrDefault(composerJSON.Type))
+	add("license", joinSorted(version.License), joinSorted(composerJSON.License))
+
+	names := make(map[string]bool)
+	for name := range version.Require {
+		names[name] = true
+	}
+	for name := range composerJSON.Require {
+		names[name] = true
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+	for _, name := range sortedNames {
+		add("require."+name, version.Require[name], composerJSON.Require[name])
+	}
+
+	add("autoload", autoloadString(version.Autoload), autoloadString(composerJSON.Autoload))
+	return mismatches
+}
+
+func typeOrDefault(packageType string) string {
+	if packageType == "" {
+		return "library"
+	}
+	return packageType
+}
+
+func joinSorted(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// 用来比较的 autoload 的字符串形式，空的 autoload 都当成一样的
+func autoloadString(autoload *manifest.Autoload) string {
+	if autoload.IsEmpty() {
+		return ""
+	}
+	data, _ := json.Marshal(autoload)
+	return string(data)
+}
@@ -0,0 +1,27 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	composerJSON, err := manifest.ParseComposerJSON([]byte(testComposerJSON))
+	assert.NoError(t, err)
+
+	assert.Empty(t, Compare(composerJSON, newTestVersion()))
+	assert.Nil(t, Compare(composerJSON, nil))
+	assert.Equal(t, []*Mismatch{{Field: "composer.json", Metadata: "present", Archive: "missing"}}, Compare(nil, newTestVersion()))
+
+	// An extra dependency only present in the archive is a classic tampering sign
+	version := newTestVersion()
+	version.Type = ""
+	composerJSON.Require["evil/payload"] = "*"
+	composerJSON.Type = "composer-plugin"
+	assert.Equal(t, []*Mismatch{
+		{Field: "type", Metadata: "library", Archive: "composer-plugin"},
+		{Field: "require.evil/payload", Metadata: "", Archive: "*"},
+	}, Compare(composerJSON, version))
+}
@@ -0,0 +1,101 @@
+package archive
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FindingKind 表示检查出来的问题的类型
+type FindingKind string
+
+const (
+	// FindingBinary 二进制文件，PHP 包中一般不应该出现可执行文件
+	FindingBinary FindingKind = "binary"
+
+	// FindingObfuscatedPHP PHP 代码中出现了 eval、base64 之类常见于恶意代码的混淆写法
+	FindingObfuscatedPHP FindingKind = "obfuscated_php"
+
+	// FindingOutsideAutoload PHP 文件不在 composer.json 声明的自动加载路径中
+	FindingOutsideAutoload FindingKind = "outside_autoload"
+
+	// FindingInvalidComposerJSON 压缩包中的 composer.json 无法解析
+	FindingInvalidComposerJSON FindingKind = "invalid_composer_json"
+)
+
+// Finding 表示压缩包中一个需要人工确认的问题
+type Finding struct {
+	Kind FindingKind `json:"kind"`
+	Path string      `json:"path"`
+	// 问题的细节，比如匹配到的混淆规则、二进制文件的类型
+	Detail string `json:"detail,omitempty"`
+}
+
+// 可执行文件的魔数
+var executableMagics = []struct {
+	magic []byte
+	kind  string
+}{
+	{[]byte("\x7fELF"), "ELF executable"},
+	{[]byte("MZ"), "PE executable"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xce}, "Mach-O executable"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xcf}, "Mach-O executable"},
+	{[]byte{0xce, 0xfa, 0xed, 0xfe}, "Mach-O executable"},
+	{[]byte{0xcf, 0xfa, 0xed, 0xfe}, "Mach-O executable"},
+	{[]byte{0xca, 0xfe, 0xba, 0xbe}, "Mach-O universal binary"},
+}
+
+// 判断内容是不是二进制，和 git 一样看开头的 8000 个字节里有没有 NUL
+func detectBinary(content []byte) (string, bool) {
+	for _, executable := range executableMagics {
+		if bytes.HasPrefix(content, executable.magic) {
+			// MZ 太短了，纯文本也可能以它开头，需要同时满足包含 NUL
+			if executable.kind != "PE executable" || bytes.IndexByte(content, 0) >= 0 {
+				return executable.kind, true
+			}
+		}
+	}
+	head := content
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "binary content", true
+	}
+	return "", false
+}
+
+// 根据扩展名或者内容判断是不是 PHP 代码
+func isPHP(name string, content []byte) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".php", ".phtml", ".inc", ".module", ".phar":
+		return true
+	}
+	return bytes.Contains(content, []byte("<?php"))
+}
+
+// 混淆规则，名字会作为 Finding 的 Detail
+var obfuscationPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"eval of decoded payload", regexp.MustCompile(`(?i)\b(eval|assert)\s*\(\s*(@\s*)?(base64_decode|gzinflate|gzuncompress|gzdecode|str_rot13|hex2bin|convert_uudecode)\s*\(`)},
+	{"nested decoding", regexp.MustCompile(`(?i)\b(base64_decode|gzinflate|gzuncompress|gzdecode|str_rot13)\s*\(\s*(base64_decode|gzinflate|gzuncompress|gzdecode|str_rot13)\s*\(`)},
+	{"eval of request input", regexp.MustCompile(`(?i)\b(eval|assert|system|exec|shell_exec|passthru)\s*\(\s*(@\s*)?\$_(GET|POST|REQUEST|COOKIE|SERVER)\b`)},
+	{"preg_replace with /e modifier", regexp.MustCompile(`(?i)\bpreg_replace\s*\(\s*['"][/#~|!@%][^'"]*[/#~|!@%][a-z]*e[a-z]*['"]\s*,`)},
+	{"create_function", regexp.MustCompile(`(?i)\bcreate_function\s*\(`)},
+	{"long base64 literal", regexp.MustCompile(`['"][A-Za-z0-9+/]{400,}={0,2}['"]`)},
+	{"hex escaped string", regexp.MustCompile(`(\\x[0-9a-fA-F]{2}){32,}`)},
+}
+
+// 返回内容匹配到的所有混淆规则的名字
+func detectObfuscation(content []byte) []string {
+	var matched []string
+	for _, obfuscation := range obfuscationPatterns {
+		if obfuscation.pattern.Match(content) {
+			matched = append(matched, obfuscation.name)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectObfuscation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"clean", "<?php\n$data = base64_decode($input);\necho $data;", nil},
+		{"eval base64", "<?php @eval(base64_decode('ZWNobyAx'));", []string{"eval of decoded payload"}},
+		{"nested decoding", "<?php $x = gzinflate(base64_decode($p));", []string{"nested decoding"}},
+		{"request input", "<?php assert($_POST['c']);", []string{"eval of request input"}},
+		{"preg_replace e", `<?php preg_replace("/.*/e", $_GET['c'], "");`, []string{"preg_replace with /e modifier"}},
+		{"preg_replace without e", `<?php preg_replace("/a/i", "b", "");`, nil},
+		{"create_function", "<?php $f = create_function('', $code);", []string{"create_function"}},
+		{"long base64", "<?php $p = '" + strings.Repeat("QUJD", 120) + "';", []string{"long base64 literal"}},
+		{"hex escapes", `<?php $s = "` + strings.Repeat(`\x41`, 40) + `";`, []string{"hex escaped string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectObfuscation([]byte(tt.content)))
+		})
+	}
+}
+
+func TestDetectBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		binary  bool
+	}{
+		{"text", "hello world", "", false},
+		{"elf", "\x7fELF\x02\x01", "ELF executable", true},
+		{"pe", "MZ\x90\x00\x03", "PE executable", true},
+		{"text starting with MZ", "MZ is a prefix", "", false},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "binary content", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, binary := detectBinary([]byte(tt.content))
+			assert.Equal(t, tt.want, kind)
+			assert.Equal(t, tt.binary, binary)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// JobKind 表示爬取任务的类型
+type JobKind string
+
+const (
+	// JobKindFull 爬取全部的包
+	JobKindFull JobKind = "full"
+
+	// JobKindChanges 根据元数据变化的增量信息爬取
+	JobKindChanges JobKind = "changes"
+)
+
+// Checkpoint 记录爬取的进度，进程崩溃之后可以从上次停下的位置继续
+type Checkpoint struct {
+
+	// 进行中的任务的类型，为空表示没有进行中的任务
+	Kind JobKind `json:"kind,omitempty"`
+
+	// 进行中的任务需要爬取的包
+	PackageNames []string `json:"packageNames,omitempty"`
+
+	// PackageNames 中这个下标之前的包都已经处理完了
+	Offset int `json:"offset"`
+
+	// 失败队列，key 是包名
+	Failures map[string]*Failure `json:"failures,omitempty"`
+
+	// 任务完成之后 ChangesTimestamp 要推进到的值
+	NextChangesTimestamp int64 `json:"nextChangesTimestamp,omitempty"`
+
+	// 下一次增量爬取时使用的 changes 时间戳，单位是 1/10000 秒
+	ChangesTimestamp int64 `json:"changesTimestamp,omitempty"`
+
+	// 上一次任务结束时重试次数用完仍然失败的包，时间戳推进之后 changes 中不会再出现它们，
+	// 所以下一次增量爬取时会和 changes 中的包一起重新爬取
+	PendingPackages []string `json:"pendingPackages,omitempty"`
+}
+
+// Failure 表示一个爬取失败的包
+type Failure struct {
+	PackageName string    `json:"packageName"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	LastAttempt time.Time `json:"lastAttempt"`
+}
+
+// LoadCheckpoint 从文件中读取检查点，文件不存在的时候返回一个空的检查点
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return checkpoint, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(bytes, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Save 把检查点保存到文件，先写临时文件再重命名，避免写一半的时候进程退出把检查点写坏
+func (x *Checkpoint) Save(path string) error {
+	bytes, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// InProgress 是否有没有完成的任务
+func (x *Checkpoint) InProgress() bool {
+	return x.Kind != ""
+}
+
+// 还可以重试的失败包，按包名排序
+func (x *Checkpoint) retryable(maxRetries int) []string {
+	names := make([]string, 0)
+	for name, failure := range x.Failures {
+		if failure.Attempts <= maxRetries {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (x *Checkpoint) start(kind JobKind, packageNames []string, nextChangesTimestamp int64) {
+	x.Kind = kind
+	x.PackageNames = packageNames
+	x.Offset = 0
+	x.Failures = make(map[string]*Failure)
+	x.NextChangesTimestamp = nextChangesTimestamp
+	// 增量任务已经把它们放进了 packageNames，全量任务本来就会爬取所有的包
+	x.PendingPackages = nil
+}
+
+func (x *Checkpoint) finish() {
+	if x.NextChangesTimestamp != 0 {
+		x.ChangesTimestamp = x.NextChangesTimestamp
+	}
+	x.PendingPackages = nil
+	for name := range x.Failures {
+		x.PendingPackages = append(x.PendingPackages, name)
+	}
+	sort.Strings(x.PendingPackages)
+	x.Kind = ""
+	x.PackageNames = nil
+	x.Offset = 0
+	x.Failures = nil
+	x.NextChangesTimestamp = 0
+}
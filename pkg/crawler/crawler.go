@@ -0,0 +1,472 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/scagogogo/composer-crawler/pkg/store"
+)
+
+// Packagist 的 changes 中分支版本的变化使用的包名后缀
+const devPackageSuffix = "~dev"
+
+// ErrResyncRequired 增量信息要求重新同步全部的包，需要调用 CrawlAll
+var ErrResyncRequired = errors.New("changes feed requires a full resync")
+
+// ErrNoChangesTimestamp 还没有 changes 时间戳，需要先调用一次 CrawlAll
+var ErrNoChangesTimestamp = errors.New("no changes timestamp, run a full crawl first")
+
+// Result 表示一个包的处理结果
+type Result struct {
+	PackageName string
+
+	// 保存到存储的结果，包被删除的时候为 nil
+	Upsert *store.UpsertResult
+
+	// 包在仓库中已经不存在了，已经从存储中删除
+	Deleted bool
+
+	// 这是第几次尝试
+	Attempt int
+
+	Err error
+}
+
+// Stats 表示一次爬取的统计信息，恢复之前的任务时只统计这一次运行处理的包
+type Stats struct {
+	Total     int
+	Succeeded int
+	Created   int
+	Changed   int
+	Deleted   int
+
+	// 失败的次数，包括之后重试成功的
+	Errors int
+
+	// 重试次数用完之后仍然失败的包
+	Failures []*Failure
+}
+
+// Crawler 把包列表或者元数据变化的增量信息分发给多个 worker 并发爬取，结果写入存储。
+//
+// 进度会定期保存到检查点，进程崩溃之后再次调用 CrawlAll 或者 CrawlChanges 会先把没完成的任务做完；
+// 爬取失败的包会进入失败队列，主流程结束之后再重试，超过重试次数的包在 Stats.Failures 中返回
+type Crawler struct {
+	options    *Options
+	repository *repository.Repository
+	checkpoint *Checkpoint
+
+	lock sync.Mutex
+}
+
+// NewCrawler 创建爬虫，如果配置了检查点文件并且文件存在则从检查点继续
+func NewCrawler(options *Options) (*Crawler, error) {
+	if options == nil || options.Store == nil {
+		return nil, errors.New("store is required")
+	}
+	crawler := &Crawler{
+		options:    options,
+		repository: options.Repository,
+		checkpoint: &Checkpoint{},
+	}
+	if crawler.repository == nil {
+		crawler.repository = repository.NewRepository(nil)
+	}
+	if options.CheckpointFile != "" {
+		checkpoint, err := LoadCheckpoint(options.CheckpointFile)
+		if err != nil {
+			return nil, fmt.Errorf("load checkpoint %s: %w", options.CheckpointFile, err)
+		}
+		crawler.checkpoint = checkpoint
+	}
+	return crawler, nil
+}
+
+// Checkpoint 返回当前的检查点，爬取进行中的时候不要修改它
+func (x *Crawler) Checkpoint() *Checkpoint {
+	return x.checkpoint
+}
+
+// CrawlAll 爬取仓库中所有的包，有没完成的任务时会先继续那个任务；
+// 开始之前会记录当前的 changes 时间戳，完成之后可以用 CrawlChanges 做增量爬取
+func (x *Crawler) CrawlAll(ctx context.Context) (*Stats, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if x.checkpoint.InProgress() {
+		kind := x.checkpoint.Kind
+		stats, err := x.run(ctx)
+		if err != nil || kind == JobKindFull {
+			return stats, err
+		}
+	}
+
+	// 先拿时间戳再拿列表，这样列表之后发生的变化一定能在增量信息中拿到；
+	// 有的镜像不支持增量信息，拿不到时间戳的时候只是不能做增量爬取
+	var changesTimestamp int64
+	if changes, err := x.repository.ListChanges(ctx, 0); changes != nil {
+		changesTimestamp = changes.Timestamp
+	} else {
+		x.logger().LogAttrs(ctx, slog.LevelInfo, "changes timestamp unavailable, incremental crawl disabled", slog.Any("error", err))
+	}
+	packages, err := x.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list packages: %w", err)
+	}
+	names := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		names = append(names, pkg.Name)
+	}
+	sort.Strings(names)
+	x.checkpoint.start(JobKindFull, names, changesTimestamp)
+	return x.run(ctx)
+}
+
+// CrawlChanges 爬取上一次爬取之后元数据发生了变化的包，有没完成的任务时会先继续那个任务
+func (x *Crawler) CrawlChanges(ctx context.Context) (*Stats, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if x.checkpoint.InProgress() {
+		kind := x.checkpoint.Kind
+		stats, err := x.run(ctx)
+		if err != nil || kind == JobKindChanges {
+			return stats, err
+		}
+	}
+	if x.checkpoint.ChangesTimestamp == 0 {
+		return nil, ErrNoChangesTimestamp
+	}
+
+	changes, err := x.repository.ListChanges(ctx, x.checkpoint.ChangesTimestamp)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidChangesSince) {
+			return nil, fmt.Errorf("%w: %s", ErrResyncRequired, err.Error())
+		}
+		return nil, err
+	}
+
+	// 同一个包可能有多个变化，只有最后一个变化是有效的
+	finalActions := make(map[string]response.ChangeActionType)
+	// 上一次任务中重试次数用完仍然失败的包
+	for _, packageName := range x.checkpoint.PendingPackages {
+		finalActions[packageName] = response.ChangeActionUpdate
+	}
+	for _, action := range changes.Actions {
+		// 分支版本的变化报告为 vendor/package~dev，包本身还在，重新爬取这个包就可以了，
+		// 包本身已经被删除的时候（通常 ~dev 也会跟着删除）不需要再爬取
+		if packageName := strings.TrimSuffix(action.Package, devPackageSuffix); packageName != action.Package {
+			if action.Type != response.ChangeActionResync {
+				if finalActions[packageName] != response.ChangeActionDelete {
+					finalActions[packageName] = response.ChangeActionUpdate
+				}
+				continue
+			}
+		}
+		switch action.Type {
+		case response.ChangeActionResync:
+			return nil, ErrResyncRequired
+		case response.ChangeActionDelete, response.ChangeActionUpdate:
+			finalActions[action.Package] = action.Type
+		}
+	}
+
+	names := make([]string, 0)
+	deletedNames := make([]string, 0)
+	for packageName, actionType := range finalActions {
+		if actionType == response.ChangeActionDelete {
+			deletedNames = append(deletedNames, packageName)
+		} else {
+			names = append(names, packageName)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(deletedNames)
+	for _, packageName := range deletedNames {
+		// 删除是幂等的，任务没完成之前时间戳不会推进，崩溃之后会再删一次
+		if err := x.options.Store.Delete(ctx, packageName); err != nil {
+			return nil, err
+		}
+	}
+	deleted := len(deletedNames)
+
+	x.checkpoint.start(JobKindChanges, names, changes.Timestamp)
+	stats, err := x.run(ctx)
+	if stats != nil {
+		stats.Deleted += deleted
+	}
+	return stats, err
+}
+
+// Crawl 爬取给定的包，不使用检查点
+func (x *Crawler) Crawl(ctx context.Context, packageNames []string) (*Stats, error) {
+	stats := &Stats{Total: len(packageNames)}
+	failures := make(map[string]*Failure)
+	retryDelay := x.retryDelay()
+	for len(packageNames) > 0 {
+		err := x.pass(ctx, packageNames, 0, func(index int, result *Result) {
+			x.record(result, stats, failures)
+		})
+		if err != nil {
+			return stats, err
+		}
+		packageNames = retryable(failures, x.maxRetries())
+		if len(packageNames) > 0 {
+			if err := sleep(ctx, retryDelay); err != nil {
+				return stats, err
+			}
+			retryDelay *= 2
+		}
+	}
+	stats.Failures = sortedFailures(failures)
+	return stats, nil
+}
+
+// 执行检查点中记录的任务
+func (x *Crawler) run(ctx context.Context) (*Stats, error) {
+	checkpoint := x.checkpoint
+	stats := &Stats{Total: len(checkpoint.PackageNames)}
+	if checkpoint.Failures == nil {
+		checkpoint.Failures = make(map[string]*Failure)
+	}
+
+	// 主流程，记录已经完成的下标，连续完成的部分推进 Offset
+	finished := make(map[int]bool)
+	lastSave := time.Now()
+	err := x.pass(ctx, checkpoint.PackageNames, checkpoint.Offset, func(index int, result *Result) {
+		finished[index] = true
+		for finished[checkpoint.Offset] {
+			delete(finished, checkpoint.Offset)
+			checkpoint.Offset++
+		}
+		x.record(result, stats, checkpoint.Failures)
+		if time.Since(lastSave) >= x.checkpointInterval() {
+			lastSave = time.Now()
+			_ = x.saveCheckpoint()
+		}
+	})
+	if err != nil {
+		return stats, x.saveAfterError(err)
+	}
+
+	// 重试失败队列
+	retryDelay := x.retryDelay()
+	for {
+		names := checkpoint.retryable(x.maxRetries())
+		if len(names) == 0 {
+			break
+		}
+		if err := x.saveCheckpoint(); err != nil {
+			return stats, err
+		}
+		if err := sleep(ctx, retryDelay); err != nil {
+			return stats, err
+		}
+		retryDelay *= 2
+		err := x.pass(ctx, names, 0, func(index int, result *Result) {
+			x.record(result, stats, checkpoint.Failures)
+		})
+		if err != nil {
+			return stats, x.saveAfterError(err)
+		}
+	}
+
+	stats.Failures = sortedFailures(checkpoint.Failures)
+	checkpoint.finish()
+	return stats, x.saveCheckpoint()
+}
+
+// 用 worker 池处理 names[offset:]，结果在调用者的 goroutine 中交给 onResult；
+// ctx 结束导致的失败不会交给 onResult，这些包在恢复的时候会重新处理
+func (x *Crawler) pass(ctx context.Context, names []string, offset int, onResult func(index int, result *Result)) error {
+	type indexedResult struct {
+		index  int
+		result *Result
+	}
+
+	jobs := make(chan int)
+	results := make(chan *indexedResult)
+	var wg sync.WaitGroup
+	for i := 0; i < x.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results <- &indexedResult{index: index, result: x.crawlOne(ctx, names[index])}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for index := offset; index < len(names); index++ {
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if r.result.Err != nil && ctx.Err() != nil {
+			continue
+		}
+		onResult(r.index, r.result)
+	}
+	return ctx.Err()
+}
+
+func (x *Crawler) crawlOne(ctx context.Context, packageName string) *Result {
+	result := &Result{PackageName: packageName}
+	info, err := x.repository.GetPackage(ctx, packageName)
+	if err != nil {
+		if errors.Is(err, repository.ErrPackageNotFound) {
+			result.Deleted = true
+			result.Err = x.options.Store.Delete(ctx, packageName)
+			return result
+		}
+		result.Err = err
+		return result
+	}
+	result.Upsert, result.Err = x.options.Store.Upsert(ctx, info)
+	return result
+}
+
+// 更新统计信息和失败队列
+func (x *Crawler) record(result *Result, stats *Stats, failures map[string]*Failure) {
+	if result.Err != nil {
+		stats.Errors++
+		failure := failures[result.PackageName]
+		if failure == nil {
+			failure = &Failure{PackageName: result.PackageName}
+			failures[result.PackageName] = failure
+		}
+		failure.Attempts++
+		failure.LastError = result.Err.Error()
+		failure.LastAttempt = time.Now()
+		result.Attempt = failure.Attempts
+		if failure.Attempts > x.maxRetries() {
+			x.logger().LogAttrs(context.Background(), slog.LevelWarn, "package skipped after retries",
+				slog.String("package", result.PackageName), slog.Int("attempts", failure.Attempts), slog.Any("error", result.Err))
+		} else {
+			x.logger().LogAttrs(context.Background(), slog.LevelInfo, "package failed, will retry",
+				slog.String("package", result.PackageName), slog.Int("attempts", failure.Attempts), slog.Any("error", result.Err))
+		}
+	} else {
+		if failure := failures[result.PackageName]; failure != nil {
+			result.Attempt = failure.Attempts + 1
+			delete(failures, result.PackageName)
+		} else {
+			result.Attempt = 1
+		}
+		stats.Succeeded++
+		switch {
+		case result.Deleted:
+			x.logger().LogAttrs(context.Background(), slog.LevelInfo, "package no longer exists, deleted from store",
+				slog.String("package", result.PackageName))
+			stats.Deleted++
+		case result.Upsert.Created:
+			stats.Created++
+		case result.Upsert.Changed:
+			stats.Changed++
+		}
+	}
+	if x.options.OnResult != nil {
+		x.options.OnResult(result)
+	}
+}
+
+func (x *Crawler) saveCheckpoint() error {
+	if x.options.CheckpointFile == "" {
+		return nil
+	}
+	return x.checkpoint.Save(x.options.CheckpointFile)
+}
+
+func (x *Crawler) saveAfterError(err error) error {
+	if saveErr := x.saveCheckpoint(); saveErr != nil {
+		return fmt.Errorf("%w, save checkpoint: %s", err, saveErr.Error())
+	}
+	return err
+}
+
+// 没有设置 Options.Logger 的时候丢弃所有的日志
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func (x *Crawler) logger() *slog.Logger {
+	if x.options.Logger != nil {
+		return x.options.Logger
+	}
+	return discardLogger
+}
+
+func (x *Crawler) workers() int {
+	if x.options.Workers > 0 {
+		return x.options.Workers
+	}
+	return DefaultWorkers
+}
+
+func (x *Crawler) maxRetries() int {
+	if x.options.MaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	if x.options.MaxRetries < 0 {
+		return 0
+	}
+	return x.options.MaxRetries
+}
+
+func (x *Crawler) retryDelay() time.Duration {
+	if x.options.RetryDelay > 0 {
+		return x.options.RetryDelay
+	}
+	return DefaultRetryDelay
+}
+
+func (x *Crawler) checkpointInterval() time.Duration {
+	if x.options.CheckpointInterval > 0 {
+		return x.options.CheckpointInterval
+	}
+	return DefaultCheckpointInterval
+}
+
+func retryable(failures map[string]*Failure, maxRetries int) []string {
+	checkpoint := &Checkpoint{Failures: failures}
+	return checkpoint.retryable(maxRetries)
+}
+
+func sortedFailures(failures map[string]*Failure) []*Failure {
+	result := make([]*Failure, 0, len(failures))
+	for _, failure := range failures {
+		result = append(result, failure)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PackageName < result[j].PackageName
+	})
+	return result
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,352 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePackagist serves list.json, changes.json and package metadata, counting requests per path
+type fakePackagist struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	packages []string
+	changes  string
+	// failHits makes a package respond with 500 for its first N requests
+	failHits map[string]int
+	hits     map[string]int
+}
+
+func newFakePackagist(t *testing.T, packages ...string) *fakePackagist {
+	fake := &fakePackagist{
+		packages: packages,
+		changes:  `{"error": "Invalid or missing \"since\" query parameter", "timestamp": 16000000000000}`,
+		failHits: make(map[string]int),
+		hits:     make(map[string]int),
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (x *fakePackagist) serve(w http.ResponseWriter, r *http.Request) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.hits[r.URL.Path]++
+
+	switch {
+	case r.URL.Path == "/packages/list.json":
+		w.Write([]byte(fmt.Sprintf(`{"packageNames": ["%s"]}`, strings.Join(x.packages, `", "`))))
+	case r.URL.Path == "/metadata/changes.json":
+		if r.URL.Query().Get("since") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "Invalid or missing \"since\" query parameter", "timestamp": 16000000000000}`))
+			return
+		}
+		w.Write([]byte(x.changes))
+	case strings.HasPrefix(r.URL.Path, "/packages/"):
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/packages/"), ".json")
+		if x.failHits[name] > 0 {
+			x.failHits[name]--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for _, pkg := range x.packages {
+			if pkg == name {
+				w.Write([]byte(fmt.Sprintf(`{"package": {"name": "%s", "description": "hits %d"}}`, name, x.hits[r.URL.Path])))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status": "error", "message": "Package not found"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (x *fakePackagist) hitsOf(path string) int {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.hits[path]
+}
+
+func newTestCrawler(t *testing.T, fake *fakePackagist, checkpointFile string, options *Options) (*Crawler, store.PackageStore) {
+	packageStore, err := store.OpenBoltStore(filepath.Join(t.TempDir(), "packages.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		packageStore.Close()
+	})
+	if options == nil {
+		options = &Options{}
+	}
+	options.Repository = repository.NewRepository(&repository.Options{ServerUrl: fake.URL, RetryDelay: time.Millisecond})
+	options.Store = packageStore
+	options.CheckpointFile = checkpointFile
+	options.RetryDelay = time.Millisecond
+	crawler, err := NewCrawler(options)
+	assert.NoError(t, err)
+	return crawler, packageStore
+}
+
+func storedNames(t *testing.T, packageStore store.PackageStore) []string {
+	names := make([]string, 0)
+	assert.NoError(t, packageStore.Iterate(context.Background(), func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageName)
+		return nil
+	}))
+	return names
+}
+
+func TestCrawler_CrawlAll(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/broken", "vendor/c", "vendor/flaky")
+	// vendor/flaky fails one crawler attempt (the http client tries three times), vendor/broken never recovers
+	fake.failHits["vendor/flaky"] = 3
+	fake.failHits["vendor/broken"] = 1000
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.json")
+	results := make([]*Result, 0)
+	crawler, packageStore := newTestCrawler(t, fake, checkpointFile, &Options{
+		Workers:    3,
+		MaxRetries: 2,
+		OnResult: func(result *Result) {
+			results = append(results, result)
+		},
+	})
+
+	stats, err := crawler.CrawlAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 3, stats.Succeeded)
+	assert.Equal(t, 3, stats.Created)
+	assert.Equal(t, 4, stats.Errors)
+	if assert.Len(t, stats.Failures, 1) {
+		assert.Equal(t, "vendor/broken", stats.Failures[0].PackageName)
+		assert.Equal(t, 3, stats.Failures[0].Attempts)
+		assert.Contains(t, stats.Failures[0].LastError, "500")
+	}
+	assert.Len(t, results, 7)
+	assert.Equal(t, []string{"vendor/a", "vendor/c", "vendor/flaky"}, storedNames(t, packageStore))
+
+	// The job is finished and the changes timestamp was recorded
+	checkpoint, err := LoadCheckpoint(checkpointFile)
+	assert.NoError(t, err)
+	assert.False(t, checkpoint.InProgress())
+	assert.Equal(t, int64(16000000000000), checkpoint.ChangesTimestamp)
+	assert.Empty(t, checkpoint.PackageNames)
+}
+
+func TestCrawler_CrawlAllResume(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b", "vendor/c", "vendor/d", "vendor/e")
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	// Simulate a crash after two packages
+	ctx, cancel := context.WithCancel(context.Background())
+	processed := 0
+	crawler, _ := newTestCrawler(t, fake, checkpointFile, &Options{
+		Workers: 1,
+		OnResult: func(result *Result) {
+			processed++
+			if processed == 2 {
+				cancel()
+			}
+		},
+	})
+	_, err := crawler.CrawlAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	checkpoint, err := LoadCheckpoint(checkpointFile)
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.InProgress())
+	assert.Equal(t, JobKindFull, checkpoint.Kind)
+	assert.Equal(t, 2, checkpoint.Offset)
+
+	// A new crawler resumes from the checkpoint without listing again
+	resumed, packageStore := newTestCrawler(t, fake, checkpointFile, nil)
+	stats, err := resumed.CrawlAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, 3, stats.Succeeded)
+	assert.Equal(t, []string{"vendor/c", "vendor/d", "vendor/e"}, storedNames(t, packageStore))
+	assert.Equal(t, 1, fake.hitsOf("/packages/list.json"))
+	assert.Equal(t, 1, fake.hitsOf("/packages/vendor/a.json"))
+	assert.False(t, resumed.Checkpoint().InProgress())
+}
+
+func TestCrawler_CrawlChanges(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b", "vendor/c")
+	crawler, packageStore := newTestCrawler(t, fake, "", nil)
+
+	_, err := crawler.CrawlChanges(context.Background())
+	assert.ErrorIs(t, err, ErrNoChangesTimestamp)
+
+	_, err = crawler.CrawlAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/a", "vendor/b", "vendor/c"}, storedNames(t, packageStore))
+
+	fake.lock.Lock()
+	fake.packages = []string{"vendor/a", "vendor/c", "vendor/d"}
+	fake.changes = `{"actions": [
+		{"type": "update", "package": "vendor/a", "time": 1600000001},
+		{"type": "delete", "package": "vendor/b", "time": 1600000002},
+		{"type": "update", "package": "vendor/d", "time": 1600000003},
+		{"type": "update", "package": "vendor/a", "time": 1600000004}
+	], "timestamp": 16000000050000}`
+	fake.lock.Unlock()
+
+	stats, err := crawler.CrawlChanges(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 1, stats.Created)
+	assert.Equal(t, 1, stats.Changed)
+	assert.Equal(t, 1, stats.Deleted)
+	assert.Equal(t, []string{"vendor/a", "vendor/c", "vendor/d"}, storedNames(t, packageStore))
+	assert.Equal(t, int64(16000000050000), crawler.Checkpoint().ChangesTimestamp)
+
+	fake.lock.Lock()
+	fake.changes = `{"actions": [{"type": "resync", "package": "*", "time": 1600000010}], "timestamp": 16000000100000}`
+	fake.lock.Unlock()
+	_, err = crawler.CrawlChanges(context.Background())
+	assert.ErrorIs(t, err, ErrResyncRequired)
+	assert.Equal(t, int64(16000000050000), crawler.Checkpoint().ChangesTimestamp)
+}
+
+func TestCrawler_CrawlChangesDev(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b")
+	crawler, packageStore := newTestCrawler(t, fake, "", nil)
+	_, err := crawler.CrawlAll(context.Background())
+	assert.NoError(t, err)
+
+	// Dev branch changes are reported with a ~dev suffix, even when the branch was deleted
+	fake.lock.Lock()
+	fake.changes = `{"actions": [
+		{"type": "update", "package": "vendor/a~dev", "time": 1600000001},
+		{"type": "update", "package": "vendor/a", "time": 1600000002},
+		{"type": "delete", "package": "vendor/b~dev", "time": 1600000003}
+	], "timestamp": 16000000050000}`
+	fake.lock.Unlock()
+
+	stats, err := crawler.CrawlChanges(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 2, stats.Changed)
+	assert.Equal(t, 0, stats.Deleted)
+	assert.Equal(t, []string{"vendor/a", "vendor/b"}, storedNames(t, packageStore))
+	assert.Equal(t, 0, fake.hitsOf("/packages/vendor/a~dev.json"))
+	// Once by CrawlAll and once more for both changes
+	assert.Equal(t, 2, fake.hitsOf("/packages/vendor/a.json"))
+}
+
+func TestCrawler_CrawlChangesLastActionWins(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b", "vendor/c")
+	crawler, packageStore := newTestCrawler(t, fake, "", nil)
+	_, err := crawler.CrawlAll(context.Background())
+	assert.NoError(t, err)
+
+	// vendor/b is deleted and published again, vendor/c is updated and then deleted
+	fake.lock.Lock()
+	fake.packages = []string{"vendor/a", "vendor/b"}
+	fake.changes = `{"actions": [
+		{"type": "update", "package": "vendor/b", "time": 1600000001},
+		{"type": "delete", "package": "vendor/b", "time": 1600000002},
+		{"type": "update", "package": "vendor/b", "time": 1600000003},
+		{"type": "update", "package": "vendor/c", "time": 1600000004},
+		{"type": "delete", "package": "vendor/c", "time": 1600000005},
+		{"type": "delete", "package": "vendor/c~dev", "time": 1600000006}
+	], "timestamp": 16000000050000}`
+	fake.lock.Unlock()
+
+	stats, err := crawler.CrawlChanges(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+	assert.Equal(t, 0, stats.Errors)
+	assert.Empty(t, stats.Failures)
+	assert.Equal(t, 1, stats.Deleted)
+	assert.Equal(t, []string{"vendor/a", "vendor/b"}, storedNames(t, packageStore))
+	// Once by CrawlAll and once more for the changes
+	assert.Equal(t, 2, fake.hitsOf("/packages/vendor/b.json"))
+	assert.Equal(t, 1, fake.hitsOf("/packages/vendor/c.json"))
+}
+
+func TestCrawler_CrawlChangesPendingFailures(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b")
+	crawler, packageStore := newTestCrawler(t, fake, "", &Options{MaxRetries: -1})
+	_, err := crawler.CrawlAll(context.Background())
+	assert.NoError(t, err)
+
+	fake.lock.Lock()
+	// The repository tries three times before giving up
+	fake.failHits["vendor/b"] = 3
+	fake.changes = `{"actions": [
+		{"type": "update", "package": "vendor/a", "time": 1600000001},
+		{"type": "update", "package": "vendor/b", "time": 1600000002}
+	], "timestamp": 16000000050000}`
+	fake.lock.Unlock()
+
+	stats, err := crawler.CrawlChanges(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, stats.Failures, 1)
+	assert.Equal(t, int64(16000000050000), crawler.Checkpoint().ChangesTimestamp)
+	assert.Equal(t, []string{"vendor/b"}, crawler.Checkpoint().PendingPackages)
+
+	// The feed no longer mentions vendor/b, but it is crawled again
+	fake.lock.Lock()
+	fake.changes = `{"actions": [], "timestamp": 16000000060000}`
+	fake.lock.Unlock()
+	stats, err = crawler.CrawlChanges(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+	assert.Equal(t, 1, stats.Changed)
+	assert.Empty(t, stats.Failures)
+	assert.Empty(t, crawler.Checkpoint().PendingPackages)
+	assert.Equal(t, []string{"vendor/a", "vendor/b"}, storedNames(t, packageStore))
+}
+
+func TestCrawler_Crawl(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b")
+	fake.failHits["vendor/b"] = 1000
+	crawler, packageStore := newTestCrawler(t, fake, "", &Options{MaxRetries: -1})
+
+	stats, err := crawler.Crawl(context.Background(), []string{"vendor/a", "vendor/b", "vendor/gone"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Succeeded)
+	assert.Equal(t, 1, stats.Deleted)
+	if assert.Len(t, stats.Failures, 1) {
+		assert.Equal(t, 1, stats.Failures[0].Attempts)
+	}
+	assert.Equal(t, []string{"vendor/a"}, storedNames(t, packageStore))
+}
+
+func TestNewCrawler(t *testing.T) {
+	_, err := NewCrawler(&Options{})
+	assert.Error(t, err)
+}
+
+func TestCrawler_Logger(t *testing.T) {
+	fake := newFakePackagist(t, "vendor/a", "vendor/b")
+	fake.failHits["vendor/b"] = 1000
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buffer, nil))
+	crawler, _ := newTestCrawler(t, fake, "", &Options{MaxRetries: 1, Logger: logger})
+
+	_, err := crawler.Crawl(context.Background(), []string{"vendor/a", "vendor/b", "vendor/gone"})
+	assert.NoError(t, err)
+
+	logs := buffer.String()
+	assert.Contains(t, logs, `"msg":"package no longer exists, deleted from store","package":"vendor/gone"`)
+	assert.Contains(t, logs, `"msg":"package failed, will retry","package":"vendor/b","attempts":1`)
+	assert.Contains(t, logs, `"level":"WARN","msg":"package skipped after retries","package":"vendor/b","attempts":2`)
+}
@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/store"
+)
+
+const (
+	// DefaultWorkers 默认的并发数
+	DefaultWorkers = 8
+
+	// DefaultMaxRetries 默认每个包失败之后最多重试的次数
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay 默认的第一次重试前的等待时间，之后每一轮翻倍
+	DefaultRetryDelay = time.Second
+
+	// DefaultCheckpointInterval 默认保存检查点的间隔
+	DefaultCheckpointInterval = 10 * time.Second
+)
+
+// Options 爬虫的配置
+type Options struct {
+
+	// 用来获取包信息的仓库，为空的时候使用官方仓库
+	Repository *repository.Repository
+
+	// 爬取结果写入的存储，必填
+	Store store.PackageStore
+
+	// 并发的 worker 数，为空的时候使用 DefaultWorkers
+	Workers int
+
+	// 检查点文件的路径，为空的时候检查点只保存在内存中，进程退出之后无法继续
+	CheckpointFile string
+
+	// 检查点保存的间隔，为空的时候使用 DefaultCheckpointInterval
+	CheckpointInterval time.Duration
+
+	// 每个包失败之后最多重试的次数，为 0 的时候使用 DefaultMaxRetries，小于 0 表示不重试
+	MaxRetries int
+
+	// 第一次重试前的等待时间，为空的时候使用 DefaultRetryDelay
+	RetryDelay time.Duration
+
+	// 每个包处理完之后的回调，可以用来展示进度，在同一个 goroutine 中被调用
+	OnResult func(result *Result)
+
+	// 记录失败、重试以及被放弃的包，为空的时候不记录。请求级别的日志需要设置 repository.Options 的 Logger
+	Logger *slog.Logger
+}
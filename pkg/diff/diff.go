@@ -0,0 +1,329 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// ChangeKind 表示包信息变化的类型
+type ChangeKind string
+
+const (
+	// ChangeVersionAdded 新发布了版本
+	ChangeVersionAdded ChangeKind = "version_added"
+
+	// ChangeVersionRemoved 版本被删除了
+	ChangeVersionRemoved ChangeKind = "version_removed"
+
+	// ChangeVersionRetagged 同一个版本号指向了不同的代码，dev 分支的变化不算
+	ChangeVersionRetagged ChangeKind = "version_retagged"
+
+	// ChangeMaintainerAdded 新增了维护者
+	ChangeMaintainerAdded ChangeKind = "maintainer_added"
+
+	// ChangeMaintainerRemoved 维护者被移除了
+	ChangeMaintainerRemoved ChangeKind = "maintainer_removed"
+
+	// ChangeLicenseChanged 许可证变化了，Version 为空的时候表示最新版本的许可证和之前最新版本的不同
+	ChangeLicenseChanged ChangeKind = "license_changed"
+
+	// ChangeRequireAdded 某个版本新增了依赖
+	ChangeRequireAdded ChangeKind = "require_added"
+
+	// ChangeRequireRemoved 某个版本删除了依赖
+	ChangeRequireRemoved ChangeKind = "require_removed"
+
+	// ChangeRequireChanged 某个版本的依赖约束变化了
+	ChangeRequireChanged ChangeKind = "require_changed"
+
+	// ChangeAbandonedChanged 包的废弃状态或者推荐的替代包变化了
+	ChangeAbandonedChanged ChangeKind = "abandoned_changed"
+)
+
+// 输出时各种变化的顺序
+var changeKindOrder = map[ChangeKind]int{
+	ChangeAbandonedChanged:  0,
+	ChangeMaintainerAdded:   1,
+	ChangeMaintainerRemoved: 2,
+	ChangeLicenseChanged:    3,
+	ChangeVersionRetagged:   4,
+	ChangeVersionRemoved:    5,
+	ChangeVersionAdded:      6,
+	ChangeRequireAdded:      7,
+	ChangeRequireRemoved:    8,
+	ChangeRequireChanged:    9,
+}
+
+// Change 表示一处变化
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+
+	// 变化所在的版本，包级别的变化为空
+	Version string `json:"version,omitempty"`
+
+	// 依赖变化时被依赖的包名，维护者变化时的维护者名字
+	Name string `json:"name,omitempty"`
+
+	// 变化前后的值，比如 reference、许可证、版本约束，没有的时候为空
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// IsRedFlag 是否是需要关注的供应链风险信号：已发布版本被重新打标签或者维护者发生变化
+func (x *Change) IsRedFlag() bool {
+	switch x.Kind {
+	case ChangeVersionRetagged, ChangeMaintainerAdded, ChangeMaintainerRemoved:
+		return true
+	default:
+		return false
+	}
+}
+
+// PackageDiff 表示同一个包两次快照之间的变化
+type PackageDiff struct {
+	Name    string    `json:"name"`
+	Changes []*Change `json:"changes"`
+}
+
+// HasChanges 是否有变化
+func (x *PackageDiff) HasChanges() bool {
+	return len(x.Changes) > 0
+}
+
+// RedFlags 返回需要关注的供应链风险信号
+func (x *PackageDiff) RedFlags() []*Change {
+	result := make([]*Change, 0)
+	for _, change := range x.Changes {
+		if change.IsRedFlag() {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// Filter 返回给定类型的变化
+func (x *PackageDiff) Filter(kinds ...ChangeKind) []*Change {
+	result := make([]*Change, 0)
+	for _, change := range x.Changes {
+		for _, kind := range kinds {
+			if change.Kind == kind {
+				result = append(result, change)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Packages 比较同一个包的两次快照，old 为 nil 的时候表示这个包是新出现的
+func Packages(old, new *composer_crawler.ComposerPackageInfo) *PackageDiff {
+	if old == nil {
+		old = &composer_crawler.ComposerPackageInfo{}
+	}
+	if new == nil {
+		new = &composer_crawler.ComposerPackageInfo{}
+	}
+	result := &PackageDiff{Name: new.Package.Name, Changes: make([]*Change, 0)}
+	if result.Name == "" {
+		result.Name = old.Package.Name
+	}
+
+	if old.Package.Abandoned != new.Package.Abandoned {
+		result.Changes = append(result.Changes, &Change{
+			Kind: ChangeAbandonedChanged,
+			Old:  abandonedString(old),
+			New:  abandonedString(new),
+		})
+	}
+	result.Changes = append(result.Changes, diffMaintainers(old, new)...)
+	result.Changes = append(result.Changes, diffVersions(old.Package.Versions, new.Package.Versions)...)
+
+	oldLicense, newLicense := latestLicense(old.Package.Versions), latestLicense(new.Package.Versions)
+	if oldLicense != nil && newLicense != nil && licenseString(oldLicense) != licenseString(newLicense) &&
+		!hasChange(result.Changes, ChangeLicenseChanged, licenseString(oldLicense), licenseString(newLicense)) {
+		result.Changes = append(result.Changes, &Change{
+			Kind: ChangeLicenseChanged,
+			Old:  licenseString(oldLicense),
+			New:  licenseString(newLicense),
+		})
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		a, b := result.Changes[i], result.Changes[j]
+		if a.Kind != b.Kind {
+			return changeKindOrder[a.Kind] < changeKindOrder[b.Kind]
+		}
+		if a.Version != b.Version {
+			return compareVersions(a.Version, b.Version) < 0
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+func hasChange(changes []*Change, kind ChangeKind, old, new string) bool {
+	for _, change := range changes {
+		if change.Kind == kind && change.Old == old && change.New == new {
+			return true
+		}
+	}
+	return false
+}
+
+func diffMaintainers(old, new *composer_crawler.ComposerPackageInfo) []*Change {
+	changes := make([]*Change, 0)
+	oldNames, newNames := maintainerNames(old), maintainerNames(new)
+	for name := range newNames {
+		if !oldNames[name] {
+			changes = append(changes, &Change{Kind: ChangeMaintainerAdded, Name: name})
+		}
+	}
+	for name := range oldNames {
+		if !newNames[name] {
+			changes = append(changes, &Change{Kind: ChangeMaintainerRemoved, Name: name})
+		}
+	}
+	return changes
+}
+
+func diffVersions(oldVersions, newVersions map[string]*composer_crawler.Version) []*Change {
+	changes := make([]*Change, 0)
+	for version, newVersion := range newVersions {
+		if newVersion == nil {
+			continue
+		}
+		oldVersion, ok := oldVersions[version]
+		if !ok || oldVersion == nil {
+			changes = append(changes, &Change{Kind: ChangeVersionAdded, Version: version, New: newVersion.Dist.Reference})
+			continue
+		}
+
+		if !isDevVersion(version) {
+			oldReference, newReference := reference(oldVersion), reference(newVersion)
+			if oldReference != "" && newReference != "" && oldReference != newReference {
+				changes = append(changes, &Change{Kind: ChangeVersionRetagged, Version: version, Old: oldReference, New: newReference})
+			}
+		}
+
+		if licenseString(oldVersion.License) != licenseString(newVersion.License) {
+			changes = append(changes, &Change{
+				Kind:    ChangeLicenseChanged,
+				Version: version,
+				Old:     licenseString(oldVersion.License),
+				New:     licenseString(newVersion.License),
+			})
+		}
+		changes = append(changes, diffRequire(version, oldVersion.Require, newVersion.Require)...)
+	}
+	for version, oldVersion := range oldVersions {
+		if _, ok := newVersions[version]; !ok && oldVersion != nil {
+			changes = append(changes, &Change{Kind: ChangeVersionRemoved, Version: version, Old: oldVersion.Dist.Reference})
+		}
+	}
+	return changes
+}
+
+func diffRequire(version string, oldRequire, newRequire map[string]string) []*Change {
+	changes := make([]*Change, 0)
+	for name, newConstraint := range newRequire {
+		oldConstraint, ok := oldRequire[name]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Kind: ChangeRequireAdded, Version: version, Name: name, New: newConstraint})
+		case oldConstraint != newConstraint:
+			changes = append(changes, &Change{Kind: ChangeRequireChanged, Version: version, Name: name, Old: oldConstraint, New: newConstraint})
+		}
+	}
+	for name, oldConstraint := range oldRequire {
+		if _, ok := newRequire[name]; !ok {
+			changes = append(changes, &Change{Kind: ChangeRequireRemoved, Version: version, Name: name, Old: oldConstraint})
+		}
+	}
+	return changes
+}
+
+// 优先使用 dist 的 reference，没有的时候使用 source 的
+func reference(version *composer_crawler.Version) string {
+	if version.Dist.Reference != "" {
+		return version.Dist.Reference
+	}
+	return version.Source.Reference
+}
+
+// dev 分支会随着提交不断变化，不能算作重新打标签
+func isDevVersion(version string) bool {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return strings.HasPrefix(version, "dev-") || strings.HasSuffix(version, "-dev")
+	}
+	return v.Stability() == semver.StabilityDev
+}
+
+// 最新的稳定版本的许可证，没有稳定版本的时候使用最新的版本
+func latestLicense(versions map[string]*composer_crawler.Version) []string {
+	var latest, latestStable *semver.Version
+	licenses := make(map[string][]string)
+	for name, version := range versions {
+		v, err := semver.Parse(name)
+		if err != nil || version == nil || v.Stability() == semver.StabilityDev {
+			continue
+		}
+		licenses[v.Normalized()] = version.License
+		if latest == nil || latest.LessThan(v) {
+			latest = v
+		}
+		if v.Stability() == semver.StabilityStable && (latestStable == nil || latestStable.LessThan(v)) {
+			latestStable = v
+		}
+	}
+	if latestStable != nil {
+		return licenses[latestStable.Normalized()]
+	}
+	if latest != nil {
+		return licenses[latest.Normalized()]
+	}
+	return nil
+}
+
+func licenseString(licenses []string) string {
+	sorted := append([]string{}, licenses...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+func maintainerNames(info *composer_crawler.ComposerPackageInfo) map[string]bool {
+	names := make(map[string]bool)
+	for _, maintainer := range info.Package.Maintainers {
+		if maintainer != nil {
+			names[maintainer.Name] = true
+		}
+	}
+	return names
+}
+
+func abandonedString(info *composer_crawler.ComposerPackageInfo) string {
+	abandoned := info.Package.Abandoned
+	switch {
+	case !abandoned.IsAbandoned:
+		return "false"
+	case abandoned.Replacement != "":
+		return abandoned.Replacement
+	default:
+		return "true"
+	}
+}
+
+// 能解析的版本按版本号比较，否则按字符串比较
+func compareVersions(a, b string) int {
+	va, errA := semver.Parse(a)
+	vb, errB := semver.Parse(b)
+	if errA == nil && errB == nil {
+		if c := semver.Compare(va, vb); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
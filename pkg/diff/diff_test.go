@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/stretchr/testify/assert"
+)
+
+func parsePackageInfo(t *testing.T, data string) *composer_crawler.ComposerPackageInfo {
+	info := &composer_crawler.ComposerPackageInfo{}
+	assert.NoError(t, json.Unmarshal([]byte(data), info))
+	return info
+}
+
+const oldSnapshot = `{"package": {
+	"name": "vendor/package",
+	"maintainers": [{"name": "alice"}, {"name": "bob"}],
+	"versions": {
+		"dev-main": {"version": "dev-main", "license": ["MIT"], "dist": {"reference": "aaaa"}},
+		"1.0.0": {"version": "1.0.0", "license": ["MIT"], "dist": {"reference": "1111"}, "require": {"php": ">=7.4"}},
+		"1.1.0": {"version": "1.1.0", "license": ["MIT"], "dist": {"reference": "2222"}, "require": {"php": ">=7.4", "psr/log": "^1.0"}},
+		"0.9.0": {"version": "0.9.0", "license": ["MIT"], "source": {"reference": "0909"}}
+	}
+}}`
+
+const newSnapshot = `{"package": {
+	"name": "vendor/package",
+	"maintainers": [{"name": "alice"}, {"name": "mallory"}],
+	"abandoned": "vendor/other",
+	"versions": {
+		"dev-main": {"version": "dev-main", "license": ["MIT"], "dist": {"reference": "bbbb"}},
+		"1.0.0": {"version": "1.0.0", "license": ["MIT"], "dist": {"reference": "1111"}, "require": {"php": ">=7.4"}},
+		"1.1.0": {"version": "1.1.0", "license": ["MIT"], "dist": {"reference": "3333"}, "require": {"php": ">=8.0", "guzzlehttp/guzzle": "^7.0"}},
+		"2.0.0": {"version": "2.0.0", "license": ["GPL-3.0-only"], "dist": {"reference": "4444"}}
+	}
+}}`
+
+func TestPackages(t *testing.T) {
+	diff := Packages(parsePackageInfo(t, oldSnapshot), parsePackageInfo(t, newSnapshot))
+	assert.Equal(t, "vendor/package", diff.Name)
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, []*Change{
+		{Kind: ChangeAbandonedChanged, Old: "false", New: "vendor/other"},
+		{Kind: ChangeMaintainerAdded, Name: "mallory"},
+		{Kind: ChangeMaintainerRemoved, Name: "bob"},
+		{Kind: ChangeLicenseChanged, Old: "MIT", New: "GPL-3.0-only"},
+		{Kind: ChangeVersionRetagged, Version: "1.1.0", Old: "2222", New: "3333"},
+		{Kind: ChangeVersionRemoved, Version: "0.9.0"},
+		{Kind: ChangeVersionAdded, Version: "2.0.0", New: "4444"},
+		{Kind: ChangeRequireAdded, Version: "1.1.0", Name: "guzzlehttp/guzzle", New: "^7.0"},
+		{Kind: ChangeRequireRemoved, Version: "1.1.0", Name: "psr/log", Old: "^1.0"},
+		{Kind: ChangeRequireChanged, Version: "1.1.0", Name: "php", Old: ">=7.4", New: ">=8.0"},
+	}, diff.Changes)
+
+	redFlags := diff.RedFlags()
+	assert.Len(t, redFlags, 3)
+	assert.Len(t, diff.Filter(ChangeVersionAdded, ChangeVersionRemoved), 2)
+}
+
+func TestPackages_SameSnapshot(t *testing.T) {
+	diff := Packages(parsePackageInfo(t, oldSnapshot), parsePackageInfo(t, oldSnapshot))
+	assert.False(t, diff.HasChanges())
+	assert.Empty(t, diff.RedFlags())
+}
+
+func TestPackages_NewPackage(t *testing.T) {
+	diff := Packages(nil, parsePackageInfo(t, oldSnapshot))
+	assert.Len(t, diff.Filter(ChangeVersionAdded), 4)
+	assert.Len(t, diff.Filter(ChangeMaintainerAdded), 2)
+	assert.Empty(t, diff.Filter(ChangeLicenseChanged))
+
+	// Versions are ordered by version number, not by string
+	versions := make([]string, 0)
+	for _, change := range diff.Filter(ChangeVersionAdded) {
+		versions = append(versions, change.Version)
+	}
+	assert.Equal(t, []string{"dev-main", "0.9.0", "1.0.0", "1.1.0"}, versions)
+}
+
+func TestPackages_LicenseChangedInPlace(t *testing.T) {
+	old := parsePackageInfo(t, `{"package": {"name": "vendor/package", "versions": {"1.0.0": {"license": ["MIT"]}}}}`)
+	new := parsePackageInfo(t, `{"package": {"name": "vendor/package", "versions": {"1.0.0": {"license": ["proprietary"]}}}}`)
+	assert.Equal(t, []*Change{
+		{Kind: ChangeLicenseChanged, Version: "1.0.0", Old: "MIT", New: "proprietary"},
+	}, Packages(old, new).Changes)
+}
+
+func TestPackages_NullVersions(t *testing.T) {
+	old := parsePackageInfo(t, `{"package": {"name": "vendor/package", "versions": {"1.0.0": null, "1.1.0": {}}}}`)
+	new := parsePackageInfo(t, `{"package": {"name": "vendor/package", "versions": {"1.0.0": {}, "1.1.0": null, "2.0.0": null}}}`)
+	// null versions are skipped on both sides instead of panicking
+	assert.NotPanics(t, func() { Packages(old, new) })
+	assert.Equal(t, []*Change{
+		{Kind: ChangeVersionAdded, Version: "1.0.0"},
+	}, Packages(old, new).Changes)
+}
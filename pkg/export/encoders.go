@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文本格式中单元格的写法
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// 和 json.Marshal 一样，但是不转义 <、>、&，版本约束里面经常会出现这些字符
+func marshalJSON(value interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+type jsonLinesEncoder struct {
+	writer *bufio.Writer
+	keys   [][]byte
+}
+
+func newJSONLinesEncoder(writer io.Writer, columns []Column) (Encoder, error) {
+	keys := make([][]byte, 0, len(columns))
+	for _, column := range columns {
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return &jsonLinesEncoder{writer: bufio.NewWriter(writer), keys: keys}, nil
+}
+
+// 手动拼接而不是使用 map，这样字段的顺序和列的顺序一致
+func (x *jsonLinesEncoder) Encode(row []interface{}) error {
+	x.writer.WriteByte('{')
+	for i, value := range row {
+		if i > 0 {
+			x.writer.WriteByte(',')
+		}
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339)
+		}
+		data, err := marshalJSON(value)
+		if err != nil {
+			return err
+		}
+		x.writer.Write(x.keys[i])
+		x.writer.WriteByte(':')
+		x.writer.Write(data)
+	}
+	x.writer.WriteString("}\n")
+	// 每行都刷新一下，方便下游边写边读
+	return x.writer.Flush()
+}
+
+func (x *jsonLinesEncoder) Close() error {
+	return x.writer.Flush()
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+	cells  []string
+}
+
+func newCSVEncoder(writer io.Writer, columns []Column) (Encoder, error) {
+	csvWriter := csv.NewWriter(writer)
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{writer: csvWriter, cells: make([]string, len(columns))}, nil
+}
+
+func (x *csvEncoder) Encode(row []interface{}) error {
+	for i, value := range row {
+		x.cells[i] = formatCell(value)
+	}
+	return x.writer.Write(x.cells)
+}
+
+func (x *csvEncoder) Close() error {
+	x.writer.Flush()
+	return x.writer.Error()
+}
+
+type markdownEncoder struct {
+	writer *bufio.Writer
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+func newMarkdownEncoder(writer io.Writer, columns []Column) (Encoder, error) {
+	buffered := bufio.NewWriter(writer)
+	names := make([]string, 0, len(columns))
+	separators := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, markdownEscaper.Replace(column.Name))
+		// 数字右对齐
+		switch column.Type {
+		case ColumnInt64, ColumnFloat64:
+			separators = append(separators, "---:")
+		default:
+			separators = append(separators, "---")
+		}
+	}
+	buffered.WriteString("| " + strings.Join(names, " | ") + " |\n")
+	buffered.WriteString("| " + strings.Join(separators, " | ") + " |\n")
+	return &markdownEncoder{writer: buffered}, buffered.Flush()
+}
+
+func (x *markdownEncoder) Encode(row []interface{}) error {
+	cells := make([]string, len(row))
+	for i, value := range row {
+		cells[i] = markdownEscaper.Replace(formatCell(value))
+	}
+	x.writer.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	return x.writer.Flush()
+}
+
+func (x *markdownEncoder) Close() error {
+	return x.writer.Flush()
+}
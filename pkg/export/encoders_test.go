@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var encoderRows = []*row{
+	{name: "monolog/monolog", count: 900, at: time.Date(2023, 10, 27, 15, 32, 31, 0, time.FixedZone("CST", 8*3600))},
+	{name: "a|b, \"c\" <&>", count: 0},
+}
+
+func TestJSONLinesEncoder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeAll(buffer, FormatJSONLines, rowSchema, encoderRows))
+	// Keys keep the column order and times are in UTC
+	assert.Equal(t, `{"name":"monolog/monolog","count":900,"at":"2023-10-27T07:32:31Z"}`+"\n"+
+		`{"name":"a|b, \"c\" <&>","count":0,"at":null}`+"\n", buffer.String())
+}
+
+func TestCSVEncoder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeAll(buffer, FormatCSV, rowSchema, encoderRows))
+	assert.Equal(t, "name,count,at\n"+
+		"monolog/monolog,900,2023-10-27T07:32:31Z\n"+
+		"\"a|b, \"\"c\"\" <&>\",0,\n", buffer.String())
+
+	// The header is written even when there are no records
+	buffer.Reset()
+	assert.NoError(t, writeAll(buffer, FormatCSV, rowSchema, nil))
+	assert.Equal(t, "name,count,at\n", buffer.String())
+}
+
+func TestMarkdownEncoder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeAll(buffer, FormatMarkdown, rowSchema, encoderRows))
+	assert.Equal(t, "| name | count | at |\n"+
+		"| --- | ---: | --- |\n"+
+		"| monolog/monolog | 900 | 2023-10-27T07:32:31Z |\n"+
+		"| a\\|b, \"c\" <&> | 0 |  |\n", buffer.String())
+}
+
+func TestFormatCell(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{"x", "x"},
+		{int64(-3), "-3"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{time.Unix(0, 0), "1970-01-01T00:00:00Z"},
+		{3, "3"},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, formatCell(testCase.value))
+	}
+}
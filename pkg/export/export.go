@@ -0,0 +1,159 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Format 表示输出格式
+type Format string
+
+const (
+	// FormatJSONLines 每行一个 JSON 对象，字段和表格的列一致
+	FormatJSONLines Format = "jsonl"
+
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+
+	// FormatParquet Apache Parquet 列式存储格式，可以直接导入数据仓库
+	FormatParquet Format = "parquet"
+)
+
+// ColumnType 表示列的类型，决定了 Parquet 中的物理类型以及文本格式中的写法
+type ColumnType string
+
+const (
+	ColumnString  ColumnType = "string"
+	ColumnInt64   ColumnType = "int64"
+	ColumnFloat64 ColumnType = "float64"
+	ColumnBool    ColumnType = "bool"
+	// ColumnTime 文本格式中是 RFC 3339，Parquet 中是毫秒精度的时间戳
+	ColumnTime ColumnType = "time"
+)
+
+// Column 表示输出表格中的一列
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Encoder 把拍平之后的行写入输出，每种输出格式实现一个 Encoder。
+// row 中的值和列一一对应，类型分别是 string、int64、float64、bool、time.Time，也可以是 nil 表示没有值
+type Encoder interface {
+	Encode(row []interface{}) error
+
+	// Close 写入剩余的内容，比如 Parquet 的 footer，不会关闭底层的 io.Writer
+	Close() error
+}
+
+// EncoderFactory 创建某种格式的 Encoder
+type EncoderFactory func(writer io.Writer, columns []Column) (Encoder, error)
+
+var (
+	encodersLock sync.RWMutex
+	encoders     = map[Format]EncoderFactory{
+		FormatJSONLines: newJSONLinesEncoder,
+		FormatCSV:       newCSVEncoder,
+		FormatMarkdown:  newMarkdownEncoder,
+		FormatParquet:   newParquetEncoder,
+	}
+)
+
+// RegisterFormat 注册自定义的输出格式，已经存在的格式会被覆盖
+func RegisterFormat(format Format, factory EncoderFactory) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+	encoders[format] = factory
+}
+
+// Formats 返回所有支持的输出格式
+func Formats() []Format {
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+	formats := make([]Format, 0, len(encoders))
+	for format := range encoders {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return formats[i] < formats[j]
+	})
+	return formats
+}
+
+// NewEncoder 创建给定格式的 Encoder
+func NewEncoder(format Format, writer io.Writer, columns []Column) (Encoder, error) {
+	encodersLock.RLock()
+	factory, ok := encoders[format]
+	encodersLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return factory(writer, columns)
+}
+
+// Schema 描述一种记录如何拍平成表格，嵌套的结构展开为多列（比如 downloads_total），列表要么合并成一列，要么展开成多行
+type Schema[T any] struct {
+	Columns []Column
+
+	// Rows 把一条记录拍平成一行或者多行
+	Rows func(record T) [][]interface{}
+}
+
+// Writer 以流的形式把记录写入输出，写完之后需要调用 Close
+type Writer[T any] struct {
+	schema  *Schema[T]
+	encoder Encoder
+}
+
+// NewWriter 创建一个按照 schema 拍平记录的 Writer
+func NewWriter[T any](writer io.Writer, format Format, schema *Schema[T]) (*Writer[T], error) {
+	encoder, err := NewEncoder(format, writer, schema.Columns)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer[T]{schema: schema, encoder: encoder}, nil
+}
+
+// Write 写入一条记录
+func (x *Writer[T]) Write(record T) error {
+	for _, row := range x.schema.Rows(record) {
+		if len(row) != len(x.schema.Columns) {
+			return fmt.Errorf("row has %d values, expected %d columns", len(row), len(x.schema.Columns))
+		}
+		if err := x.encoder.Encode(normalizeRow(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 结束写入
+func (x *Writer[T]) Close() error {
+	return x.encoder.Close()
+}
+
+// 零值的时间当做没有值
+func normalizeRow(row []interface{}) []interface{} {
+	for i, value := range row {
+		if t, ok := value.(time.Time); ok && t.IsZero() {
+			row[i] = nil
+		}
+	}
+	return row
+}
+
+func writeAll[T any](writer io.Writer, format Format, schema *Schema[T], records []T) error {
+	w, err := NewWriter(writer, format, schema)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
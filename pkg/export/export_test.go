@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type row struct {
+	name  string
+	count int64
+	at    time.Time
+}
+
+var rowSchema = &Schema[*row]{
+	Columns: []Column{
+		{Name: "name", Type: ColumnString},
+		{Name: "count", Type: ColumnInt64},
+		{Name: "at", Type: ColumnTime},
+	},
+	Rows: func(record *row) [][]interface{} {
+		return [][]interface{}{{record.name, record.count, record.at}}
+	},
+}
+
+type recordingEncoder struct {
+	rows   [][]interface{}
+	closed bool
+}
+
+func (x *recordingEncoder) Encode(row []interface{}) error {
+	x.rows = append(x.rows, append([]interface{}{}, row...))
+	return nil
+}
+
+func (x *recordingEncoder) Close() error {
+	x.closed = true
+	return nil
+}
+
+func TestFormats(t *testing.T) {
+	formats := Formats()
+	assert.Contains(t, formats, FormatJSONLines)
+	assert.Contains(t, formats, FormatCSV)
+	assert.Contains(t, formats, FormatMarkdown)
+	assert.Contains(t, formats, FormatParquet)
+
+	_, err := NewEncoder("yaml", &bytes.Buffer{}, rowSchema.Columns)
+	assert.EqualError(t, err, `unknown export format "yaml"`)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	encoder := &recordingEncoder{}
+	RegisterFormat("recording", func(writer io.Writer, columns []Column) (Encoder, error) {
+		return encoder, nil
+	})
+	defer func() {
+		encodersLock.Lock()
+		delete(encoders, "recording")
+		encodersLock.Unlock()
+	}()
+
+	w, err := NewWriter(&bytes.Buffer{}, "recording", rowSchema)
+	assert.NoError(t, err)
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, w.Write(&row{name: "a", count: 1, at: at}))
+	// A zero time is written as a missing value
+	assert.NoError(t, w.Write(&row{name: "b", count: 2}))
+	assert.NoError(t, w.Close())
+
+	assert.True(t, encoder.closed)
+	assert.Equal(t, [][]interface{}{{"a", int64(1), at}, {"b", int64(2), nil}}, encoder.rows)
+}
+
+func TestWriter_ColumnMismatch(t *testing.T) {
+	schema := &Schema[string]{
+		Columns: []Column{{Name: "a", Type: ColumnString}, {Name: "b", Type: ColumnString}},
+		Rows: func(record string) [][]interface{} {
+			return [][]interface{}{{record}}
+		},
+	}
+	w, err := NewWriter(&bytes.Buffer{}, FormatCSV, schema)
+	assert.NoError(t, err)
+	assert.EqualError(t, w.Write("x"), "row has 1 values, expected 2 columns")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriter_WriteError(t *testing.T) {
+	err := writeAll(failingWriter{}, FormatJSONLines, rowSchema, []*row{{name: "a"}})
+	assert.EqualError(t, err, "disk full")
+}
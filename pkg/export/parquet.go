@@ -0,0 +1,98 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Parquet 的 row group 在内存中攒够之后才会写出，所以这里是按 row group 流式写入的
+type parquetEncoder struct {
+	writer  *writer.CSVWriter
+	columns []Column
+}
+
+func newParquetEncoder(w io.Writer, columns []Column) (Encoder, error) {
+	metadata := make([]string, 0, len(columns))
+	for _, column := range columns {
+		field, err := parquetField(column)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, field)
+	}
+	parquetWriter, err := writer.NewCSVWriterFromWriter(metadata, w, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetEncoder{writer: parquetWriter, columns: columns}, nil
+}
+
+// 所有的列都是可以为空的
+func parquetField(column Column) (string, error) {
+	if strings.ContainsAny(column.Name, ",=") {
+		return "", fmt.Errorf("invalid parquet column name %q", column.Name)
+	}
+	var physical string
+	switch column.Type {
+	case ColumnString:
+		physical = "type=BYTE_ARRAY, convertedtype=UTF8"
+	case ColumnInt64:
+		physical = "type=INT64"
+	case ColumnFloat64:
+		physical = "type=DOUBLE"
+	case ColumnBool:
+		physical = "type=BOOLEAN"
+	case ColumnTime:
+		physical = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	default:
+		return "", fmt.Errorf("unsupported column type %q of column %s", column.Type, column.Name)
+	}
+	return fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", column.Name, physical), nil
+}
+
+// 写入的行在 row group 写出之前会一直被引用，所以每行都要用新的切片
+func (x *parquetEncoder) Encode(row []interface{}) error {
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		converted, err := parquetValue(x.columns[i], value)
+		if err != nil {
+			return err
+		}
+		values[i] = converted
+	}
+	return x.writer.Write(values)
+}
+
+func parquetValue(column Column, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	ok := false
+	switch column.Type {
+	case ColumnString:
+		_, ok = value.(string)
+	case ColumnInt64:
+		_, ok = value.(int64)
+	case ColumnFloat64:
+		_, ok = value.(float64)
+	case ColumnBool:
+		_, ok = value.(bool)
+	case ColumnTime:
+		var t time.Time
+		if t, ok = value.(time.Time); ok {
+			return t.UnixMilli(), nil
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("column %s expects %s, got %T", column.Name, column.Type, value)
+	}
+	return value, nil
+}
+
+func (x *parquetEncoder) Close() error {
+	return x.writer.WriteStop()
+}
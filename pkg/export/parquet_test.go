@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// readParquetColumn reads all values of one column from a parquet file
+func readParquetColumn(t *testing.T, data []byte, column string) []interface{} {
+	file := buffer.NewBufferFileFromBytes(data)
+	r, err := reader.NewParquetColumnReader(file, 1)
+	assert.NoError(t, err)
+	defer r.ReadStop()
+	values, _, _, err := r.ReadColumnByPath(r.SchemaHandler.GetRootExName()+"\x01"+column, r.GetNumRows())
+	assert.NoError(t, err)
+	return values
+}
+
+func TestParquetEncoder(t *testing.T) {
+	schema := &Schema[*row]{
+		Columns: append(append([]Column{}, rowSchema.Columns...),
+			Column{Name: "ratio", Type: ColumnFloat64},
+			Column{Name: "ok", Type: ColumnBool}),
+		Rows: func(record *row) [][]interface{} {
+			return [][]interface{}{{record.name, record.count, record.at, float64(record.count) / 2, record.count > 0}}
+		},
+	}
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeAll(buffer, FormatParquet, schema, encoderRows))
+	data := buffer.Bytes()
+	assert.Equal(t, "PAR1", string(data[:4]))
+
+	assert.Equal(t, []interface{}{"monolog/monolog", "a|b, \"c\" <&>"}, readParquetColumn(t, data, "name"))
+	assert.Equal(t, []interface{}{int64(900), int64(0)}, readParquetColumn(t, data, "count"))
+	assert.Equal(t, []interface{}{encoderRows[0].at.UnixMilli(), nil}, readParquetColumn(t, data, "at"))
+	assert.Equal(t, []interface{}{450.0, 0.0}, readParquetColumn(t, data, "ratio"))
+	assert.Equal(t, []interface{}{true, false}, readParquetColumn(t, data, "ok"))
+}
+
+func TestParquetEncoder_InvalidColumns(t *testing.T) {
+	_, err := NewEncoder(FormatParquet, &bytes.Buffer{}, []Column{{Name: "a,b", Type: ColumnString}})
+	assert.EqualError(t, err, `invalid parquet column name "a,b"`)
+
+	_, err = NewEncoder(FormatParquet, &bytes.Buffer{}, []Column{{Name: "a", Type: "decimal"}})
+	assert.EqualError(t, err, `unsupported column type "decimal" of column a`)
+
+	encoder, err := NewEncoder(FormatParquet, &bytes.Buffer{}, []Column{{Name: "a", Type: ColumnInt64}})
+	assert.NoError(t, err)
+	assert.EqualError(t, encoder.Encode([]interface{}{"x"}), "column a expects int64, got string")
+	assert.EqualError(t, encoder.Encode([]interface{}{time.Now()}), "column a expects int64, got time.Time")
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// PackageSchema 包名列表，一个包一行
+var PackageSchema = &Schema[*repository.Package]{
+	Columns: []Column{
+		{Name: "name", Type: ColumnString},
+	},
+	Rows: func(record *repository.Package) [][]interface{} {
+		return [][]interface{}{{record.Name}}
+	},
+}
+
+// StatisticsSchema 仓库整体的统计信息，只有一行
+var StatisticsSchema = &Schema[*response.StatisticsResponse]{
+	Columns: []Column{
+		{Name: "totals_downloads", Type: ColumnInt64},
+		{Name: "totals_packages", Type: ColumnInt64},
+		{Name: "totals_versions", Type: ColumnInt64},
+	},
+	Rows: func(record *response.StatisticsResponse) [][]interface{} {
+		return [][]interface{}{{record.Totals.Downloads, int64(record.Totals.Packages), int64(record.Totals.Versions)}}
+	},
+}
+
+// AdvisorySchema 安全公告，一个公告一行，sources 合并成 name:remoteId;name:remoteId 的形式
+var AdvisorySchema = &Schema[*response.Advisory]{
+	Columns: []Column{
+		{Name: "package_name", Type: ColumnString},
+		{Name: "advisory_id", Type: ColumnString},
+		{Name: "remote_id", Type: ColumnString},
+		{Name: "title", Type: ColumnString},
+		{Name: "link", Type: ColumnString},
+		{Name: "cve", Type: ColumnString},
+		{Name: "affected_versions", Type: ColumnString},
+		{Name: "source", Type: ColumnString},
+		{Name: "reported_at", Type: ColumnTime},
+		{Name: "composer_repository", Type: ColumnString},
+		{Name: "severity", Type: ColumnString},
+		{Name: "sources", Type: ColumnString},
+	},
+	Rows: func(record *response.Advisory) [][]interface{} {
+		sources := make([]string, 0, len(record.Sources))
+		for _, source := range record.Sources {
+			sources = append(sources, source.Name+":"+source.RemoteID)
+		}
+		return [][]interface{}{{
+			record.PackageName,
+			record.AdvisoryID,
+			record.RemoteID,
+			record.Title,
+			record.Link,
+			record.Cve,
+			record.AffectedVersions,
+			record.Source,
+			record.ReportedAtTime(),
+			record.ComposerRepository,
+			string(record.Severity),
+			strings.Join(sources, ";"),
+		}}
+	},
+}
+
+// PackageInfoSchema 包的详情，一个包一行，版本只保留版本号，需要版本的详细信息的话使用 VersionSchema
+var PackageInfoSchema = &Schema[*composer_crawler.ComposerPackageInfo]{
+	Columns: []Column{
+		{Name: "package_name", Type: ColumnString},
+		{Name: "description", Type: ColumnString},
+		{Name: "type", Type: ColumnString},
+		{Name: "repository", Type: ColumnString},
+		{Name: "language", Type: ColumnString},
+		{Name: "time", Type: ColumnTime},
+		{Name: "maintainers", Type: ColumnString},
+		{Name: "downloads_total", Type: ColumnInt64},
+		{Name: "downloads_monthly", Type: ColumnInt64},
+		{Name: "downloads_daily", Type: ColumnInt64},
+		{Name: "github_stars", Type: ColumnInt64},
+		{Name: "github_watchers", Type: ColumnInt64},
+		{Name: "github_forks", Type: ColumnInt64},
+		{Name: "github_open_issues", Type: ColumnInt64},
+		{Name: "dependents", Type: ColumnInt64},
+		{Name: "suggesters", Type: ColumnInt64},
+		{Name: "favers", Type: ColumnInt64},
+		{Name: "abandoned", Type: ColumnBool},
+		{Name: "abandoned_replacement", Type: ColumnString},
+		{Name: "versions_count", Type: ColumnInt64},
+		{Name: "versions", Type: ColumnString},
+		{Name: "package_info_md5", Type: ColumnString},
+		{Name: "create_time", Type: ColumnTime},
+		{Name: "update_time", Type: ColumnTime},
+		{Name: "change_time", Type: ColumnTime},
+	},
+	Rows: func(record *composer_crawler.ComposerPackageInfo) [][]interface{} {
+		pkg := &record.Package
+		name := record.PackageName
+		if name == "" {
+			name = pkg.Name
+		}
+		maintainers := make([]string, 0, len(pkg.Maintainers))
+		for _, maintainer := range pkg.Maintainers {
+			maintainers = append(maintainers, maintainer.Name)
+		}
+		versions := sortedVersions(pkg.Versions)
+		return [][]interface{}{{
+			name,
+			pkg.Description,
+			pkg.Type,
+			pkg.Repository,
+			pkg.Language,
+			pkg.Time,
+			strings.Join(maintainers, ","),
+			int64(pkg.Downloads.Total),
+			int64(pkg.Downloads.Monthly),
+			int64(pkg.Downloads.Daily),
+			int64(pkg.GithubStars),
+			int64(pkg.GithubWatchers),
+			int64(pkg.GithubForks),
+			int64(pkg.GithubOpenIssues),
+			int64(pkg.Dependents),
+			int64(pkg.Suggesters),
+			int64(pkg.Favers),
+			pkg.Abandoned.IsAbandoned,
+			pkg.Abandoned.Replacement,
+			int64(len(versions)),
+			strings.Join(versions, ","),
+			record.PackageInfoMd5,
+			timeOrNil(record.CreateTime),
+			timeOrNil(record.UpdateTime),
+			timeOrNil(record.ChangeTime),
+		}}
+	},
+}
+
+// VersionSchema 包的版本，一个版本一行，require 以 JSON 字符串的形式保存
+var VersionSchema = &Schema[*composer_crawler.ComposerPackageInfo]{
+	Columns: []Column{
+		{Name: "package_name", Type: ColumnString},
+		{Name: "version", Type: ColumnString},
+		{Name: "version_normalized", Type: ColumnString},
+		{Name: "type", Type: ColumnString},
+		{Name: "time", Type: ColumnTime},
+		{Name: "license", Type: ColumnString},
+		{Name: "homepage", Type: ColumnString},
+		{Name: "dist_type", Type: ColumnString},
+		{Name: "dist_url", Type: ColumnString},
+		{Name: "dist_reference", Type: ColumnString},
+		{Name: "dist_shasum", Type: ColumnString},
+		{Name: "source_type", Type: ColumnString},
+		{Name: "source_url", Type: ColumnString},
+		{Name: "source_reference", Type: ColumnString},
+		{Name: "require", Type: ColumnString},
+	},
+	Rows: func(record *composer_crawler.ComposerPackageInfo) [][]interface{} {
+		name := record.PackageName
+		if name == "" {
+			name = record.Package.Name
+		}
+		rows := make([][]interface{}, 0, len(record.Package.Versions))
+		for _, key := range sortedVersions(record.Package.Versions) {
+			version := record.Package.Versions[key]
+			if version == nil {
+				continue
+			}
+			versionString := version.Version
+			if versionString == "" {
+				versionString = key
+			}
+			var require interface{}
+			if len(version.Require) > 0 {
+				// map 序列化的时候 key 是排好序的，输出是稳定的
+				if data, err := marshalJSON(version.Require); err == nil {
+					require = string(data)
+				}
+			}
+			rows = append(rows, []interface{}{
+				name,
+				versionString,
+				version.VersionNormalized,
+				version.Type,
+				version.Time,
+				strings.Join(version.License, ","),
+				version.Homepage,
+				version.Dist.Type,
+				version.Dist.URL,
+				version.Dist.Reference,
+				version.Dist.Shasum,
+				version.Source.Type,
+				version.Source.URL,
+				version.Source.Reference,
+				require,
+			})
+		}
+		return rows
+	},
+}
+
+func sortedVersions(versions map[string]*composer_crawler.Version) []string {
+	keys := make([]string, 0, len(versions))
+	for key := range versions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func timeOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// WritePackages 导出包名列表
+func WritePackages(writer io.Writer, format Format, packages []*repository.Package) error {
+	return writeAll(writer, format, PackageSchema, packages)
+}
+
+// WriteStatistics 导出仓库整体的统计信息
+func WriteStatistics(writer io.Writer, format Format, statistics *response.StatisticsResponse) error {
+	return writeAll(writer, format, StatisticsSchema, []*response.StatisticsResponse{statistics})
+}
+
+// WriteAdvisories 导出安全公告，按包名排序，同一个包的公告按严重程度排序
+func WriteAdvisories(writer io.Writer, format Format, advisories *response.AdvisoriesResponse) error {
+	names := make([]string, 0, len(advisories.Advisories))
+	for name := range advisories.Advisories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	records := make([]*response.Advisory, 0)
+	for _, name := range names {
+		packageAdvisories := make([]*response.Advisory, 0, len(advisories.Advisories[name]))
+		for _, advisory := range advisories.Advisories[name] {
+			if advisory == nil {
+				continue
+			}
+			if advisory.PackageName == "" {
+				copied := *advisory
+				copied.PackageName = name
+				advisory = &copied
+			}
+			packageAdvisories = append(packageAdvisories, advisory)
+		}
+		response.SortAdvisories(packageAdvisories)
+		records = append(records, packageAdvisories...)
+	}
+	return writeAll(writer, format, AdvisorySchema, records)
+}
+
+// WritePackageInfos 导出包的详情，一个包一行
+func WritePackageInfos(writer io.Writer, format Format, infos []*composer_crawler.ComposerPackageInfo) error {
+	return writeAll(writer, format, PackageInfoSchema, infos)
+}
+
+// WriteVersions 导出包的所有版本，一个版本一行
+func WriteVersions(writer io.Writer, format Format, infos []*composer_crawler.ComposerPackageInfo) error {
+	return writeAll(writer, format, VersionSchema, infos)
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePackages(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WritePackages(buffer, FormatCSV, []*repository.Package{{Name: "monolog/monolog"}, {Name: "symfony/console"}}))
+	assert.Equal(t, "name\nmonolog/monolog\nsymfony/console\n", buffer.String())
+}
+
+func TestWriteStatistics(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	statistics := &response.StatisticsResponse{Totals: response.Totals{Downloads: 1000, Packages: 3, Versions: 30}}
+	assert.NoError(t, WriteStatistics(buffer, FormatJSONLines, statistics))
+	assert.Equal(t, `{"totals_downloads":1000,"totals_packages":3,"totals_versions":30}`+"\n", buffer.String())
+}
+
+func TestWriteAdvisories(t *testing.T) {
+	advisories := &response.AdvisoriesResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"advisories": {
+		"symfony/http-kernel": [
+			{"advisoryId": "PKSA-2", "title": "Old issue", "severity": "low", "reportedAt": "2012-01-01 00:00:00"},
+			{"advisoryId": "PKSA-1", "packageName": "symfony/http-kernel", "title": "Cookie leak", "cve": "CVE-2022-24894", "severity": "medium",
+				"reportedAt": "2023-02-01 08:00:00", "sources": [{"name": "GitHub", "remoteId": "GHSA-h7vf"}, {"name": "FriendsOfPHP/security-advisories", "remoteId": "symfony/http-kernel/CVE-2022-24894.yaml"}]}
+		],
+		"guzzlehttp/guzzle": [{"advisoryId": "PKSA-3", "title": "Header leak", "severity": "high"}]
+	}}`), advisories))
+
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WriteAdvisories(buffer, FormatCSV, advisories))
+	assert.Equal(t, "package_name,advisory_id,remote_id,title,link,cve,affected_versions,source,reported_at,composer_repository,severity,sources\n"+
+		"guzzlehttp/guzzle,PKSA-3,,Header leak,,,,,,,high,\n"+
+		"symfony/http-kernel,PKSA-1,,Cookie leak,,CVE-2022-24894,,,2023-02-01T08:00:00Z,,medium,GitHub:GHSA-h7vf;FriendsOfPHP/security-advisories:symfony/http-kernel/CVE-2022-24894.yaml\n"+
+		"symfony/http-kernel,PKSA-2,,Old issue,,,,,2012-01-01T00:00:00Z,,low,\n", buffer.String())
+
+	// The response itself is not modified
+	assert.Equal(t, "", advisories.Advisories["symfony/http-kernel"][0].PackageName)
+}
+
+func newPackageInfo() *composer_crawler.ComposerPackageInfo {
+	info := &composer_crawler.ComposerPackageInfo{PackageName: "monolog/monolog", PackageInfoMd5: "abc"}
+	info.Package.Name = "monolog/monolog"
+	info.Package.Description = "Sends your logs to files, sockets, inboxes, databases and various web services"
+	info.Package.Maintainers = []*composer_crawler.Maintainers{{Name: "Seldaek"}, {Name: "jordi"}}
+	info.Package.Downloads.Total = 900
+	info.Package.Downloads.Monthly = 90
+	info.Package.GithubStars = 20000
+	info.Package.Abandoned.IsAbandoned = true
+	info.Package.Abandoned.Replacement = "monolog/monolog2"
+	stable := &composer_crawler.Version{Version: "3.5.0", License: []string{"MIT"}, Time: time.Date(2023, 10, 27, 15, 32, 31, 0, time.UTC),
+		Require: map[string]string{"psr/log": "^2.0 || ^3.0", "php": ">=8.1"}}
+	stable.Dist.Type = "zip"
+	stable.Dist.Reference = "c915e26"
+	info.Package.Versions = map[string]*composer_crawler.Version{
+		"3.5.0":    stable,
+		"dev-main": {},
+	}
+	updateTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	info.UpdateTime = &updateTime
+	return info
+}
+
+func TestWritePackageInfos(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WritePackageInfos(buffer, FormatJSONLines, []*composer_crawler.ComposerPackageInfo{newPackageInfo()}))
+	fields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "monolog/monolog", fields["package_name"])
+	assert.Equal(t, "Seldaek,jordi", fields["maintainers"])
+	assert.Equal(t, float64(900), fields["downloads_total"])
+	assert.Equal(t, float64(90), fields["downloads_monthly"])
+	assert.Equal(t, float64(20000), fields["github_stars"])
+	assert.Equal(t, true, fields["abandoned"])
+	assert.Equal(t, "monolog/monolog2", fields["abandoned_replacement"])
+	assert.Equal(t, float64(2), fields["versions_count"])
+	assert.Equal(t, "3.5.0,dev-main", fields["versions"])
+	assert.Nil(t, fields["time"])
+	assert.Nil(t, fields["create_time"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", fields["update_time"])
+	assert.Len(t, fields, len(PackageInfoSchema.Columns))
+}
+
+func TestWriteVersions(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WriteVersions(buffer, FormatCSV, []*composer_crawler.ComposerPackageInfo{newPackageInfo()}))
+	assert.Equal(t, "package_name,version,version_normalized,type,time,license,homepage,dist_type,dist_url,dist_reference,dist_shasum,source_type,source_url,source_reference,require\n"+
+		"monolog/monolog,3.5.0,,,2023-10-27T15:32:31Z,MIT,,zip,,c915e26,,,,,\"{\"\"php\"\":\"\">=8.1\"\",\"\"psr/log\"\":\"\"^2.0 || ^3.0\"\"}\"\n"+
+		"monolog/monolog,dev-main,,,,,,,,,,,,,\n", buffer.String())
+}
+
+func TestSchemas_Parquet(t *testing.T) {
+	// Every built-in schema can be written as parquet
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, WritePackageInfos(buffer, FormatParquet, []*composer_crawler.ComposerPackageInfo{newPackageInfo()}))
+	assert.Equal(t, []interface{}{int64(900)}, readParquetColumn(t, buffer.Bytes(), "downloads_total"))
+
+	buffer.Reset()
+	assert.NoError(t, WriteVersions(buffer, FormatParquet, []*composer_crawler.ComposerPackageInfo{newPackageInfo()}))
+	assert.Equal(t, []interface{}{"3.5.0", "dev-main"}, readParquetColumn(t, buffer.Bytes(), "version"))
+
+	buffer.Reset()
+	assert.NoError(t, WriteAdvisories(buffer, FormatParquet, &response.AdvisoriesResponse{}))
+	assert.NoError(t, WriteStatistics(&bytes.Buffer{}, FormatParquet, &response.StatisticsResponse{}))
+	assert.NoError(t, WritePackages(&bytes.Buffer{}, FormatParquet, []*repository.Package{{Name: "a/b"}}))
+}
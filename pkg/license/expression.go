@@ -0,0 +1,312 @@
+package license
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidExpression license 表达式的语法错误
+var ErrInvalidExpression = errors.New("invalid license expression")
+
+// Operator 表示 license 表达式中的组合方式
+type Operator string
+
+const (
+	// OperatorAnd 需要同时遵守所有的 license
+	OperatorAnd Operator = "AND"
+
+	// OperatorOr 可以任选其中一个 license
+	OperatorOr Operator = "OR"
+)
+
+// Expression 表示一个 SPDX license 表达式，叶子节点是单个 license，其它节点是 AND、OR 的组合
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/
+type Expression struct {
+
+	// 叶子节点的 license，认识的 license 是标准的 SPDX id，不认识的保留原始写法
+	License string `json:"license,omitempty"`
+
+	// 是否是 SPDX 中的 license，proprietary 以及各种自定义的写法都不是
+	Known bool `json:"known,omitempty"`
+
+	// 非 GNU 系列的 license 后面的 +，表示该版本或者更新的版本
+	OrLater bool `json:"orLater,omitempty"`
+
+	// WITH 后面的 exception
+	Exception string `json:"exception,omitempty"`
+
+	// 组合节点的组合方式和子节点
+	Operator Operator      `json:"operator,omitempty"`
+	Operands []*Expression `json:"operands,omitempty"`
+}
+
+// IsLeaf 是否是单个 license
+func (x *Expression) IsLeaf() bool {
+	return x.Operator == ""
+}
+
+// Licenses 返回表达式中出现的所有 license，按出现的顺序去重
+func (x *Expression) Licenses() []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+	x.walk(func(leaf *Expression) {
+		if !seen[leaf.License] {
+			seen[leaf.License] = true
+			result = append(result, leaf.License)
+		}
+	})
+	return result
+}
+
+func (x *Expression) walk(visit func(leaf *Expression)) {
+	if x.IsLeaf() {
+		visit(x)
+		return
+	}
+	for _, operand := range x.Operands {
+		operand.walk(visit)
+	}
+}
+
+// String 返回标准化之后的表达式，最外层不加括号
+func (x *Expression) String() string {
+	return x.format(func(leaf *Expression) string {
+		return leaf.License
+	})
+}
+
+// SPDX 返回可以放到 SBOM 中的表达式，不认识的 license 会被转换成 LicenseRef-xxx 的形式
+func (x *Expression) SPDX() string {
+	return x.format(func(leaf *Expression) string {
+		if leaf.Known {
+			return leaf.License
+		}
+		return "LicenseRef-" + strings.Trim(licenseRefRegex.ReplaceAllString(leaf.License, "-"), "-")
+	})
+}
+
+var licenseRefRegex = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+func (x *Expression) format(license func(leaf *Expression) string) string {
+	if x.IsLeaf() {
+		s := license(x)
+		if x.OrLater {
+			s += "+"
+		}
+		if x.Exception != "" {
+			s += " WITH " + x.Exception
+		}
+		return s
+	}
+	parts := make([]string, 0, len(x.Operands))
+	for _, operand := range x.Operands {
+		part := operand.format(license)
+		if !operand.IsLeaf() {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " "+string(x.Operator)+" ")
+}
+
+// Parse 解析并标准化一个 SPDX license 表达式，运算符不区分大小写，废弃的 id 和常见的别名会被转换为标准的 SPDX id。
+// 不认识的 license 不算错误，只有语法错误的时候才返回 ErrInvalidExpression
+func Parse(expression string) (*Expression, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, fmt.Errorf("%w: empty expression", ErrInvalidExpression)
+	}
+	// 带空格的别名，比如 Apache 2.0、New BSD
+	if id := Lookup(expression); id != "" {
+		return &Expression{License: id, Known: true}, nil
+	}
+
+	parser := &parser{tokens: tokenize(expression)}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q in %q", ErrInvalidExpression, parser.tokens[parser.position], expression)
+	}
+	return result, nil
+}
+
+// Normalize 返回标准化之后的表达式
+func Normalize(expression string) (string, error) {
+	result, err := Parse(expression)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// FromComposer 把 composer.json 中的 license 列表转换为表达式，多个 license 表示可以任选其一，
+// 无法解析的写法（比如 "My Company License"）会作为一个不认识的 license 保留下来，列表为空的时候返回 nil
+func FromComposer(licenses []string) *Expression {
+	operands := make([]*Expression, 0, len(licenses))
+	for _, license := range licenses {
+		if strings.TrimSpace(license) == "" {
+			continue
+		}
+		expression, err := Parse(license)
+		if err != nil {
+			expression = &Expression{License: strings.TrimSpace(license)}
+		}
+		operands = append(operands, expression)
+	}
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return operands[0]
+	default:
+		return combine(OperatorOr, operands)
+	}
+}
+
+// 组合的时候把相同运算符的子节点展开，(MIT OR ISC) OR GPL 和 MIT OR ISC OR GPL 是一样的
+func combine(operator Operator, operands []*Expression) *Expression {
+	flattened := make([]*Expression, 0, len(operands))
+	for _, operand := range operands {
+		if operand.Operator == operator {
+			flattened = append(flattened, operand.Operands...)
+		} else {
+			flattened = append(flattened, operand)
+		}
+	}
+	return &Expression{Operator: operator, Operands: flattened}
+}
+
+func tokenize(expression string) []string {
+	tokens := make([]string, 0)
+	current := strings.Builder{}
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, c := range expression {
+		switch {
+		case c == '(' || c == ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type parser struct {
+	tokens   []string
+	position int
+}
+
+func (x *parser) peek() string {
+	if x.position < len(x.tokens) {
+		return x.tokens[x.position]
+	}
+	return ""
+}
+
+func (x *parser) next() string {
+	token := x.peek()
+	x.position++
+	return token
+}
+
+func (x *parser) parseOr() (*Expression, error) {
+	return x.parseBinary(OperatorOr, x.parseAnd)
+}
+
+func (x *parser) parseAnd() (*Expression, error) {
+	return x.parseBinary(OperatorAnd, x.parseWith)
+}
+
+func (x *parser) parseBinary(operator Operator, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Expression{first}
+	for strings.EqualFold(x.peek(), string(operator)) {
+		x.next()
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return combine(operator, operands), nil
+}
+
+func (x *parser) parseWith() (*Expression, error) {
+	atom, err := x.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(x.peek(), "WITH") {
+		return atom, nil
+	}
+	x.next()
+	if !atom.IsLeaf() {
+		return nil, fmt.Errorf("%w: WITH must follow a single license", ErrInvalidExpression)
+	}
+	exception := x.next()
+	if exception == "" || isKeyword(exception) {
+		return nil, fmt.Errorf("%w: missing exception after WITH", ErrInvalidExpression)
+	}
+	atom.Exception = lookupException(exception)
+	return atom, nil
+}
+
+func (x *parser) parseAtom() (*Expression, error) {
+	token := x.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrInvalidExpression)
+	case token == "(":
+		inner, err := x.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if x.next() != ")" {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", ErrInvalidExpression)
+		}
+		return inner, nil
+	case token == ")" || isKeyword(token):
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidExpression, token)
+	default:
+		return parseLicense(token), nil
+	}
+}
+
+func isKeyword(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
+
+// 解析单个 license，GPL-2.0+ 这种会转换为 GPL-2.0-or-later，其它的 + 保留为 OrLater
+func parseLicense(token string) *Expression {
+	if id := Lookup(token); id != "" {
+		return &Expression{License: id, Known: true}
+	}
+	if strings.HasSuffix(token, "+") {
+		if id := Lookup(strings.TrimSuffix(token, "+")); id != "" {
+			return &Expression{License: id, Known: true, OrLater: true}
+		}
+	}
+	if IsProprietary(token) {
+		return &Expression{License: "proprietary"}
+	}
+	return &Expression{License: token}
+}
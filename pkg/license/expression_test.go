@@ -0,0 +1,56 @@
+package license
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"MIT", "MIT"},
+		{"gpl-2.0+", "GPL-2.0-or-later"},
+		{"(MIT or GPL-3.0+)", "MIT OR GPL-3.0-or-later"},
+		{"MIT AND (LGPL-2.1 OR BSD-3-Clause)", "MIT AND (LGPL-2.1-only OR BSD-3-Clause)"},
+		{"MIT OR (ISC OR Zlib)", "MIT OR ISC OR Zlib"},
+		{"GPL-2.0 with classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{"Apache-1.1+", "Apache-1.1+"},
+		{"Apache 2.0", "Apache-2.0"},
+		{"MIT OR Custom-License", "MIT OR Custom-License"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := Normalize(tt.expression)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expression := range []string{"", "MIT OR", "(MIT", "MIT)", "AND MIT", "MIT WITH", "(MIT OR ISC) WITH LLVM-exception", "My License"} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := Parse(expression)
+			assert.True(t, errors.Is(err, ErrInvalidExpression))
+		})
+	}
+}
+
+func TestFromComposer(t *testing.T) {
+	assert.Nil(t, FromComposer(nil))
+	assert.Nil(t, FromComposer([]string{" "}))
+
+	expression := FromComposer([]string{"GPL-2.0+", "(MIT or ISC)", "My Company License", "proprietary"})
+	assert.Equal(t, "GPL-2.0-or-later OR MIT OR ISC OR My Company License OR proprietary", expression.String())
+	assert.Equal(t, "GPL-2.0-or-later OR MIT OR ISC OR LicenseRef-My-Company-License OR LicenseRef-proprietary", expression.SPDX())
+	assert.Equal(t, []string{"GPL-2.0-or-later", "MIT", "ISC", "My Company License", "proprietary"}, expression.Licenses())
+
+	single := FromComposer([]string{"mit"})
+	assert.True(t, single.IsLeaf())
+	assert.True(t, single.Known)
+	assert.Equal(t, "MIT", single.SPDX())
+}
@@ -0,0 +1,263 @@
+package license
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+)
+
+// Decision 表示 license 合规检查的结论
+type Decision string
+
+const (
+	DecisionAllowed Decision = "allowed"
+	DecisionReview  Decision = "review"
+	DecisionDenied  Decision = "denied"
+)
+
+func (x Decision) valid() bool {
+	return x == DecisionAllowed || x == DecisionReview || x == DecisionDenied
+}
+
+// 越大越好，OR 取最好的，AND 取最差的
+func (x Decision) rank() int {
+	switch x {
+	case DecisionAllowed:
+		return 2
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Policy 表示 license 合规策略，列表中可以是 SPDX id、别名、proprietary 或者以 * 结尾的前缀（比如 GPL-*），
+// 也可以是带 exception 的写法（比如 GPL-2.0-only WITH Classpath-exception-2.0），它比单独的 license 优先匹配
+type Policy struct {
+	Allowed []string `json:"allowed,omitempty"`
+	Denied  []string `json:"denied,omitempty"`
+	Review  []string `json:"review,omitempty"`
+
+	// 不在任何列表中的 license 的结论，为空的时候需要人工审核
+	Default Decision `json:"default,omitempty"`
+
+	// 包级别的例外，比如法务已经单独审核过的包，key 为包名
+	Packages map[string]Decision `json:"packages,omitempty"`
+
+	// 是否忽略开发环境依赖，它们不会随产品发布
+	IgnoreDev bool `json:"ignoreDev,omitempty"`
+}
+
+// ParsePolicy 从 JSON 解析策略
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	if policy.Default != "" && !policy.Default.valid() {
+		return nil, fmt.Errorf("invalid default decision %q", policy.Default)
+	}
+	for name, decision := range policy.Packages {
+		if !decision.valid() {
+			return nil, fmt.Errorf("invalid decision %q for package %s", decision, name)
+		}
+	}
+	return policy, nil
+}
+
+// ReadPolicy 从文件读取并解析策略
+func ReadPolicy(filepath string) (*Policy, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// Evaluate 根据策略判断一个 license 表达式，返回结论以及解释，expression 为空表示没有声明 license，需要人工审核
+func (x *Policy) Evaluate(expression *Expression) (Decision, string) {
+	if expression == nil {
+		return DecisionReview, "no license declared"
+	}
+	if expression.IsLeaf() {
+		return x.evaluateLicense(expression)
+	}
+
+	decisions := make([]Decision, len(expression.Operands))
+	reasons := make([]string, len(expression.Operands))
+	chosen := 0
+	for i, operand := range expression.Operands {
+		decisions[i], reasons[i] = x.Evaluate(operand)
+		better := decisions[i].rank() > decisions[chosen].rank()
+		worse := decisions[i].rank() < decisions[chosen].rank()
+		if (expression.Operator == OperatorOr && better) || (expression.Operator == OperatorAnd && worse) {
+			chosen = i
+		}
+	}
+	if expression.Operator == OperatorOr {
+		if len(expression.Operands) > 1 {
+			return decisions[chosen], fmt.Sprintf("%s; chose %s from %s", reasons[chosen], expression.Operands[chosen], expression)
+		}
+		return decisions[chosen], reasons[chosen]
+	}
+	return decisions[chosen], strings.Join(reasons, "; ")
+}
+
+func (x *Policy) evaluateLicense(leaf *Expression) (Decision, string) {
+	// 先匹配带 exception 的完整写法，再匹配单独的 license
+	candidates := []string{leaf.String()}
+	if leaf.Exception != "" || leaf.OrLater {
+		candidates = append(candidates, leaf.License)
+	}
+	for _, candidate := range candidates {
+		for _, list := range []struct {
+			patterns []string
+			decision Decision
+		}{
+			{x.Denied, DecisionDenied},
+			{x.Review, DecisionReview},
+			{x.Allowed, DecisionAllowed},
+		} {
+			if pattern, ok := matchAny(candidate, list.patterns); ok {
+				return list.decision, fmt.Sprintf("%s is %s by %q", leaf, list.decision, pattern)
+			}
+		}
+	}
+	decision := x.Default
+	if decision == "" {
+		decision = DecisionReview
+	}
+	if !leaf.Known && !IsProprietary(leaf.License) {
+		return decision, fmt.Sprintf("%s is not a known SPDX license and not in the policy, default %s", leaf, decision)
+	}
+	return decision, fmt.Sprintf("%s is not in the policy, default %s", leaf, decision)
+}
+
+// 判断 license 是否匹配列表中的某一项，返回匹配到的那一项
+func matchAny(license string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(strings.ToLower(license), strings.ToLower(strings.TrimSuffix(pattern, "*"))) {
+				return pattern, true
+			}
+			continue
+		}
+		normalized := pattern
+		if expression, err := Parse(pattern); err == nil && expression.IsLeaf() {
+			normalized = expression.String()
+		}
+		if strings.EqualFold(license, normalized) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// PackageResult 表示一个包的检查结果
+type PackageResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dev     bool   `json:"dev"`
+
+	// composer.lock 中声明的原始 license
+	Licenses []string `json:"licenses"`
+
+	// 标准化之后的表达式，没有声明 license 的时候为空
+	Expression string `json:"expression,omitempty"`
+
+	Decision    Decision `json:"decision"`
+	Explanation string   `json:"explanation"`
+}
+
+// Report 表示一个项目的 license 合规检查结果
+type Report struct {
+	Packages []*PackageResult `json:"packages"`
+}
+
+// Filter 返回结论为给定值的包
+func (x *Report) Filter(decision Decision) []*PackageResult {
+	result := make([]*PackageResult, 0)
+	for _, packageResult := range x.Packages {
+		if packageResult.Decision == decision {
+			result = append(result, packageResult)
+		}
+	}
+	return result
+}
+
+// Passed 是否所有的包都被允许
+func (x *Report) Passed() bool {
+	return len(x.Filter(DecisionAllowed)) == len(x.Packages)
+}
+
+// WriteJSON 以 JSON 格式输出检查结果
+func (x *Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(x)
+}
+
+// WriteMarkdown 以 Markdown 表格的形式输出检查结果，方便贴到发布说明或者发给法务
+func (x *Report) WriteMarkdown(writer io.Writer) error {
+	if _, err := io.WriteString(writer, "| Package | Version | License | Decision | Explanation |\n"+
+		"|---------|---------|---------|----------|-------------|\n"); err != nil {
+		return err
+	}
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	for _, p := range x.Packages {
+		name := p.Name
+		if p.Dev {
+			name += " (dev)"
+		}
+		if _, err := fmt.Fprintf(writer, "| %s | %s | %s | %s | %s |\n", escape.Replace(name), escape.Replace(p.Version),
+			escape.Replace(p.Expression), p.Decision, escape.Replace(p.Explanation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check 根据策略检查 composer.lock 中所有被锁定的包，composer.lock 中已经包含了完整的传递依赖
+func (x *Policy) Check(lock *manifest.ComposerLock) *Report {
+	report := &Report{Packages: make([]*PackageResult, 0)}
+	for _, lockPackage := range lock.AllPackages() {
+		dev := lock.IsDev(lockPackage.Name)
+		if dev && x.IgnoreDev {
+			continue
+		}
+		result := &PackageResult{
+			Name:     lockPackage.Name,
+			Version:  lockPackage.Version,
+			Dev:      dev,
+			Licenses: lockPackage.License,
+		}
+		if result.Licenses == nil {
+			result.Licenses = []string{}
+		}
+		expression := FromComposer(lockPackage.License)
+		if expression != nil {
+			result.Expression = expression.String()
+		}
+		if decision, ok := x.packageDecision(lockPackage.Name); ok {
+			result.Decision = decision
+			result.Explanation = fmt.Sprintf("package %s is %s by policy exception", lockPackage.Name, decision)
+		} else {
+			result.Decision, result.Explanation = x.Evaluate(expression)
+		}
+		report.Packages = append(report.Packages, result)
+	}
+	return report
+}
+
+func (x *Policy) packageDecision(packageName string) (Decision, bool) {
+	for name, decision := range x.Packages {
+		if strings.EqualFold(name, packageName) {
+			return decision, true
+		}
+	}
+	return "", false
+}
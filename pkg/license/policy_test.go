@@ -0,0 +1,108 @@
+package license
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `{
+	"allowed": ["MIT", "BSD-*", "Apache-2.0", "LGPL-2.1-or-later", "GPL-2.0-only WITH Classpath-exception-2.0"],
+	"denied": ["AGPL-*", "GPL-3.0+", "proprietary"],
+	"review": ["GPL-2.0"],
+	"packages": {"acme/internal": "allowed"}
+}`
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		expression string
+		want       Decision
+	}{
+		{"MIT", DecisionAllowed},
+		{"BSD-3-Clause", DecisionAllowed},
+		{"AGPL-3.0-only", DecisionDenied},
+		{"GPL-3.0-or-later", DecisionDenied},
+		{"GPL-2.0", DecisionReview},
+		{"GPL-2.0 WITH Classpath-exception-2.0", DecisionAllowed},
+		{"GPL-3.0+ OR MIT", DecisionAllowed},
+		{"MIT AND AGPL-3.0", DecisionDenied},
+		{"MIT AND (GPL-2.0 OR ISC)", DecisionReview},
+		{"LGPL-2.1+", DecisionAllowed},
+		{"Custom-License", DecisionReview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expression, err := Parse(tt.expression)
+			assert.NoError(t, err)
+			decision, explanation := policy.Evaluate(expression)
+			assert.Equal(t, tt.want, decision, explanation)
+			assert.NotEmpty(t, explanation)
+		})
+	}
+
+	decision, explanation := policy.Evaluate(nil)
+	assert.Equal(t, DecisionReview, decision)
+	assert.Equal(t, "no license declared", explanation)
+
+	expression, _ := Parse("GPL-3.0+ OR MIT")
+	_, explanation = policy.Evaluate(expression)
+	assert.Equal(t, `MIT is allowed by "MIT"; chose MIT from GPL-3.0-or-later OR MIT`, explanation)
+
+	_, explanation = (&Policy{Default: DecisionDenied}).Evaluate(FromComposer([]string{"My License"}))
+	assert.Equal(t, "My License is not a known SPDX license and not in the policy, default denied", explanation)
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	_, err := ParsePolicy([]byte(`{"default": "maybe"}`))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte(`{"packages": {"acme/a": "yes"}}`))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestPolicy_Check(t *testing.T) {
+	lock, err := manifest.ParseComposerLock([]byte(`{
+		"packages": [
+			{"name": "monolog/monolog", "version": "3.5.0", "license": ["MIT"]},
+			{"name": "acme/internal", "version": "1.0.0", "license": ["proprietary"]},
+			{"name": "acme/copyleft", "version": "2.0.0", "license": ["AGPL-3.0-or-later"]},
+			{"name": "acme/unlicensed", "version": "0.1.0"}
+		],
+		"packages-dev": [
+			{"name": "phpunit/phpunit", "version": "10.5.0", "license": ["BSD-3-Clause"]},
+			{"name": "acme/dev-tool", "version": "1.0.0", "license": "GPL-3.0"}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	assert.NoError(t, err)
+	report := policy.Check(lock)
+	if !assert.Len(t, report.Packages, 6) {
+		return
+	}
+	assert.Equal(t, DecisionAllowed, report.Packages[0].Decision)
+	assert.Equal(t, DecisionAllowed, report.Packages[1].Decision)
+	assert.Equal(t, "package acme/internal is allowed by policy exception", report.Packages[1].Explanation)
+	assert.Equal(t, DecisionDenied, report.Packages[2].Decision)
+	assert.Equal(t, DecisionReview, report.Packages[3].Decision)
+	assert.Equal(t, []string{}, report.Packages[3].Licenses)
+	assert.True(t, report.Packages[4].Dev)
+	assert.Equal(t, "GPL-3.0-only", report.Packages[5].Expression)
+	assert.Equal(t, DecisionReview, report.Packages[5].Decision)
+	assert.False(t, report.Passed())
+	assert.Len(t, report.Filter(DecisionDenied), 1)
+
+	policy.IgnoreDev = true
+	assert.Len(t, policy.Check(lock).Packages, 4)
+
+	buff := &bytes.Buffer{}
+	assert.NoError(t, report.WriteMarkdown(buff))
+	assert.Contains(t, buff.String(), "| acme/copyleft | 2.0.0 | AGPL-3.0-or-later | denied | AGPL-3.0-or-later is denied by \"AGPL-*\" |\n")
+}
@@ -0,0 +1,92 @@
+package license
+
+import (
+	"strings"
+)
+
+// composer 包中常见的 SPDX license id，不在这里面的会被当做自定义 license 处理
+// https://spdx.org/licenses/
+var spdxIds = map[string]string{}
+
+// GNU 系列的 license 在 SPDX 3.0 之后需要明确 -only 还是 -or-later，不带后缀的写法已经废弃
+var gnuLicenses = []string{
+	"GPL-1.0", "GPL-2.0", "GPL-3.0", "LGPL-2.0", "LGPL-2.1", "LGPL-3.0", "AGPL-1.0", "AGPL-3.0",
+	"GFDL-1.1", "GFDL-1.2", "GFDL-1.3",
+}
+
+// 废弃的 id、常见的非标准写法到 SPDX id 的映射，key 为小写
+var aliases = map[string]string{
+	"apache 2":            "Apache-2.0",
+	"apache 2.0":          "Apache-2.0",
+	"apache2":             "Apache-2.0",
+	"apache-2":            "Apache-2.0",
+	"apache license 2.0":  "Apache-2.0",
+	"apache license, 2.0": "Apache-2.0",
+	"bsd-2":               "BSD-2-Clause",
+	"bsd 2-clause":        "BSD-2-Clause",
+	"simplified bsd":      "BSD-2-Clause",
+	"bsd-3":               "BSD-3-Clause",
+	"bsd 3-clause":        "BSD-3-Clause",
+	"new bsd":             "BSD-3-Clause",
+	"new bsd license":     "BSD-3-Clause",
+	"modified bsd":        "BSD-3-Clause",
+	"mit license":         "MIT",
+	"the mit license":     "MIT",
+	"expat":               "MIT",
+	"gplv2":               "GPL-2.0-only",
+	"gplv2+":              "GPL-2.0-or-later",
+	"gplv3":               "GPL-3.0-only",
+	"gplv3+":              "GPL-3.0-or-later",
+	"lgplv2.1":            "LGPL-2.1-only",
+	"lgplv3":              "LGPL-3.0-only",
+	"agplv3":              "AGPL-3.0-only",
+}
+
+// 常见的 license exception，用在 WITH 后面
+var spdxExceptions = map[string]string{}
+
+func init() {
+	for _, id := range []string{
+		"0BSD", "AFL-3.0", "Apache-1.1", "Apache-2.0", "Artistic-2.0", "Beerware", "BlueOak-1.0.0",
+		"BSD-2-Clause", "BSD-3-Clause", "BSD-3-Clause-Clear", "BSD-4-Clause", "BSL-1.0",
+		"CC-BY-3.0", "CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0", "CECILL-2.1",
+		"EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2", "ISC", "JSON", "LPPL-1.3c", "MIT", "MIT-0", "MPL-1.1", "MPL-2.0",
+		"MS-PL", "NCSA", "OFL-1.1", "OpenSSL", "OSL-3.0", "PHP-3.0", "PHP-3.01", "PostgreSQL", "Python-2.0", "Ruby",
+		"Unicode-DFS-2016", "Unlicense", "Vim", "WTFPL", "X11", "Zlib",
+	} {
+		spdxIds[strings.ToLower(id)] = id
+	}
+	for _, id := range gnuLicenses {
+		spdxIds[strings.ToLower(id+"-only")] = id + "-only"
+		spdxIds[strings.ToLower(id+"-or-later")] = id + "-or-later"
+		aliases[strings.ToLower(id)] = id + "-only"
+		aliases[strings.ToLower(id+"+")] = id + "-or-later"
+	}
+	for _, id := range []string{
+		"Autoconf-exception-3.0", "Classpath-exception-2.0", "Font-exception-2.0", "GCC-exception-3.1",
+		"LLVM-exception", "OCaml-LGPL-linking-exception", "Universal-FOSS-exception-1.0",
+	} {
+		spdxExceptions[strings.ToLower(id)] = id
+	}
+}
+
+// Lookup 返回 license 对应的 SPDX id，会把废弃的 id 和常见的别名（比如 GPL-2.0+、Apache 2.0）转换为标准的 id，不认识的返回空字符串
+func Lookup(license string) string {
+	key := strings.ToLower(strings.TrimSpace(license))
+	if id, ok := spdxIds[key]; ok {
+		return id
+	}
+	return aliases[key]
+}
+
+// IsProprietary composer 中使用 proprietary 表示闭源的包
+func IsProprietary(license string) bool {
+	return strings.EqualFold(strings.TrimSpace(license), "proprietary")
+}
+
+func lookupException(exception string) string {
+	if id, ok := spdxExceptions[strings.ToLower(exception)]; ok {
+		return id
+	}
+	return exception
+}
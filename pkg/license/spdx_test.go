@@ -0,0 +1,30 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		license string
+		want    string
+	}{
+		{"mit", "MIT"},
+		{" Apache-2.0 ", "Apache-2.0"},
+		{"GPL-2.0", "GPL-2.0-only"},
+		{"GPL-2.0+", "GPL-2.0-or-later"},
+		{"lgpl-3.0-or-later", "LGPL-3.0-or-later"},
+		{"Apache 2.0", "Apache-2.0"},
+		{"New BSD", "BSD-3-Clause"},
+		{"proprietary", ""},
+		{"My License", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.license, func(t *testing.T) {
+			assert.Equal(t, tt.want, Lookup(tt.license))
+		})
+	}
+	assert.True(t, IsProprietary("Proprietary"))
+}
@@ -0,0 +1,43 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Abandoned 表示包是否被废弃，Packagist 和 composer.lock 中这个字段可能是 true，也可能是推荐的替代包的名字
+type Abandoned struct {
+	// 是否已经被废弃
+	IsAbandoned bool
+	// 推荐的替代包，可能为空
+	Replacement string
+}
+
+func (x Abandoned) MarshalJSON() ([]byte, error) {
+	if x.IsAbandoned && x.Replacement != "" {
+		return json.Marshal(x.Replacement)
+	}
+	return json.Marshal(x.IsAbandoned)
+}
+
+func (x *Abandoned) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var replacement string
+		if err := json.Unmarshal(data, &replacement); err != nil {
+			return err
+		}
+		*x = Abandoned{IsAbandoned: true, Replacement: replacement}
+		return nil
+	}
+	if bytes.Equal(data, []byte("null")) {
+		*x = Abandoned{}
+		return nil
+	}
+	var abandoned bool
+	if err := json.Unmarshal(data, &abandoned); err != nil {
+		return err
+	}
+	*x = Abandoned{IsAbandoned: abandoned}
+	return nil
+}
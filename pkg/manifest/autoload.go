@@ -0,0 +1,49 @@
+package manifest
+
+import "encoding/json"
+
+// Autoload 表示 composer.json 中的 autoload 和 autoload-dev 配置
+// https://getcomposer.org/doc/04-schema.md#autoload
+type Autoload struct {
+	// 命名空间前缀到目录的映射，一个前缀可以对应多个目录
+	Psr4 map[string]StringList `json:"psr-4,omitempty"`
+	Psr0 map[string]StringList `json:"psr-0,omitempty"`
+	// 需要扫描生成 classmap 的目录或文件
+	Classmap []string `json:"classmap,omitempty"`
+	// 每次请求都会被 include 的文件
+	Files []string `json:"files,omitempty"`
+	// 生成 classmap 时需要排除的路径
+	ExcludeFromClassmap []string `json:"exclude-from-classmap,omitempty"`
+}
+
+// 没有自动加载规则的时候 PHP 序列化出来的是 []
+func (x *Autoload) UnmarshalJSON(data []byte) error {
+	if isEmptyJsonArray(data) {
+		*x = Autoload{}
+		return nil
+	}
+	type autoload Autoload
+	return json.Unmarshal(data, (*autoload)(x))
+}
+
+// IsEmpty 是否没有声明任何自动加载规则
+func (x *Autoload) IsEmpty() bool {
+	return x == nil || (len(x.Psr4) == 0 && len(x.Psr0) == 0 && len(x.Classmap) == 0 && len(x.Files) == 0)
+}
+
+// Paths 返回自动加载规则中声明的所有路径，用来判断某个文件是否在自动加载的范围内
+func (x *Autoload) Paths() []string {
+	if x == nil {
+		return nil
+	}
+	paths := make([]string, 0)
+	for _, dirs := range x.Psr4 {
+		paths = append(paths, dirs...)
+	}
+	for _, dirs := range x.Psr0 {
+		paths = append(paths, dirs...)
+	}
+	paths = append(paths, x.Classmap...)
+	paths = append(paths, x.Files...)
+	return paths
+}
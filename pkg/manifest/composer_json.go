@@ -0,0 +1,102 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// ComposerJSON 表示项目或者包根目录下的 composer.json 文件
+// https://getcomposer.org/doc/04-schema.md
+type ComposerJSON struct {
+	// 包名，格式为 vendor/package
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Keywords    []string          `json:"keywords,omitempty"`
+	Homepage    string            `json:"homepage,omitempty"`
+	Readme      string            `json:"readme,omitempty"`
+	Time        string            `json:"time,omitempty"`
+	License     StringList        `json:"license,omitempty"`
+	Authors     []*Author         `json:"authors,omitempty"`
+	Support     map[string]string `json:"support,omitempty"`
+	Funding     []*Funding        `json:"funding,omitempty"`
+
+	// 依赖关系
+	Require    Links `json:"require,omitempty"`
+	RequireDev Links `json:"require-dev,omitempty"`
+	Conflict   Links `json:"conflict,omitempty"`
+	Replace    Links `json:"replace,omitempty"`
+	Provide    Links `json:"provide,omitempty"`
+	// 建议安装的包，值是一段说明而不是版本约束
+	Suggest Links `json:"suggest,omitempty"`
+
+	Autoload    *Autoload `json:"autoload,omitempty"`
+	AutoloadDev *Autoload `json:"autoload-dev,omitempty"`
+
+	MinimumStability string       `json:"minimum-stability,omitempty"`
+	PreferStable     bool         `json:"prefer-stable,omitempty"`
+	Repositories     Repositories `json:"repositories,omitempty"`
+	Config           *Config      `json:"config,omitempty"`
+
+	// 脚本名到命令列表的映射
+	Scripts             map[string]StringList `json:"scripts,omitempty"`
+	ScriptsDescriptions map[string]string     `json:"scripts-descriptions,omitempty"`
+
+	Extra     Extra      `json:"extra,omitempty"`
+	Bin       StringList `json:"bin,omitempty"`
+	Abandoned *Abandoned `json:"abandoned,omitempty"`
+	Archive   *struct {
+		Name    string   `json:"name,omitempty"`
+		Exclude []string `json:"exclude,omitempty"`
+	} `json:"archive,omitempty"`
+	NonFeatureBranches []string `json:"non-feature-branches,omitempty"`
+
+	// 解析时的原始内容，计算 content-hash 的时候需要用到
+	raw []byte
+}
+
+// ParseComposerJSON 从字节解析 composer.json
+func ParseComposerJSON(data []byte) (*ComposerJSON, error) {
+	r := &ComposerJSON{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	r.raw = append([]byte(nil), data...)
+	return r, nil
+}
+
+// ReadComposerJSON 从文件读取并解析 composer.json
+func ReadComposerJSON(filepath string) (*ComposerJSON, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseComposerJSON(data)
+}
+
+// ContentHash 计算这个 composer.json 对应的 content-hash，算法与 composer 保持一致
+func (x *ComposerJSON) ContentHash() (string, error) {
+	raw := x.raw
+	if raw == nil {
+		// 不是解析出来的，只能用序列化后的内容来算了
+		bytes, err := json.Marshal(x)
+		if err != nil {
+			return "", err
+		}
+		raw = bytes
+	}
+	return ContentHash(raw)
+}
+
+// AllRequires 返回 require 和 require-dev 合并后的依赖
+func (x *ComposerJSON) AllRequires() Links {
+	result := make(Links, len(x.Require)+len(x.RequireDev))
+	for name, constraint := range x.RequireDev {
+		result[name] = constraint
+	}
+	for name, constraint := range x.Require {
+		result[name] = constraint
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testComposerJSON = `{
+	"name": "acme/shop",
+	"type": "project",
+	"license": "MIT",
+	"require": {
+		"php": ">=8.1",
+		"ext-json": "*",
+		"monolog/monolog": "^2.0",
+		"symfony/console": "^6.2"
+	},
+	"require-dev": {
+		"phpunit/phpunit": "^10.0"
+	},
+	"conflict": {
+		"symfony/symfony": "*"
+	},
+	"replace": {
+		"symfony/polyfill-php80": "*"
+	},
+	"provide": {
+		"psr/log-implementation": "1.0"
+	},
+	"suggest": [],
+	"autoload": {
+		"psr-4": {
+			"Acme\\Shop\\": "src/",
+			"Acme\\Shop\\Legacy\\": ["legacy/", "lib/"]
+		},
+		"classmap": ["database/"],
+		"files": ["src/helpers.php"],
+		"exclude-from-classmap": ["tests/"]
+	},
+	"repositories": [
+		{"type": "vcs", "url": "https://github.com/acme/fork"},
+		{"packagist.org": false}
+	],
+	"config": {
+		"sort-packages": true,
+		"preferred-install": "dist",
+		"allow-plugins": {"composer/installers": true},
+		"platform": {"php": "8.1.0", "ext-redis": false}
+	},
+	"scripts": {
+		"test": "phpunit",
+		"post-install-cmd": ["@php artisan optimize", "@test"]
+	},
+	"extra": {
+		"branch-alias": {"dev-main": "1.x-dev"}
+	},
+	"minimum-stability": "stable",
+	"prefer-stable": true
+}`
+
+func TestParseComposerJSON(t *testing.T) {
+	composerJSON, err := ParseComposerJSON([]byte(testComposerJSON))
+	assert.NoError(t, err)
+
+	// Basic fields
+	assert.Equal(t, "acme/shop", composerJSON.Name)
+	assert.Equal(t, StringList{"MIT"}, composerJSON.License)
+
+	// Links
+	assert.Equal(t, "^2.0", composerJSON.Require["monolog/monolog"])
+	assert.Equal(t, "^10.0", composerJSON.RequireDev["phpunit/phpunit"])
+	assert.Equal(t, "*", composerJSON.Conflict["symfony/symfony"])
+	assert.Equal(t, "*", composerJSON.Replace["symfony/polyfill-php80"])
+	assert.Equal(t, "1.0", composerJSON.Provide["psr/log-implementation"])
+	assert.Empty(t, composerJSON.Suggest)
+
+	// Autoload
+	assert.Equal(t, StringList{"src/"}, composerJSON.Autoload.Psr4["Acme\\Shop\\"])
+	assert.Equal(t, StringList{"legacy/", "lib/"}, composerJSON.Autoload.Psr4["Acme\\Shop\\Legacy\\"])
+	assert.Equal(t, []string{"database/"}, composerJSON.Autoload.Classmap)
+	assert.Equal(t, []string{"src/helpers.php"}, composerJSON.Autoload.Files)
+	assert.Equal(t, []string{"tests/"}, composerJSON.Autoload.ExcludeFromClassmap)
+	assert.ElementsMatch(t, []string{"src/", "legacy/", "lib/", "database/", "src/helpers.php"}, composerJSON.Autoload.Paths())
+
+	// Repositories
+	assert.Len(t, composerJSON.Repositories, 2)
+	assert.Equal(t, "vcs", composerJSON.Repositories[0].Type)
+	assert.Equal(t, "https://github.com/acme/fork", composerJSON.Repositories[0].URL)
+	assert.True(t, composerJSON.Repositories[1].Disabled)
+	assert.Equal(t, "packagist.org", composerJSON.Repositories[1].Key)
+
+	// Config
+	assert.True(t, composerJSON.Config.SortPackages)
+	assert.Equal(t, PreferredInstall{"*": "dist"}, composerJSON.Config.PreferredInstall)
+	assert.Equal(t, AllowPlugins{"composer/installers": true}, composerJSON.Config.AllowPlugins)
+	assert.Equal(t, PlatformOverrides{"php": "8.1.0", "ext-redis": ""}, composerJSON.Config.Platform)
+
+	// Scripts
+	assert.Equal(t, StringList{"phpunit"}, composerJSON.Scripts["test"])
+	assert.Equal(t, StringList{"@php artisan optimize", "@test"}, composerJSON.Scripts["post-install-cmd"])
+
+	// Extra
+	branchAlias := map[string]string{}
+	found, err := composerJSON.Extra.Get("branch-alias", &branchAlias)
+	assert.True(t, found)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.x-dev", branchAlias["dev-main"])
+
+	assert.Equal(t, "stable", composerJSON.MinimumStability)
+	assert.True(t, composerJSON.PreferStable)
+	assert.Len(t, composerJSON.AllRequires(), 5)
+}
+
+func TestParseComposerJSON_RepositoriesAsObject(t *testing.T) {
+	composerJSON, err := ParseComposerJSON([]byte(`{
+		"repositories": {
+			"packagist.org": false,
+			"private": {"type": "composer", "url": "https://repo.example.com"}
+		}
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, composerJSON.Repositories, 2)
+	assert.Equal(t, "packagist.org", composerJSON.Repositories[0].Key)
+	assert.True(t, composerJSON.Repositories[0].Disabled)
+	assert.Equal(t, "private", composerJSON.Repositories[1].Key)
+	assert.Equal(t, "composer", composerJSON.Repositories[1].Type)
+}
+
+func TestParseComposerJSON_EmptyAutoload(t *testing.T) {
+	composerJSON, err := ParseComposerJSON([]byte(`{"name": "vendor/package", "autoload": [], "autoload-dev": {"psr-4": {"Tests\\": "tests/"}}}`))
+	assert.NoError(t, err)
+	assert.NotNil(t, composerJSON.Autoload)
+	assert.True(t, composerJSON.Autoload.IsEmpty())
+	assert.Equal(t, StringList{"tests/"}, composerJSON.AutoloadDev.Psr4["Tests\\"])
+}
+
+func TestParseComposerJSON_Invalid(t *testing.T) {
+	_, err := ParseComposerJSON([]byte(`{invalid}`))
+	assert.Error(t, err)
+
+	_, err = ParseComposerJSON([]byte(`{"require": "monolog/monolog"}`))
+	assert.Error(t, err)
+}
+
+func TestReadComposerJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "composer.json")
+	assert.NoError(t, os.WriteFile(path, []byte(testComposerJSON), 0644))
+
+	composerJSON, err := ReadComposerJSON(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme/shop", composerJSON.Name)
+
+	_, err = ReadComposerJSON(filepath.Join(t.TempDir(), "not-exists.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrContentHashMismatch composer.lock 中的 content-hash 与 composer.json 不匹配，说明 lock 文件已经过期了
+var ErrContentHashMismatch = errors.New("content-hash of composer.lock does not match composer.json")
+
+// ComposerLock 表示 composer install 之后生成的 composer.lock 文件
+type ComposerLock struct {
+	Readme      StringList `json:"_readme,omitempty"`
+	ContentHash string     `json:"content-hash"`
+
+	// 生产环境依赖的包
+	Packages []*LockPackage `json:"packages"`
+	// 开发环境依赖的包
+	PackagesDev []*LockPackage `json:"packages-dev"`
+
+	Aliases          []*LockAlias   `json:"aliases"`
+	MinimumStability string         `json:"minimum-stability"`
+	StabilityFlags   StabilityFlags `json:"stability-flags"`
+	PreferStable     bool           `json:"prefer-stable"`
+	PreferLowest     bool           `json:"prefer-lowest"`
+
+	// 项目对平台包（php、扩展等）的要求
+	Platform          Links  `json:"platform"`
+	PlatformDev       Links  `json:"platform-dev"`
+	PlatformOverrides Links  `json:"platform-overrides,omitempty"`
+	PluginAPIVersion  string `json:"plugin-api-version,omitempty"`
+}
+
+// LockPackage 表示 composer.lock 中被锁定的一个包
+type LockPackage struct {
+	Name              string            `json:"name"`
+	Version           string            `json:"version"`
+	VersionNormalized string            `json:"version_normalized,omitempty"`
+	Source            *Source           `json:"source,omitempty"`
+	Dist              *Dist             `json:"dist,omitempty"`
+	Require           Links             `json:"require,omitempty"`
+	RequireDev        Links             `json:"require-dev,omitempty"`
+	Conflict          Links             `json:"conflict,omitempty"`
+	Replace           Links             `json:"replace,omitempty"`
+	Provide           Links             `json:"provide,omitempty"`
+	Suggest           Links             `json:"suggest,omitempty"`
+	Bin               StringList        `json:"bin,omitempty"`
+	Type              string            `json:"type,omitempty"`
+	Extra             Extra             `json:"extra,omitempty"`
+	Autoload          *Autoload         `json:"autoload,omitempty"`
+	AutoloadDev       *Autoload         `json:"autoload-dev,omitempty"`
+	NotificationURL   string            `json:"notification-url,omitempty"`
+	License           StringList        `json:"license,omitempty"`
+	Authors           []*Author         `json:"authors,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Homepage          string            `json:"homepage,omitempty"`
+	Keywords          []string          `json:"keywords,omitempty"`
+	Support           map[string]string `json:"support,omitempty"`
+	Funding           []*Funding        `json:"funding,omitempty"`
+	// 发布时间，格式一般为 2006-01-02T15:04:05+00:00
+	Time               string     `json:"time,omitempty"`
+	Abandoned          *Abandoned `json:"abandoned,omitempty"`
+	InstallationSource string     `json:"installation-source,omitempty"`
+}
+
+// LockAlias 表示通过 `as` 声明的版本别名，比如 "dev-main as 1.0.x-dev"
+type LockAlias struct {
+	Package         string `json:"package"`
+	Version         string `json:"version"`
+	Alias           string `json:"alias"`
+	AliasNormalized string `json:"alias_normalized"`
+}
+
+// StabilityFlags 表示包名到稳定性标记的映射，空的时候 PHP 会序列化成 []
+type StabilityFlags map[string]int
+
+func (x *StabilityFlags) UnmarshalJSON(data []byte) error {
+	if isEmptyJsonArray(data) {
+		*x = StabilityFlags{}
+		return nil
+	}
+	m := make(map[string]int)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*x = m
+	return nil
+}
+
+// ParseComposerLock 从字节解析 composer.lock
+func ParseComposerLock(data []byte) (*ComposerLock, error) {
+	r := &ComposerLock{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReadComposerLock 从文件读取并解析 composer.lock
+func ReadComposerLock(filepath string) (*ComposerLock, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseComposerLock(data)
+}
+
+// AllPackages 返回所有被锁定的包，生产环境依赖在前，开发环境依赖在后
+func (x *ComposerLock) AllPackages() []*LockPackage {
+	result := make([]*LockPackage, 0, len(x.Packages)+len(x.PackagesDev))
+	result = append(result, x.Packages...)
+	result = append(result, x.PackagesDev...)
+	return result
+}
+
+// Find 根据包名查找被锁定的包，包名不区分大小写，找不到时返回nil
+func (x *ComposerLock) Find(packageName string) *LockPackage {
+	for _, lockPackage := range x.AllPackages() {
+		if strings.EqualFold(lockPackage.Name, packageName) {
+			return lockPackage
+		}
+	}
+	return nil
+}
+
+// IsDev 判断给定的包是否只是开发环境依赖
+func (x *ComposerLock) IsDev(packageName string) bool {
+	for _, lockPackage := range x.PackagesDev {
+		if strings.EqualFold(lockPackage.Name, packageName) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyContentHash 检查 content-hash 是否与给定的 composer.json 匹配，不匹配时返回 ErrContentHashMismatch
+func (x *ComposerLock) VerifyContentHash(composerJSON *ComposerJSON) error {
+	hash, err := composerJSON.ContentHash()
+	if err != nil {
+		return err
+	}
+	if hash != x.ContentHash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrContentHashMismatch, hash, x.ContentHash)
+	}
+	return nil
+}
+
+// ReleaseTime 解析包的发布时间
+func (x *LockPackage) ReleaseTime() (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, x.Time); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse time %q of package %s", x.Time, x.Name)
+}
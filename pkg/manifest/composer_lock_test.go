@@ -0,0 +1,155 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testComposerLock = `{
+	"_readme": [
+		"This file locks the dependencies of your project to a known state"
+	],
+	"content-hash": "d751713988987e9331980363e24189ce",
+	"packages": [
+		{
+			"name": "monolog/monolog",
+			"version": "2.9.1",
+			"source": {
+				"type": "git",
+				"url": "https://github.com/Seldaek/monolog.git",
+				"reference": "f259e2b15fb95494c83f52d3caad003bbf5ffaa1"
+			},
+			"dist": {
+				"type": "zip",
+				"url": "https://api.github.com/repos/Seldaek/monolog/zipball/f259e2b15fb95494c83f52d3caad003bbf5ffaa1",
+				"reference": "f259e2b15fb95494c83f52d3caad003bbf5ffaa1",
+				"shasum": ""
+			},
+			"require": {
+				"php": ">=7.2",
+				"psr/log": "^1.0.1 || ^2.0 || ^3.0"
+			},
+			"provide": {
+				"psr/log-implementation": "1.0.0 || 2.0.0 || 3.0.0"
+			},
+			"type": "library",
+			"autoload": {
+				"psr-4": {"Monolog\\": "src/Monolog"}
+			},
+			"license": ["MIT"],
+			"authors": [{"name": "Jordi Boggiano", "email": "j.boggiano@seld.be"}],
+			"time": "2023-02-06T13:44:46+00:00"
+		},
+		{
+			"name": "swiftmailer/swiftmailer",
+			"version": "v6.3.0",
+			"dist": {"type": "zip", "url": "https://example.com/swiftmailer.zip"},
+			"abandoned": "symfony/mailer",
+			"time": "2021-10-18 15:26:12"
+		}
+	],
+	"packages-dev": [
+		{
+			"name": "phpunit/phpunit",
+			"version": "10.1.2",
+			"dist": {"type": "zip", "url": "https://example.com/phpunit.zip"},
+			"abandoned": false
+		}
+	],
+	"aliases": [],
+	"minimum-stability": "stable",
+	"stability-flags": [],
+	"prefer-stable": true,
+	"prefer-lowest": false,
+	"platform": {"php": ">=8.1"},
+	"platform-dev": [],
+	"plugin-api-version": "2.3.0"
+}`
+
+func TestParseComposerLock(t *testing.T) {
+	lock, err := ParseComposerLock([]byte(testComposerLock))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "d751713988987e9331980363e24189ce", lock.ContentHash)
+	assert.Len(t, lock.Packages, 2)
+	assert.Len(t, lock.PackagesDev, 1)
+	assert.Len(t, lock.AllPackages(), 3)
+	assert.Empty(t, lock.StabilityFlags)
+	assert.Equal(t, Links{"php": ">=8.1"}, lock.Platform)
+	assert.Empty(t, lock.PlatformDev)
+	assert.Equal(t, "2.3.0", lock.PluginAPIVersion)
+
+	monolog := lock.Find("Monolog/Monolog")
+	if assert.NotNil(t, monolog) {
+		assert.Equal(t, "2.9.1", monolog.Version)
+		assert.Equal(t, "git", monolog.Source.Type)
+		assert.Equal(t, "f259e2b15fb95494c83f52d3caad003bbf5ffaa1", monolog.Dist.Reference)
+		assert.Equal(t, "^1.0.1 || ^2.0 || ^3.0", monolog.Require["psr/log"])
+		assert.Equal(t, StringList{"src/Monolog"}, monolog.Autoload.Psr4["Monolog\\"])
+		assert.Nil(t, monolog.Abandoned)
+
+		releaseTime, err := monolog.ReleaseTime()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 2, 6, 13, 44, 46, 0, time.UTC), releaseTime.UTC())
+	}
+
+	swiftmailer := lock.Find("swiftmailer/swiftmailer")
+	if assert.NotNil(t, swiftmailer) {
+		assert.Equal(t, &Abandoned{IsAbandoned: true, Replacement: "symfony/mailer"}, swiftmailer.Abandoned)
+		releaseTime, err := swiftmailer.ReleaseTime()
+		assert.NoError(t, err)
+		assert.Equal(t, 2021, releaseTime.Year())
+	}
+
+	assert.Equal(t, &Abandoned{}, lock.Find("phpunit/phpunit").Abandoned)
+	assert.True(t, lock.IsDev("phpunit/phpunit"))
+	assert.False(t, lock.IsDev("monolog/monolog"))
+	assert.Nil(t, lock.Find("not/exists"))
+}
+
+func TestComposerLock_VerifyContentHash(t *testing.T) {
+	lock, err := ParseComposerLock([]byte(testComposerLock))
+	assert.NoError(t, err)
+
+	// The hash of a composer.json without relevant keys
+	composerJSON, err := ParseComposerJSON([]byte(`{"description": "not relevant", "config": {"sort-packages": true}}`))
+	assert.NoError(t, err)
+	assert.NoError(t, lock.VerifyContentHash(composerJSON))
+
+	composerJSON, err = ParseComposerJSON([]byte(`{"require": {"monolog/monolog": "^2.0"}}`))
+	assert.NoError(t, err)
+	err = lock.VerifyContentHash(composerJSON)
+	assert.True(t, errors.Is(err, ErrContentHashMismatch))
+}
+
+func TestAbandoned_JSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want Abandoned
+	}{
+		{`true`, Abandoned{IsAbandoned: true}},
+		{`false`, Abandoned{}},
+		{`null`, Abandoned{}},
+		{`"symfony/mailer"`, Abandoned{IsAbandoned: true, Replacement: "symfony/mailer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var got Abandoned
+			assert.NoError(t, json.Unmarshal([]byte(tt.json), &got))
+			assert.Equal(t, tt.want, got)
+
+			if tt.json != `null` {
+				bytes, err := json.Marshal(got)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.json, string(bytes))
+			}
+		})
+	}
+
+	var got Abandoned
+	assert.Error(t, json.Unmarshal([]byte(`123`), &got))
+}
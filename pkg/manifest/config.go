@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Config 表示 composer.json 中的 config 配置
+// https://getcomposer.org/doc/06-config.md
+type Config struct {
+	ProcessTimeout   int                   `json:"process-timeout,omitempty"`
+	UseIncludePath   bool                  `json:"use-include-path,omitempty"`
+	PreferredInstall PreferredInstall      `json:"preferred-install,omitempty"`
+	AllowPlugins     AllowPlugins          `json:"allow-plugins,omitempty"`
+	GithubProtocols  []string              `json:"github-protocols,omitempty"`
+	GithubOauth      map[string]string     `json:"github-oauth,omitempty"`
+	GithubDomains    []string              `json:"github-domains,omitempty"`
+	GitlabDomains    []string              `json:"gitlab-domains,omitempty"`
+	GitlabOauth      map[string]string     `json:"gitlab-oauth,omitempty"`
+	Bearer           map[string]string     `json:"bearer,omitempty"`
+	HttpBasic        map[string]*HttpBasic `json:"http-basic,omitempty"`
+	SecureHttp       *bool                 `json:"secure-http,omitempty"`
+	Lock             *bool                 `json:"lock,omitempty"`
+	// 平台包版本覆盖，值为空字符串表示这个平台包被禁用（composer.json 中写的是 false）
+	Platform              PlatformOverrides `json:"platform,omitempty"`
+	VendorDir             string            `json:"vendor-dir,omitempty"`
+	BinDir                string            `json:"bin-dir,omitempty"`
+	DataDir               string            `json:"data-dir,omitempty"`
+	CacheDir              string            `json:"cache-dir,omitempty"`
+	OptimizeAutoloader    bool              `json:"optimize-autoloader,omitempty"`
+	SortPackages          bool              `json:"sort-packages,omitempty"`
+	ClassmapAuthoritative bool              `json:"classmap-authoritative,omitempty"`
+	ApcuAutoloader        bool              `json:"apcu-autoloader,omitempty"`
+	NotifyOnInstall       *bool             `json:"notify-on-install,omitempty"`
+}
+
+// HttpBasic 表示某个域名的 http basic 认证信息
+type HttpBasic struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// PreferredInstall 表示包名模式到安装方式（source、dist、auto）的映射
+// composer.json 中直接写成字符串的时候等价于 {"*": "..."}
+type PreferredInstall map[string]string
+
+func (x *PreferredInstall) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var mode string
+		if err := json.Unmarshal(data, &mode); err != nil {
+			return err
+		}
+		*x = PreferredInstall{"*": mode}
+		return nil
+	}
+	m := make(map[string]string)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*x = m
+	return nil
+}
+
+// AllowPlugins 表示插件包名模式到是否允许运行的映射
+// composer.json 中直接写成 true 或 false 的时候等价于 {"*": true} 或 {"*": false}
+type AllowPlugins map[string]bool
+
+func (x *AllowPlugins) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")) {
+		*x = AllowPlugins{"*": bytes.Equal(data, []byte("true"))}
+		return nil
+	}
+	m := make(map[string]bool)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*x = m
+	return nil
+}
+
+// PlatformOverrides 表示平台包的版本覆盖，被禁用的平台包的值为空字符串
+type PlatformOverrides map[string]string
+
+func (x *PlatformOverrides) UnmarshalJSON(data []byte) error {
+	if isEmptyJsonArray(data) {
+		*x = PlatformOverrides{}
+		return nil
+	}
+	raws := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	result := make(PlatformOverrides, len(raws))
+	for name, raw := range raws {
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("false")) {
+			result[name] = ""
+			continue
+		}
+		var version string
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return err
+		}
+		result[name] = version
+	}
+	*x = result
+	return nil
+}
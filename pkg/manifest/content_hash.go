@@ -0,0 +1,225 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// 参与计算 content-hash 的字段，与 composer 的 Locker::getContentHash 保持一致
+var contentHashKeys = map[string]bool{
+	"name":              true,
+	"version":           true,
+	"require":           true,
+	"require-dev":       true,
+	"conflict":          true,
+	"replace":           true,
+	"provide":           true,
+	"minimum-stability": true,
+	"prefer-stable":     true,
+	"repositories":      true,
+	"extra":             true,
+}
+
+// ContentHash 根据 composer.json 的原始内容计算 content-hash
+//
+// composer 的算法是挑出相关的字段，按键名排序后用 PHP 的 json_encode 序列化再取 md5，
+// 所以这里需要保留嵌套对象的键顺序，并且模拟 PHP 的转义规则（比如 / 会被转义为 \/）
+func ContentHash(composerJSON []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(composerJSON))
+	decoder.UseNumber()
+	root, err := decodeOrdered(decoder)
+	if err != nil {
+		return "", err
+	}
+	object, ok := root.(*orderedObject)
+	if !ok {
+		return "", fmt.Errorf("composer.json must be a json object")
+	}
+
+	relevant := &orderedObject{}
+	for _, member := range object.members {
+		if contentHashKeys[member.key] {
+			relevant.members = append(relevant.members, member)
+		}
+	}
+	if config, ok := object.get("config").(*orderedObject); ok {
+		if platform := config.get("platform"); platform != nil {
+			relevant.members = append(relevant.members, orderedMember{
+				key:   "config",
+				value: &orderedObject{members: []orderedMember{{key: "platform", value: platform}}},
+			})
+		}
+	}
+	sort.SliceStable(relevant.members, func(i, j int) bool {
+		return relevant.members[i].key < relevant.members[j].key
+	})
+
+	buff := &bytes.Buffer{}
+	encodePhpJson(buff, relevant)
+	sum := md5.Sum(buff.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// orderedObject 保留键顺序的 JSON 对象
+type orderedObject struct {
+	members []orderedMember
+}
+
+type orderedMember struct {
+	key   string
+	value interface{}
+}
+
+func (x *orderedObject) get(key string) interface{} {
+	for _, member := range x.members {
+		if member.key == key {
+			return member.value
+		}
+	}
+	return nil
+}
+
+// decodeOrdered 解析一个 JSON 值，对象会被解析为 *orderedObject，数组为 []interface{}
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			object := &orderedObject{}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object.members = append(object.members, orderedMember{key: keyToken.(string), value: value})
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return object, nil
+		case '[':
+			array := make([]interface{}, 0)
+			for decoder.More() {
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return array, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// encodePhpJson 按照 PHP 中 json_encode($value, 0) 的规则序列化
+func encodePhpJson(buff *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buff.WriteString("null")
+	case bool:
+		buff.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		buff.WriteString(v.String())
+	case string:
+		encodePhpString(buff, v)
+	case []interface{}:
+		buff.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			encodePhpJson(buff, item)
+		}
+		buff.WriteByte(']')
+	case *orderedObject:
+		// PHP 中空对象和键为 0..n-1 的对象解码成数组后会被序列化为列表
+		if isPhpList(v) {
+			values := make([]interface{}, 0, len(v.members))
+			for _, member := range v.members {
+				values = append(values, member.value)
+			}
+			encodePhpJson(buff, values)
+			return
+		}
+		buff.WriteByte('{')
+		for i, member := range v.members {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			encodePhpString(buff, member.key)
+			buff.WriteByte(':')
+			encodePhpJson(buff, member.value)
+		}
+		buff.WriteByte('}')
+	}
+}
+
+func isPhpList(object *orderedObject) bool {
+	for i, member := range object.members {
+		if member.key != strconv.Itoa(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func encodePhpString(buff *bytes.Buffer, s string) {
+	buff.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == '"':
+			buff.WriteString(`\"`)
+		case r == '\\':
+			buff.WriteString(`\\`)
+		case r == '/':
+			buff.WriteString(`\/`)
+		case r == '\b':
+			buff.WriteString(`\b`)
+		case r == '\f':
+			buff.WriteString(`\f`)
+		case r == '\n':
+			buff.WriteString(`\n`)
+		case r == '\r':
+			buff.WriteString(`\r`)
+		case r == '\t':
+			buff.WriteString(`\t`)
+		case r < 0x20 || r >= 0x80:
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(buff, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(buff, `\u%04x`, r)
+			}
+		default:
+			buff.WriteRune(r)
+		}
+	}
+	buff.WriteByte('"')
+}
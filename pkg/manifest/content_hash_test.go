@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		name         string
+		composerJSON string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "no relevant keys",
+			composerJSON: `{"description": "irrelevant", "autoload": {"psr-4": {"App\\": "src/"}}}`,
+			// md5("[]")
+			want: "d751713988987e9331980363e24189ce",
+		},
+		{
+			name:         "slashes are escaped",
+			composerJSON: `{"require": {"monolog/monolog": "^2.0"}}`,
+			// md5(`{"require":{"monolog\/monolog":"^2.0"}}`)
+			want: "4935b31e96983947a7f426533533f2b3",
+		},
+		{
+			name: "top level keys are sorted and whitespace is ignored",
+			composerJSON: `{
+				"require": {
+					"monolog/monolog": "^2.0"
+				},
+				"authors": [{"name": "Someone"}]
+			}`,
+			want: "4935b31e96983947a7f426533533f2b3",
+		},
+		{
+			name:         "not an object",
+			composerJSON: `[]`,
+			wantErr:      true,
+		},
+		{
+			name:         "invalid json",
+			composerJSON: `{"require": `,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContentHash([]byte(tt.composerJSON))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContentHash_NestedOrderIsKept(t *testing.T) {
+	a, err := ContentHash([]byte(`{"require": {"a/a": "1.0", "b/b": "1.0"}}`))
+	assert.NoError(t, err)
+	b, err := ContentHash([]byte(`{"require": {"b/b": "1.0", "a/a": "1.0"}}`))
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestContentHash_ConfigPlatform(t *testing.T) {
+	withoutPlatform, err := ContentHash([]byte(`{"config": {"sort-packages": true}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "d751713988987e9331980363e24189ce", withoutPlatform)
+
+	withPlatform, err := ContentHash([]byte(`{"config": {"sort-packages": true, "platform": {"php": "8.1"}}}`))
+	assert.NoError(t, err)
+	assert.NotEqual(t, withoutPlatform, withPlatform)
+}
+
+func TestEncodePhpJson(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty object becomes list", `{"require": {}}`, `{"require":[]}`},
+		{"unicode is escaped", `{"name": "café ☕"}`, `{"name":"caf\u00e9 \u2615"}`},
+		{"astral plane uses surrogates", `{"name": "😀"}`, `{"name":"\ud83d\ude00"}`},
+		{"control characters", `{"name": "a\nb\u0001"}`, `{"name":"a\nb\u0001"}`},
+		{"numbers keep their literal", `{"extra": {"n": 1.50, "m": 10}}`, `{"extra":{"n":1.50,"m":10}}`},
+		{"sequential keys become list", `{"extra": {"0": "a", "1": "b"}}`, `{"extra":["a","b"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(tt.input))
+			decoder.UseNumber()
+			value, err := decodeOrdered(decoder)
+			assert.NoError(t, err)
+			buff := &bytes.Buffer{}
+			encodePhpJson(buff, value)
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
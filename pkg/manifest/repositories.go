@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Repository 表示 composer.json 中声明的一个仓库
+// https://getcomposer.org/doc/05-repositories.md
+type Repository struct {
+	// 对象形式声明时的键名，数组形式声明时为空
+	Key string `json:"-"`
+	// 仓库类型：composer、vcs、git、path、package、artifact 等
+	Type string `json:"type,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// 仓库是否是权威来源，为 false 时允许从后面的仓库中查找同名包
+	Canonical *bool `json:"canonical,omitempty"`
+	// 只从这个仓库加载的包
+	Only []string `json:"only,omitempty"`
+	// 不从这个仓库加载的包
+	Exclude []string `json:"exclude,omitempty"`
+	// 仓库的额外选项，不同类型的仓库不一样
+	Options map[string]interface{} `json:"options,omitempty"`
+	// package 类型的仓库内联的包定义
+	Package json.RawMessage `json:"package,omitempty"`
+	// 形如 {"packagist.org": false} 的声明，表示禁用这个仓库
+	Disabled bool `json:"-"`
+}
+
+// Repositories 表示仓库列表，composer.json 中既可以写成数组也可以写成对象
+type Repositories []*Repository
+
+func (x *Repositories) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return err
+		}
+		result := make(Repositories, 0, len(raws))
+		for _, raw := range raws {
+			repository, err := unmarshalRepository("", raw)
+			if err != nil {
+				return err
+			}
+			result = append(result, repository)
+		}
+		*x = result
+		return nil
+	}
+
+	var raws map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(raws))
+	for key := range raws {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make(Repositories, 0, len(raws))
+	for _, key := range keys {
+		repository, err := unmarshalRepository(key, raws[key])
+		if err != nil {
+			return err
+		}
+		result = append(result, repository)
+	}
+	*x = result
+	return nil
+}
+
+func (x Repositories) MarshalJSON() ([]byte, error) {
+	raws := make([]interface{}, 0, len(x))
+	for _, repository := range x {
+		if repository.Disabled {
+			raws = append(raws, map[string]bool{repository.Key: false})
+		} else {
+			raws = append(raws, repository)
+		}
+	}
+	return json.Marshal(raws)
+}
+
+func unmarshalRepository(key string, raw json.RawMessage) (*Repository, error) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("false")) {
+		return &Repository{Key: key, Disabled: true}, nil
+	}
+
+	// 数组形式里面也可以写 {"packagist.org": false}
+	var disabled map[string]bool
+	if err := json.Unmarshal(raw, &disabled); err == nil && len(disabled) == 1 {
+		for name, enabled := range disabled {
+			if !enabled {
+				return &Repository{Key: name, Disabled: true}, nil
+			}
+		}
+	}
+
+	repository := &Repository{Key: key}
+	if err := json.Unmarshal(raw, repository); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Links 表示包名到版本约束的映射，require、require-dev、conflict、replace、provide、suggest 都是这种结构
+// PHP 在序列化空数组的时候会输出 []，所以这里需要兼容一下
+type Links map[string]string
+
+func (x *Links) UnmarshalJSON(data []byte) error {
+	if isEmptyJsonArray(data) {
+		*x = Links{}
+		return nil
+	}
+	m := make(map[string]string)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*x = m
+	return nil
+}
+
+// StringList 表示既可以是单个字符串也可以是字符串数组的字段，比如 license、autoload 中的路径
+type StringList []string
+
+func (x *StringList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*x = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*x = list
+	return nil
+}
+
+// Author 表示包的作者
+type Author struct {
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Homepage string `json:"homepage,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+// Source 表示包的源码仓库地址
+type Source struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Reference string `json:"reference"`
+}
+
+// Dist 表示包的分发压缩包地址
+type Dist struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Reference string `json:"reference,omitempty"`
+	Shasum    string `json:"shasum,omitempty"`
+}
+
+// Funding 表示包的赞助渠道
+type Funding struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+func isEmptyJsonArray(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '[' || data[len(data)-1] != ']' {
+		return false
+	}
+	return len(bytes.TrimSpace(data[1:len(data)-1])) == 0
+}
+
+// Extra 表示 extra 字段，里面的内容由各个插件自行约定，所以只保留原始的 JSON，使用时再按需解析
+type Extra map[string]json.RawMessage
+
+func (x *Extra) UnmarshalJSON(data []byte) error {
+	if isEmptyJsonArray(data) {
+		*x = Extra{}
+		return nil
+	}
+	m := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*x = m
+	return nil
+}
+
+// Get 把 extra 中的某个键解析到 v 中，键不存在的时候返回 false
+func (x Extra) Get(key string, v interface{}) (bool, error) {
+	raw, ok := x[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, err
+	}
+	return true, nil
+}
@@ -0,0 +1,213 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError 表示一个校验错误，Path 是出错字段的 JSON Path，比如 $.require["vendor/package"]
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (x *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", x.Path, x.Message)
+}
+
+// ValidationErrors 表示一次校验中发现的所有错误
+type ValidationErrors []*ValidationError
+
+func (x ValidationErrors) Error() string {
+	messages := make([]string, 0, len(x))
+	for _, err := range x {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+var (
+	// 与 composer 中 ValidatingArrayLoader 使用的包名规则一致
+	packageNameRegex = regexp.MustCompile(`^[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]|-{1,2})?[a-z0-9]+)*$`)
+	// 平台包：php、扩展、系统库以及 composer 自身
+	platformPackageRegex = regexp.MustCompile(`^(?i)(php(-64bit|-ipv6|-zts|-debug)?|hhvm|(ext|lib)-[a-z0-9](?:[_.-]?[a-z0-9]+)*|composer(-(plugin|runtime)-api)?)$`)
+	contentHashRegex     = regexp.MustCompile(`^[a-f0-9]{32}$`)
+
+	stabilities = map[string]bool{"dev": true, "alpha": true, "beta": true, "rc": true, "stable": true}
+
+	// 需要 url 的仓库类型
+	repositoryTypesRequireUrl = map[string]bool{
+		"composer": true, "vcs": true, "git": true, "svn": true, "hg": true, "fossil": true, "path": true,
+		"artifact": true, "github": true, "gitlab": true, "bitbucket": true, "git-bitbucket": true,
+	}
+)
+
+// IsValidPackageName 判断包名是否是合法的 vendor/package 格式
+func IsValidPackageName(name string) bool {
+	return packageNameRegex.MatchString(name)
+}
+
+// IsPlatformPackage 判断是否是平台包，比如 php、ext-json、lib-curl
+func IsPlatformPackage(name string) bool {
+	return platformPackageRegex.MatchString(name)
+}
+
+// Validate 校验 composer.json 的内容，有错误时返回 ValidationErrors
+func (x *ComposerJSON) Validate() error {
+	errs := ValidationErrors{}
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if x.Name != "" && !IsValidPackageName(x.Name) {
+		add("$.name", "%q is not a valid package name, it should match vendor/package in lowercase", x.Name)
+	}
+	if x.MinimumStability != "" && !stabilities[strings.ToLower(x.MinimumStability)] {
+		add(jsonPath("minimum-stability"), "%q is not one of dev, alpha, beta, RC, stable", x.MinimumStability)
+	}
+	for i, license := range x.License {
+		if strings.TrimSpace(license) == "" {
+			add(jsonPath("license", i), "license must not be empty")
+		}
+	}
+
+	linkFields := []struct {
+		name  string
+		links Links
+	}{
+		{"require", x.Require},
+		{"require-dev", x.RequireDev},
+		{"conflict", x.Conflict},
+		{"replace", x.Replace},
+		{"provide", x.Provide},
+	}
+	for _, field := range linkFields {
+		for _, name := range sortedKeys(field.links) {
+			if !IsValidPackageName(strings.ToLower(name)) && !IsPlatformPackage(name) {
+				add(jsonPath(field.name, name), "%q is not a valid package name", name)
+			}
+			if strings.TrimSpace(field.links[name]) == "" {
+				add(jsonPath(field.name, name), "version constraint must not be empty")
+			}
+		}
+	}
+
+	validateAutoload(x.Autoload, "autoload", add)
+	validateAutoload(x.AutoloadDev, "autoload-dev", add)
+
+	for i, repository := range x.Repositories {
+		if repository.Disabled {
+			continue
+		}
+		if repository.Type == "" {
+			add(jsonPath("repositories", i, "type"), "repository type is required")
+			continue
+		}
+		if repositoryTypesRequireUrl[repository.Type] && repository.URL == "" {
+			add(jsonPath("repositories", i, "url"), "repository of type %q requires an url", repository.Type)
+		}
+		if repository.Type == "package" && len(repository.Package) == 0 {
+			add(jsonPath("repositories", i, "package"), "repository of type package requires a package definition")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateAutoload(autoload *Autoload, field string, add func(path, format string, args ...interface{})) {
+	if autoload == nil {
+		return
+	}
+	for _, namespace := range sortedKeys(autoload.Psr4) {
+		if namespace != "" && !strings.HasSuffix(namespace, "\\") {
+			add(jsonPath(field, "psr-4", namespace), "namespace prefix %q must end with a namespace separator", namespace)
+		}
+	}
+}
+
+// Validate 校验 composer.lock 的内容，有错误时返回 ValidationErrors
+func (x *ComposerLock) Validate() error {
+	errs := ValidationErrors{}
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !contentHashRegex.MatchString(x.ContentHash) {
+		add(jsonPath("content-hash"), "%q is not a valid md5 hash", x.ContentHash)
+	}
+
+	seen := make(map[string]string)
+	for _, field := range []struct {
+		name     string
+		packages []*LockPackage
+	}{
+		{"packages", x.Packages},
+		{"packages-dev", x.PackagesDev},
+	} {
+		for i, lockPackage := range field.packages {
+			if lockPackage == nil {
+				add(jsonPath(field.name, i), "package must not be null")
+				continue
+			}
+			if !IsValidPackageName(strings.ToLower(lockPackage.Name)) {
+				add(jsonPath(field.name, i, "name"), "%q is not a valid package name", lockPackage.Name)
+			}
+			if lockPackage.Version == "" {
+				add(jsonPath(field.name, i, "version"), "version is required")
+			}
+			if lockPackage.Source == nil && lockPackage.Dist == nil && lockPackage.Type != "metapackage" {
+				add(jsonPath(field.name, i), "package %s has neither source nor dist", lockPackage.Name)
+			}
+			if lockPackage.Dist != nil && (lockPackage.Dist.Type == "" || lockPackage.Dist.URL == "") {
+				add(jsonPath(field.name, i, "dist"), "dist requires both type and url")
+			}
+			name := strings.ToLower(lockPackage.Name)
+			if previous, ok := seen[name]; ok {
+				add(jsonPath(field.name, i, "name"), "package %s is already locked at %s", lockPackage.Name, previous)
+			} else {
+				seen[name] = jsonPath(field.name, i)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// jsonPath 拼接 JSON Path，能作为标识符的键使用 .key 形式，其它的使用 ["key"] 形式
+func jsonPath(elements ...interface{}) string {
+	builder := strings.Builder{}
+	builder.WriteString("$")
+	for _, element := range elements {
+		switch e := element.(type) {
+		case int:
+			fmt.Fprintf(&builder, "[%d]", e)
+		case string:
+			if identifierRegex.MatchString(e) {
+				builder.WriteString(".")
+				builder.WriteString(e)
+			} else {
+				fmt.Fprintf(&builder, "[%q]", e)
+			}
+		}
+	}
+	return builder.String()
+}
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
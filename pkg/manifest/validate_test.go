@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposerJSON_Validate(t *testing.T) {
+	t.Run("valid composer.json", func(t *testing.T) {
+		composerJSON, err := ParseComposerJSON([]byte(testComposerJSON))
+		assert.NoError(t, err)
+		assert.NoError(t, composerJSON.Validate())
+	})
+
+	t.Run("invalid composer.json", func(t *testing.T) {
+		composerJSON, err := ParseComposerJSON([]byte(`{
+			"name": "Acme/Shop",
+			"minimum-stability": "nightly",
+			"require": {
+				"not a package": "^1.0",
+				"monolog/monolog": ""
+			},
+			"autoload": {
+				"psr-4": {"Acme\\Shop": "src/"}
+			},
+			"repositories": [
+				{"url": "https://example.com"},
+				{"type": "vcs"},
+				{"type": "package"}
+			]
+		}`))
+		assert.NoError(t, err)
+
+		err = composerJSON.Validate()
+		var validationErrors ValidationErrors
+		if assert.True(t, errors.As(err, &validationErrors)) {
+			paths := make([]string, 0)
+			for _, validationError := range validationErrors {
+				paths = append(paths, validationError.Path)
+			}
+			assert.Equal(t, []string{
+				`$.name`,
+				`$["minimum-stability"]`,
+				`$.require["monolog/monolog"]`,
+				`$.require["not a package"]`,
+				`$.autoload["psr-4"]["Acme\\Shop"]`,
+				`$.repositories[0].type`,
+				`$.repositories[1].url`,
+				`$.repositories[2].package`,
+			}, paths)
+		}
+		assert.Contains(t, err.Error(), `$.name: "Acme/Shop" is not a valid package name`)
+	})
+}
+
+func TestComposerLock_Validate(t *testing.T) {
+	t.Run("valid composer.lock", func(t *testing.T) {
+		lock, err := ParseComposerLock([]byte(testComposerLock))
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Validate())
+	})
+
+	t.Run("invalid composer.lock", func(t *testing.T) {
+		lock, err := ParseComposerLock([]byte(`{
+			"content-hash": "not-a-hash",
+			"packages": [
+				{"name": "vendor/a", "version": "1.0.0", "dist": {"type": "zip"}},
+				{"name": "vendor/b"}
+			],
+			"packages-dev": [
+				{"name": "vendor/a", "version": "1.0.0", "type": "metapackage"}
+			]
+		}`))
+		assert.NoError(t, err)
+
+		err = lock.Validate()
+		var validationErrors ValidationErrors
+		if assert.True(t, errors.As(err, &validationErrors)) {
+			paths := make([]string, 0)
+			for _, validationError := range validationErrors {
+				paths = append(paths, validationError.Path)
+			}
+			assert.Equal(t, []string{
+				`$["content-hash"]`,
+				`$.packages[0].dist`,
+				`$.packages[1].version`,
+				`$.packages[1]`,
+				`$["packages-dev"][0].name`,
+			}, paths)
+		}
+	})
+}
+
+func TestIsPlatformPackage(t *testing.T) {
+	for _, name := range []string{"php", "php-64bit", "ext-json", "ext-pdo_mysql", "lib-curl", "composer-plugin-api", "composer-runtime-api", "hhvm"} {
+		assert.True(t, IsPlatformPackage(name), name)
+	}
+	for _, name := range []string{"monolog/monolog", "phpunit", "ext-"} {
+		assert.False(t, IsPlatformPackage(name), name)
+	}
+}
@@ -0,0 +1,172 @@
+package osv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/scagogogo/composer-crawler/pkg/sbom"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// 可以作为 alias 的漏洞编号，FriendsOfPHP 中的 remoteId 是文件路径，不能作为 alias
+var aliasRegex = regexp.MustCompile(`^(CVE-\d{4}-\d+|GHSA(-[23456789cfghjmpqrvwx]{4}){3}|PKSA(-[a-z0-9]{4}){3})$`)
+
+// FromAdvisory 把 Packagist 的安全公告转换为 OSV 记录
+func FromAdvisory(advisory *response.Advisory) (*Vulnerability, error) {
+	ranges, err := RangesFromConstraint(advisory.AffectedVersions)
+	if err != nil {
+		return nil, fmt.Errorf("convert affected versions of %s: %w", advisory.AdvisoryID, err)
+	}
+
+	vulnerability := &Vulnerability{
+		SchemaVersion: SchemaVersion,
+		ID:            advisory.AdvisoryID,
+		Summary:       advisory.Title,
+		Affected: []*Affected{{
+			Package: &Package{
+				Ecosystem: Ecosystem,
+				Name:      advisory.PackageName,
+				Purl:      sbom.PURL(advisory.PackageName, ""),
+			},
+			Ranges: ranges,
+			DatabaseSpecific: map[string]interface{}{
+				"affectedVersions": advisory.AffectedVersions,
+			},
+		}},
+		DatabaseSpecific: map[string]interface{}{},
+	}
+
+	if reportedAt := advisory.ReportedAtTime(); !reportedAt.IsZero() {
+		vulnerability.Published = reportedAt.UTC().Format(time.RFC3339)
+		vulnerability.Modified = vulnerability.Published
+	} else {
+		vulnerability.Modified = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	aliases := make(map[string]bool)
+	if advisory.Cve != "" {
+		aliases[advisory.Cve] = true
+	}
+	sources := make([]map[string]string, 0, len(advisory.Sources))
+	for _, source := range advisory.Sources {
+		sources = append(sources, map[string]string{"name": source.Name, "remoteId": source.RemoteID})
+		if aliasRegex.MatchString(source.RemoteID) {
+			aliases[source.RemoteID] = true
+		}
+	}
+	delete(aliases, vulnerability.ID)
+	for alias := range aliases {
+		vulnerability.Aliases = append(vulnerability.Aliases, alias)
+	}
+	sort.Strings(vulnerability.Aliases)
+
+	if advisory.Link != "" {
+		vulnerability.References = append(vulnerability.References, &Reference{Type: "ADVISORY", URL: advisory.Link})
+	}
+	if advisory.Cve != "" {
+		vulnerability.References = append(vulnerability.References, &Reference{Type: "ADVISORY", URL: "https://nvd.nist.gov/vuln/detail/" + advisory.Cve})
+	}
+
+	vulnerability.DatabaseSpecific["remoteId"] = advisory.RemoteID
+	vulnerability.DatabaseSpecific["source"] = advisory.Source
+	vulnerability.DatabaseSpecific["sources"] = sources
+	if advisory.ComposerRepository != "" {
+		vulnerability.DatabaseSpecific["composerRepository"] = advisory.ComposerRepository
+	}
+	if advisory.Severity != "" {
+		vulnerability.DatabaseSpecific["severity"] = string(advisory.Severity)
+	}
+	return vulnerability, nil
+}
+
+// FromAdvisories 把查询到的所有安全公告转换为 OSV 记录，按 ID 排序
+func FromAdvisories(advisories *response.AdvisoriesResponse) ([]*Vulnerability, error) {
+	result := make([]*Vulnerability, 0)
+	for _, packageAdvisories := range advisories.Advisories {
+		for _, advisory := range packageAdvisories {
+			vulnerability, err := FromAdvisory(advisory)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, vulnerability)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// Export 把安全公告以 OSV JSON 数组的形式写出
+func Export(w io.Writer, advisories *response.AdvisoriesResponse) error {
+	vulnerabilities, err := FromAdvisories(advisories)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(vulnerabilities)
+}
+
+// ErrUnsupportedConstraint 版本约束无法用 OSV 的范围准确地表示：OSV 的 introduced 总是包含这个版本，
+// 所以 > 没法表示；!= 会把一个范围切成两段，直接忽略又会把范围变大
+var ErrUnsupportedConstraint = errors.New("constraint cannot be expressed as an OSV range")
+
+// RangesFromConstraint 把 composer 的版本约束转换为 OSV 的 ECOSYSTEM 版本范围，每个“或”的分支对应一个范围，
+// 包含 > 或者 != 的约束返回 ErrUnsupportedConstraint
+func RangesFromConstraint(affectedVersions string) ([]*Range, error) {
+	constraint, err := semver.ParseConstraint(affectedVersions)
+	if err != nil {
+		return nil, err
+	}
+	if constraint.IsAny() {
+		return []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}}}}, nil
+	}
+
+	ranges := make([]*Range, 0)
+	for _, conditions := range constraint.Alternatives() {
+		introduced := "0"
+		var end *Event
+		for _, condition := range conditions {
+			version := eventVersion(condition.Version)
+			switch condition.Operator {
+			case ">=":
+				introduced = version
+			case ">", "!=":
+				return nil, fmt.Errorf("%w: %s%s in %q", ErrUnsupportedConstraint, condition.Operator, version, affectedVersions)
+			case "<":
+				end = &Event{Fixed: version}
+			case "<=":
+				end = &Event{LastAffected: version}
+			case "==":
+				introduced = version
+				end = &Event{LastAffected: version}
+			}
+		}
+		events := []*Event{{Introduced: introduced}}
+		if end != nil {
+			events = append(events, end)
+		}
+		ranges = append(ranges, &Range{Type: "ECOSYSTEM", Events: events})
+	}
+	return ranges, nil
+}
+
+// eventVersion 返回适合放在 OSV 事件中的版本号，去掉约束解析时补上的 -dev 后缀和多余的第四段
+func eventVersion(v *semver.Version) string {
+	if v.IsBranch() {
+		return v.Normalized()
+	}
+	if original := v.Original(); original != "" {
+		return strings.TrimSuffix(original, "-dev")
+	}
+	normalized := strings.TrimSuffix(v.Normalized(), "-dev")
+	return strings.TrimSuffix(normalized, ".0")
+}
@@ -0,0 +1,108 @@
+package osv
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdvisory() *response.Advisory {
+	return &response.Advisory{
+		AdvisoryID:         "PKSA-1234-5678-9abc",
+		PackageName:        "symfony/http-kernel",
+		RemoteID:           "symfony/http-kernel/CVE-2022-24894.yaml",
+		Title:              "Prevent storing cookie headers in HttpCache",
+		Link:               "https://symfony.com/cve-2022-24894",
+		Cve:                "CVE-2022-24894",
+		AffectedVersions:   ">=2.0.0,<4.4.50|>=5.0.0,<5.4.20",
+		Source:             "FriendsOfPHP/security-advisories",
+		ReportedAt:         "2023-02-01 08:00:00",
+		ComposerRepository: "https://packagist.org",
+		Severity:           response.SeverityMedium,
+		Sources: []*response.Sources{
+			{Name: "GitHub", RemoteID: "GHSA-h7vf-5wrv-9fhv"},
+			{Name: "FriendsOfPHP/security-advisories", RemoteID: "symfony/http-kernel/CVE-2022-24894.yaml"},
+		},
+	}
+}
+
+func TestFromAdvisory(t *testing.T) {
+	vulnerability, err := FromAdvisory(newTestAdvisory())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "PKSA-1234-5678-9abc", vulnerability.ID)
+	assert.Equal(t, SchemaVersion, vulnerability.SchemaVersion)
+	assert.Equal(t, "2023-02-01T08:00:00Z", vulnerability.Published)
+	assert.Equal(t, "2023-02-01T08:00:00Z", vulnerability.Modified)
+	assert.Equal(t, []string{"CVE-2022-24894", "GHSA-h7vf-5wrv-9fhv"}, vulnerability.Aliases)
+	assert.Equal(t, "https://symfony.com/cve-2022-24894", vulnerability.References[0].URL)
+	assert.Equal(t, "medium", vulnerability.DatabaseSpecific["severity"])
+
+	if assert.Len(t, vulnerability.Affected, 1) {
+		affected := vulnerability.Affected[0]
+		assert.Equal(t, &Package{Ecosystem: "Packagist", Name: "symfony/http-kernel", Purl: "pkg:composer/symfony/http-kernel"}, affected.Package)
+		assert.Equal(t, []*Range{
+			{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "2.0.0"}, {Fixed: "4.4.50"}}},
+			{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "5.0.0"}, {Fixed: "5.4.20"}}},
+		}, affected.Ranges)
+	}
+}
+
+func TestFromAdvisory_InvalidConstraint(t *testing.T) {
+	advisory := newTestAdvisory()
+	advisory.AffectedVersions = ">=foo bar"
+	_, err := FromAdvisory(advisory)
+	assert.Error(t, err)
+}
+
+func TestRangesFromConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       []*Range
+	}{
+		{"<1.2.3", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}, {Fixed: "1.2.3"}}}}},
+		{">=1.0,<=1.5.2", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "1.0"}, {LastAffected: "1.5.2"}}}}},
+		{"1.0.0", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "1.0.0"}, {LastAffected: "1.0.0"}}}}},
+		{">=3.0.0", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "3.0.0"}}}}},
+		{"*", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}}}}},
+		{"^2.1", []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "2.1"}, {Fixed: "3.0.0"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := RangesFromConstraint(tt.constraint)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRangesFromConstraint_Unsupported(t *testing.T) {
+	// >1.0 excludes 1.0 and != splits the range, neither can be expressed with OSV events
+	for _, constraint := range []string{">1.0", ">1.0,<2.0", "<1.0|>2.0", ">=1.0,<2.0,!=1.5.0", "!=1.5.0"} {
+		t.Run(constraint, func(t *testing.T) {
+			_, err := RangesFromConstraint(constraint)
+			assert.ErrorIs(t, err, ErrUnsupportedConstraint)
+		})
+	}
+
+	advisory := newTestAdvisory()
+	advisory.AffectedVersions = ">1.0,<1.2"
+	_, err := FromAdvisory(advisory)
+	assert.ErrorIs(t, err, ErrUnsupportedConstraint)
+}
+
+func TestExport(t *testing.T) {
+	buff := &bytes.Buffer{}
+	err := Export(buff, &response.AdvisoriesResponse{Advisories: map[string][]*response.Advisory{
+		"symfony/http-kernel": {newTestAdvisory()},
+	}})
+	assert.NoError(t, err)
+
+	var vulnerabilities []*Vulnerability
+	assert.NoError(t, json.Unmarshal(buff.Bytes(), &vulnerabilities))
+	assert.Len(t, vulnerabilities, 1)
+	assert.Equal(t, "PKSA-1234-5678-9abc", vulnerabilities[0].ID)
+}
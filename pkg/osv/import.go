@@ -0,0 +1,197 @@
+package osv
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// ToAdvisories 把 OSV 记录转换为 Packagist 格式的安全公告，非 Packagist 生态的包会被忽略
+func ToAdvisories(vulnerabilities []*Vulnerability) *response.AdvisoriesResponse {
+	result := &response.AdvisoriesResponse{Advisories: make(map[string][]*response.Advisory)}
+	for _, vulnerability := range vulnerabilities {
+		for _, affected := range vulnerability.Affected {
+			if affected.Package == nil || !strings.EqualFold(affected.Package.Ecosystem, Ecosystem) {
+				continue
+			}
+			advisory := toAdvisory(vulnerability, affected)
+			result.Advisories[advisory.PackageName] = append(result.Advisories[advisory.PackageName], advisory)
+		}
+	}
+	return result
+}
+
+func toAdvisory(vulnerability *Vulnerability, affected *Affected) *response.Advisory {
+	advisory := &response.Advisory{
+		AdvisoryID:         vulnerability.ID,
+		PackageName:        affected.Package.Name,
+		RemoteID:           vulnerability.ID,
+		Title:              vulnerability.Summary,
+		AffectedVersions:   ConstraintFromAffected(affected),
+		Source:             "OSV",
+		ComposerRepository: "https://packagist.org",
+		Sources:            make([]*response.Sources, 0),
+	}
+	if advisory.Title == "" {
+		advisory.Title = vulnerability.Details
+	}
+
+	// 从 Packagist 导出的记录可以还原出原始的字段
+	if original, ok := affected.DatabaseSpecific["affectedVersions"].(string); ok && original != "" {
+		advisory.AffectedVersions = original
+	}
+	if remoteId, ok := vulnerability.DatabaseSpecific["remoteId"].(string); ok && remoteId != "" {
+		advisory.RemoteID = remoteId
+	}
+	if source, ok := vulnerability.DatabaseSpecific["source"].(string); ok && source != "" {
+		advisory.Source = source
+	}
+	if repository, ok := vulnerability.DatabaseSpecific["composerRepository"].(string); ok {
+		advisory.ComposerRepository = repository
+	}
+	// GitHub 的 OSV 记录也是用 database_specific.severity 表示严重程度，不过是大写的
+	if severity, ok := vulnerability.DatabaseSpecific["severity"].(string); ok && response.Severity(severity).Rank() > 0 {
+		advisory.Severity = response.Severity(strings.ToLower(severity))
+		if advisory.Severity == "moderate" {
+			advisory.Severity = response.SeverityMedium
+		}
+	}
+	if sources, ok := vulnerability.DatabaseSpecific["sources"].([]interface{}); ok {
+		for _, item := range sources {
+			if source, ok := item.(map[string]interface{}); ok {
+				name, _ := source["name"].(string)
+				remoteId, _ := source["remoteId"].(string)
+				advisory.Sources = append(advisory.Sources, &response.Sources{Name: name, RemoteID: remoteId})
+			}
+		}
+	}
+
+	ids := append([]string{vulnerability.ID}, vulnerability.Aliases...)
+	for _, id := range ids {
+		if strings.HasPrefix(id, "CVE-") && advisory.Cve == "" {
+			advisory.Cve = id
+		}
+	}
+	if len(advisory.Sources) == 0 {
+		for _, id := range ids {
+			switch {
+			case strings.HasPrefix(id, "GHSA-"):
+				advisory.Sources = append(advisory.Sources, &response.Sources{Name: "GitHub", RemoteID: id})
+			case strings.HasPrefix(id, "PKSA-"), strings.HasPrefix(id, "CVE-"):
+			default:
+				advisory.Sources = append(advisory.Sources, &response.Sources{Name: "OSV", RemoteID: id})
+			}
+		}
+	}
+
+	for _, reference := range vulnerability.References {
+		if reference.Type == "ADVISORY" {
+			advisory.Link = reference.URL
+			break
+		}
+	}
+	if advisory.Link == "" && len(vulnerability.References) > 0 {
+		advisory.Link = vulnerability.References[0].URL
+	}
+
+	reportedAt := vulnerability.Published
+	if reportedAt == "" {
+		reportedAt = vulnerability.Modified
+	}
+	if t, err := time.Parse(time.RFC3339, reportedAt); err == nil {
+		advisory.ReportedAt = t.UTC().Format("2006-01-02 15:04:05")
+	}
+	return advisory
+}
+
+// ConstraintFromAffected 把 OSV 的版本范围转换为 Packagist 格式的版本约束，比如 >=1.0.0,<1.2.3|>=2.0.0,<2.0.1
+func ConstraintFromAffected(affected *Affected) string {
+	alternatives := make([]string, 0)
+	for _, r := range affected.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue
+		}
+		conditions := make([]string, 0, 2)
+		for _, event := range r.Events {
+			switch {
+			case event.Introduced != "":
+				if len(conditions) > 0 {
+					alternatives = append(alternatives, strings.Join(conditions, ","))
+					conditions = conditions[:0:0]
+				}
+				if event.Introduced != "0" {
+					conditions = append(conditions, ">="+event.Introduced)
+				}
+			case event.Fixed != "":
+				conditions = append(conditions, "<"+event.Fixed)
+				alternatives = append(alternatives, strings.Join(conditions, ","))
+				conditions = conditions[:0:0]
+			case event.LastAffected != "":
+				conditions = append(conditions, "<="+event.LastAffected)
+				alternatives = append(alternatives, strings.Join(conditions, ","))
+				conditions = conditions[:0:0]
+			case event.Limit != "":
+				conditions = append(conditions, "<"+event.Limit)
+			}
+		}
+		if len(conditions) > 0 {
+			alternatives = append(alternatives, strings.Join(conditions, ","))
+		} else if len(r.Events) == 1 && r.Events[0].Introduced == "0" {
+			alternatives = append(alternatives, "*")
+		}
+	}
+	// 没有范围的时候只能一个个列出受影响的版本
+	if len(alternatives) == 0 {
+		for _, version := range affected.Versions {
+			alternatives = append(alternatives, "=="+version)
+		}
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// Import 解析一条或多条 OSV 记录并转换为 Packagist 格式的安全公告
+func Import(data []byte) (*response.AdvisoriesResponse, error) {
+	vulnerabilities, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return ToAdvisories(vulnerabilities), nil
+}
+
+// ImportZip 导入 OSV 提供的 zip 格式的全量数据，比如 https://osv-vulnerabilities.storage.googleapis.com/Packagist/all.zip
+func ImportZip(r io.ReaderAt, size int64) (*response.AdvisoriesResponse, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	vulnerabilities := make([]*Vulnerability, 0, len(reader.File))
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || path.Ext(file.Name) != ".json" {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file.Name, err)
+		}
+		list, err := Unmarshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file.Name, err)
+		}
+		vulnerabilities = append(vulnerabilities, list...)
+	}
+	return ToAdvisories(vulnerabilities), nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
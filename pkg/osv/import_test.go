@@ -0,0 +1,100 @@
+package osv
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOSVRecord = `{
+	"schema_version": "1.4.0",
+	"id": "GHSA-h7vf-5wrv-9fhv",
+	"modified": "2023-02-03T10:00:00Z",
+	"published": "2023-02-01T08:00:00Z",
+	"database_specific": {"severity": "MODERATE"},
+	"aliases": ["CVE-2022-24894"],
+	"summary": "Prevent storing cookie headers in HttpCache",
+	"affected": [
+		{
+			"package": {"ecosystem": "Packagist", "name": "symfony/http-kernel"},
+			"ranges": [
+				{"type": "ECOSYSTEM", "events": [{"introduced": "2.0.0"}, {"fixed": "4.4.50"}, {"introduced": "5.0.0"}, {"fixed": "5.4.20"}]}
+			]
+		},
+		{
+			"package": {"ecosystem": "npm", "name": "not-php"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+		}
+	],
+	"references": [
+		{"type": "WEB", "url": "https://github.com/symfony/symfony/security"},
+		{"type": "ADVISORY", "url": "https://github.com/advisories/GHSA-h7vf-5wrv-9fhv"}
+	]
+}`
+
+func TestImport(t *testing.T) {
+	advisories, err := Import([]byte(testOSVRecord))
+	assert.NoError(t, err)
+	assert.Len(t, advisories.Advisories, 1)
+
+	advisory := advisories.Advisories["symfony/http-kernel"][0]
+	assert.Equal(t, "GHSA-h7vf-5wrv-9fhv", advisory.AdvisoryID)
+	assert.Equal(t, "CVE-2022-24894", advisory.Cve)
+	assert.Equal(t, ">=2.0.0,<4.4.50|>=5.0.0,<5.4.20", advisory.AffectedVersions)
+	assert.Equal(t, "https://github.com/advisories/GHSA-h7vf-5wrv-9fhv", advisory.Link)
+	assert.Equal(t, "2023-02-01 08:00:00", advisory.ReportedAt)
+	assert.Equal(t, response.SeverityMedium, advisory.Severity)
+	assert.Equal(t, []*response.Sources{{Name: "GitHub", RemoteID: "GHSA-h7vf-5wrv-9fhv"}}, advisory.Sources)
+
+	_, err = Import([]byte(`{invalid}`))
+	assert.Error(t, err)
+}
+
+func TestImport_RoundTrip(t *testing.T) {
+	original := &response.AdvisoriesResponse{Advisories: map[string][]*response.Advisory{
+		"symfony/http-kernel": {newTestAdvisory()},
+	}}
+	buff := &bytes.Buffer{}
+	assert.NoError(t, Export(buff, original))
+
+	imported, err := Import(buff.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, original, imported)
+}
+
+func TestImportZip(t *testing.T) {
+	buff := &bytes.Buffer{}
+	writer := zip.NewWriter(buff)
+	file, err := writer.Create("GHSA-h7vf-5wrv-9fhv.json")
+	assert.NoError(t, err)
+	file.Write([]byte(testOSVRecord))
+	_, err = writer.Create("README.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	advisories, err := ImportZip(bytes.NewReader(buff.Bytes()), int64(buff.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, advisories.Advisories["symfony/http-kernel"], 1)
+}
+
+func TestConstraintFromAffected(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected *Affected
+		want     string
+	}{
+		{"fixed from zero", &Affected{Ranges: []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}, {Fixed: "1.2.3"}}}}}, "<1.2.3"},
+		{"last affected", &Affected{Ranges: []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "1.0"}, {LastAffected: "1.5"}}}}}, ">=1.0,<=1.5"},
+		{"open ended", &Affected{Ranges: []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "3.0"}}}}}, ">=3.0"},
+		{"all versions", &Affected{Ranges: []*Range{{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}}}}}, "*"},
+		{"git ranges are ignored", &Affected{Ranges: []*Range{{Type: "GIT", Events: []*Event{{Introduced: "abc"}}}}, Versions: []string{"1.0.0", "1.0.1"}}, "==1.0.0|==1.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ConstraintFromAffected(tt.affected))
+		})
+	}
+}
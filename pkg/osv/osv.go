@@ -0,0 +1,85 @@
+package osv
+
+import "encoding/json"
+
+// SchemaVersion 导出时使用的 OSV schema 版本
+const SchemaVersion = "1.6.0"
+
+// Ecosystem composer 包在 OSV 中对应的生态名字
+const Ecosystem = "Packagist"
+
+// Vulnerability 表示一条 OSV 格式的漏洞记录
+// https://ossf.github.io/osv-schema/
+type Vulnerability struct {
+	SchemaVersion    string                 `json:"schema_version,omitempty"`
+	ID               string                 `json:"id"`
+	Modified         string                 `json:"modified"`
+	Published        string                 `json:"published,omitempty"`
+	Withdrawn        string                 `json:"withdrawn,omitempty"`
+	Aliases          []string               `json:"aliases,omitempty"`
+	Related          []string               `json:"related,omitempty"`
+	Summary          string                 `json:"summary,omitempty"`
+	Details          string                 `json:"details,omitempty"`
+	Severity         []*Severity            `json:"severity,omitempty"`
+	Affected         []*Affected            `json:"affected,omitempty"`
+	References       []*Reference           `json:"references,omitempty"`
+	DatabaseSpecific map[string]interface{} `json:"database_specific,omitempty"`
+}
+
+// Severity 表示漏洞的严重程度，比如 CVSS 向量
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected 表示受影响的一个包以及受影响的版本范围
+type Affected struct {
+	Package           *Package               `json:"package"`
+	Ranges            []*Range               `json:"ranges,omitempty"`
+	Versions          []string               `json:"versions,omitempty"`
+	EcosystemSpecific map[string]interface{} `json:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  map[string]interface{} `json:"database_specific,omitempty"`
+}
+
+// Package 表示 OSV 中的一个包
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Range 表示一段受影响的版本范围，由按版本排序的事件组成
+type Range struct {
+	// ECOSYSTEM、SEMVER 或者 GIT
+	Type   string   `json:"type"`
+	Repo   string   `json:"repo,omitempty"`
+	Events []*Event `json:"events"`
+}
+
+// Event 表示版本范围中的一个事件，每个事件只会设置其中一个字段
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Reference 表示漏洞相关的链接
+type Reference struct {
+	// ADVISORY、ARTICLE、REPORT、FIX、PACKAGE、WEB 等
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Unmarshal 解析一条或者多条 OSV 记录，支持单个对象和数组
+func Unmarshal(data []byte) ([]*Vulnerability, error) {
+	var list []*Vulnerability
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	vulnerability := &Vulnerability{}
+	if err := json.Unmarshal(data, vulnerability); err != nil {
+		return nil, err
+	}
+	return []*Vulnerability{vulnerability}, nil
+}
@@ -0,0 +1,51 @@
+package outdated
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON 把报告以 JSON 格式写出
+func (x *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(x)
+}
+
+// WriteMarkdown 把报告以 Markdown 表格的形式写出，只包含过期或者被废弃的包
+func (x *Report) WriteMarkdown(w io.Writer) error {
+	builder := &strings.Builder{}
+	builder.WriteString("| Package | Installed | Constraint | Latest satisfying | Latest | Update | Abandoned |\n")
+	builder.WriteString("|---------|-----------|------------|-------------------|--------|--------|-----------|\n")
+	for _, packageReport := range x.Outdated() {
+		name := packageReport.Name
+		if packageReport.Dev {
+			name += " (dev)"
+		}
+		abandoned := ""
+		if packageReport.Abandoned {
+			abandoned = "yes"
+			if packageReport.Replacement != "" {
+				abandoned = "use " + packageReport.Replacement
+			}
+		}
+		fmt.Fprintf(builder, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			escapeMarkdown(name),
+			escapeMarkdown(packageReport.Installed),
+			escapeMarkdown(packageReport.Constraint),
+			escapeMarkdown(packageReport.LatestSatisfying),
+			escapeMarkdown(packageReport.Latest),
+			packageReport.UpdateType,
+			escapeMarkdown(abandoned),
+		)
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// 约束中的 || 会破坏表格，需要转义
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
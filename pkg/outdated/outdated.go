@@ -0,0 +1,239 @@
+package outdated
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// UpdateType 表示从当前版本升级到最新版本的跨度
+type UpdateType string
+
+const (
+	UpdateNone    UpdateType = "none"
+	UpdatePatch   UpdateType = "patch"
+	UpdateMinor   UpdateType = "minor"
+	UpdateMajor   UpdateType = "major"
+	UpdateUnknown UpdateType = "unknown"
+)
+
+// PackageReport 表示一个被锁定的包的过期情况
+type PackageReport struct {
+	// 包名
+	Name string `json:"name"`
+	// 是否是开发环境依赖
+	Dev bool `json:"dev"`
+	// composer.lock 中锁定的版本
+	Installed string `json:"installed"`
+	// 对这个包的版本约束，直接依赖取自 composer.json，间接依赖取自依赖它的包
+	Constraint string `json:"constraint"`
+	// 满足版本约束的最新版本
+	LatestSatisfying string `json:"latest_satisfying"`
+	// 不考虑版本约束的最新版本
+	Latest string `json:"latest"`
+	// 升级到最新版本的跨度
+	UpdateType UpdateType `json:"update_type"`
+	// 包是否被废弃，以及推荐的替代包
+	Abandoned   bool   `json:"abandoned"`
+	Replacement string `json:"replacement,omitempty"`
+	// 获取包信息失败时的错误
+	Error string `json:"error,omitempty"`
+}
+
+// IsOutdated 是否有更新的版本可用
+func (x *PackageReport) IsOutdated() bool {
+	return x.UpdateType != UpdateNone && x.UpdateType != UpdateUnknown
+}
+
+// Report 表示一个项目的依赖过期报告
+type Report struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Packages    []*PackageReport `json:"packages"`
+}
+
+// Outdated 返回所有有更新版本可用或者已经被废弃的包
+func (x *Report) Outdated() []*PackageReport {
+	result := make([]*PackageReport, 0)
+	for _, packageReport := range x.Packages {
+		if packageReport.IsOutdated() || packageReport.Abandoned {
+			result = append(result, packageReport)
+		}
+	}
+	return result
+}
+
+// 与 composer 中 BasePackage::STABILITIES 对应的稳定性标记
+var stabilityFlags = map[int]semver.Stability{
+	0:  semver.StabilityStable,
+	5:  semver.StabilityRC,
+	10: semver.StabilityBeta,
+	15: semver.StabilityAlpha,
+	20: semver.StabilityDev,
+}
+
+// Check 检查 composer.lock 中锁定的每个包是否有更新的版本
+// composerJSON 可以为空，为空的时候直接依赖的版本约束也只能从依赖它的包中推断
+func Check(ctx context.Context, repo *repository.Repository, lock *manifest.ComposerLock, composerJSON *manifest.ComposerJSON) (*Report, error) {
+	minimumStability, err := semver.ParseStability(lock.MinimumStability)
+	if err != nil {
+		minimumStability = semver.StabilityStable
+	}
+
+	report := &Report{
+		GeneratedAt: time.Now(),
+		Packages:    make([]*PackageReport, 0, len(lock.Packages)+len(lock.PackagesDev)),
+	}
+	for _, lockPackage := range lock.AllPackages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		constraints := constraintsOf(lockPackage.Name, lock, composerJSON)
+		packageReport := &PackageReport{
+			Name:       lockPackage.Name,
+			Dev:        lock.IsDev(lockPackage.Name),
+			Installed:  lockPackage.Version,
+			Constraint: joinConstraints(constraints),
+			UpdateType: UpdateUnknown,
+		}
+		if lockPackage.Abandoned != nil {
+			packageReport.Abandoned = lockPackage.Abandoned.IsAbandoned
+			packageReport.Replacement = lockPackage.Abandoned.Replacement
+		}
+		report.Packages = append(report.Packages, packageReport)
+
+		info, err := repo.GetPackage(ctx, lockPackage.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			packageReport.Error = err.Error()
+			continue
+		}
+
+		// 仓库中的废弃状态比 composer.lock 中记录的更新
+		if info.Package.Abandoned.IsAbandoned {
+			packageReport.Abandoned = true
+			packageReport.Replacement = info.Package.Abandoned.Replacement
+		}
+
+		stability := minimumStability
+		if flag, ok := stabilityFlags[lock.StabilityFlags[strings.ToLower(lockPackage.Name)]]; ok && flag < stability {
+			stability = flag
+		}
+		fillLatest(packageReport, constraints, info, stability)
+	}
+	return report, nil
+}
+
+// constraintsOf 获取对一个包的版本约束，多个包都依赖它的时候返回多个约束，需要同时满足
+func constraintsOf(packageName string, lock *manifest.ComposerLock, composerJSON *manifest.ComposerJSON) []string {
+	if composerJSON != nil {
+		for name, constraint := range composerJSON.AllRequires() {
+			if strings.EqualFold(name, packageName) {
+				return []string{constraint}
+			}
+		}
+	}
+
+	constraints := make([]string, 0)
+	for _, lockPackage := range lock.AllPackages() {
+		for name, constraint := range lockPackage.Require {
+			if strings.EqualFold(name, packageName) {
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+	if len(constraints) == 0 {
+		return []string{"*"}
+	}
+	return constraints
+}
+
+// joinConstraints 把多个约束拼成一个字符串，只用来展示
+func joinConstraints(constraints []string) string {
+	if len(constraints) == 1 {
+		return constraints[0]
+	}
+	return "(" + strings.Join(constraints, "), (") + ")"
+}
+
+// fillLatest 从包的所有版本中找出满足约束的最新版本和最新版本
+func fillLatest(packageReport *PackageReport, constraints []string, info *composer_crawler.ComposerPackageInfo, minimumStability semver.Stability) {
+	installed, err := semver.Parse(packageReport.Installed)
+	if err != nil {
+		packageReport.Error = err.Error()
+		return
+	}
+	// 安装的就是不稳定版本的时候，同样稳定性的版本也需要考虑
+	if installed.Stability() < minimumStability {
+		minimumStability = installed.Stability()
+	}
+
+	constraint, err := parseConstraints(constraints)
+	if err != nil {
+		packageReport.Error = err.Error()
+		return
+	}
+
+	var latest, latestSatisfying *semver.Version
+	for versionName := range info.Package.Versions {
+		version, err := semver.Parse(versionName)
+		if err != nil || version.IsBranch() || version.IsNumericBranch() || version.Stability() < minimumStability {
+			continue
+		}
+		if latest == nil || latest.LessThan(version) {
+			latest = version
+		}
+		if constraint.Check(version) && (latestSatisfying == nil || latestSatisfying.LessThan(version)) {
+			latestSatisfying = version
+		}
+	}
+
+	if latestSatisfying != nil {
+		packageReport.LatestSatisfying = latestSatisfying.Original()
+	}
+	if latest == nil {
+		return
+	}
+	packageReport.Latest = latest.Original()
+	packageReport.UpdateType = updateTypeOf(installed, latest)
+}
+
+// parseConstraints 解析多个约束，返回同时满足它们的约束
+func parseConstraints(constraints []string) (*semver.Constraint, error) {
+	var result *semver.Constraint
+	for _, part := range constraints {
+		constraint, err := semver.ParseConstraint(part)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = constraint
+		} else {
+			result = result.And(constraint)
+		}
+	}
+	return result, nil
+}
+
+func updateTypeOf(installed, latest *semver.Version) UpdateType {
+	if installed.IsBranch() || installed.IsNumericBranch() {
+		return UpdateUnknown
+	}
+	switch {
+	case !installed.LessThan(latest):
+		return UpdateNone
+	case installed.Major() != latest.Major():
+		return UpdateMajor
+	case installed.Minor() != latest.Minor():
+		return UpdateMinor
+	default:
+		return UpdatePatch
+	}
+}
@@ -0,0 +1,208 @@
+package outdated
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// createPackagesServer creates a test server that serves package metadata for the given package versions
+func createPackagesServer(packages map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, versions := range packages {
+			if r.URL.Path != "/packages/"+name+".json" {
+				continue
+			}
+			versionMap := map[string]interface{}{}
+			for _, version := range versions {
+				versionMap[version] = map[string]interface{}{"name": name, "version": version}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"package": map[string]interface{}{"name": name, "versions": versionMap},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":"error","message":"Package not found"}`))
+	}))
+}
+
+const testLock = `{
+	"content-hash": "d751713988987e9331980363e24189ce",
+	"packages": [
+		{"name": "monolog/monolog", "version": "2.8.0", "require": {"psr/log": "^1.0.1 || ^2.0"}},
+		{"name": "psr/log", "version": "2.0.0"},
+		{"name": "swiftmailer/swiftmailer", "version": "v6.3.0", "abandoned": "symfony/mailer"},
+		{"name": "acme/missing", "version": "1.0.0"}
+	],
+	"packages-dev": [
+		{"name": "phpunit/phpunit", "version": "10.1.2"}
+	],
+	"minimum-stability": "stable",
+	"stability-flags": []
+}`
+
+func TestCheck(t *testing.T) {
+	server := createPackagesServer(map[string][]string{
+		"monolog/monolog":         {"2.8.0", "2.9.1", "3.0.0-RC1", "3.4.0", "dev-main", "2.x-dev"},
+		"psr/log":                 {"1.1.4", "2.0.0", "3.0.0"},
+		"swiftmailer/swiftmailer": {"v6.3.0"},
+		"phpunit/phpunit":         {"10.1.2", "10.1.3"},
+	})
+	defer server.Close()
+
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+	composerJSON, err := manifest.ParseComposerJSON([]byte(`{
+		"require": {"monolog/monolog": "^2.0", "swiftmailer/swiftmailer": "^6.0"},
+		"require-dev": {"phpunit/phpunit": "^10.0"}
+	}`))
+	assert.NoError(t, err)
+
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	report, err := Check(context.Background(), repo, lock, composerJSON)
+	assert.NoError(t, err)
+	if !assert.Len(t, report.Packages, 5) {
+		return
+	}
+
+	monolog := report.Packages[0]
+	assert.Equal(t, "^2.0", monolog.Constraint)
+	assert.Equal(t, "2.9.1", monolog.LatestSatisfying)
+	assert.Equal(t, "3.4.0", monolog.Latest)
+	assert.Equal(t, UpdateMajor, monolog.UpdateType)
+	assert.True(t, monolog.IsOutdated())
+
+	// Transitive dependency, the constraint comes from monolog
+	psrLog := report.Packages[1]
+	assert.Equal(t, "^1.0.1 || ^2.0", psrLog.Constraint)
+	assert.Equal(t, "2.0.0", psrLog.LatestSatisfying)
+	assert.Equal(t, "3.0.0", psrLog.Latest)
+	assert.Equal(t, UpdateMajor, psrLog.UpdateType)
+
+	swiftmailer := report.Packages[2]
+	assert.Equal(t, UpdateNone, swiftmailer.UpdateType)
+	assert.True(t, swiftmailer.Abandoned)
+	assert.Equal(t, "symfony/mailer", swiftmailer.Replacement)
+
+	missing := report.Packages[3]
+	assert.Equal(t, UpdateUnknown, missing.UpdateType)
+	assert.Equal(t, "*", missing.Constraint)
+	assert.NotEmpty(t, missing.Error)
+
+	phpunit := report.Packages[4]
+	assert.True(t, phpunit.Dev)
+	assert.Equal(t, UpdatePatch, phpunit.UpdateType)
+	assert.Equal(t, "10.1.3", phpunit.LatestSatisfying)
+
+	assert.Len(t, report.Outdated(), 4)
+}
+
+func TestCheck_ContextCanceled(t *testing.T) {
+	server := createPackagesServer(map[string][]string{})
+	defer server.Close()
+
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := Check(ctx, repository.NewRepository(&repository.Options{ServerUrl: server.URL}), lock, nil)
+	assert.Error(t, err)
+	assert.Nil(t, report)
+}
+
+func TestCheck_StabilityFlags(t *testing.T) {
+	server := createPackagesServer(map[string][]string{
+		"acme/beta": {"1.0.0", "1.1.0-beta1"},
+	})
+	defer server.Close()
+
+	lock, err := manifest.ParseComposerLock([]byte(`{
+		"packages": [{"name": "acme/beta", "version": "1.0.0"}],
+		"minimum-stability": "stable",
+		"stability-flags": {"acme/beta": 10}
+	}`))
+	assert.NoError(t, err)
+
+	report, err := Check(context.Background(), repository.NewRepository(&repository.Options{ServerUrl: server.URL}), lock, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0-beta1", report.Packages[0].Latest)
+	assert.Equal(t, UpdateMinor, report.Packages[0].UpdateType)
+}
+
+func TestReport_Export(t *testing.T) {
+	report := &Report{
+		Packages: []*PackageReport{
+			{Name: "monolog/monolog", Installed: "2.8.0", Constraint: "^1.0 || ^2.0", LatestSatisfying: "2.9.1", Latest: "3.4.0", UpdateType: UpdateMajor},
+			{Name: "swiftmailer/swiftmailer", Installed: "v6.3.0", Constraint: "^6.0", LatestSatisfying: "v6.3.0", Latest: "v6.3.0", UpdateType: UpdateNone, Abandoned: true, Replacement: "symfony/mailer"},
+			{Name: "psr/log", Installed: "3.0.0", Constraint: "^3.0", LatestSatisfying: "3.0.0", Latest: "3.0.0", UpdateType: UpdateNone},
+			{Name: "phpunit/phpunit", Dev: true, Installed: "10.1.2", Constraint: "^10.0", LatestSatisfying: "10.1.3", Latest: "10.1.3", UpdateType: UpdatePatch},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		assert.NoError(t, report.WriteJSON(buff))
+		decoded := &Report{}
+		assert.NoError(t, json.Unmarshal(buff.Bytes(), decoded))
+		assert.Equal(t, report.Packages, decoded.Packages)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		assert.NoError(t, report.WriteMarkdown(buff))
+		assert.Equal(t, "| Package | Installed | Constraint | Latest satisfying | Latest | Update | Abandoned |\n"+
+			"|---------|-----------|------------|-------------------|--------|--------|-----------|\n"+
+			"| monolog/monolog | 2.8.0 | ^1.0 \\|\\| ^2.0 | 2.9.1 | 3.4.0 | major |  |\n"+
+			"| swiftmailer/swiftmailer | v6.3.0 | ^6.0 | v6.3.0 | v6.3.0 | none | use symfony/mailer |\n"+
+			"| phpunit/phpunit (dev) | 10.1.2 | ^10.0 | 10.1.3 | 10.1.3 | patch |  |\n", buff.String())
+	})
+}
+
+func TestCheck_AbandonedInRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"package": {"name": "fzaninotto/faker", "abandoned": true, "versions": {"v1.9.2": {"version": "v1.9.2"}}}}`))
+	}))
+	defer server.Close()
+
+	lock, err := manifest.ParseComposerLock([]byte(`{"packages": [{"name": "fzaninotto/faker", "version": "v1.9.2"}]}`))
+	assert.NoError(t, err)
+
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	report, err := Check(context.Background(), repo, lock, nil)
+	assert.NoError(t, err)
+	assert.True(t, report.Packages[0].Abandoned)
+	assert.Empty(t, report.Packages[0].Replacement)
+	assert.Len(t, report.Outdated(), 1)
+}
+
+func TestConstraintsOf(t *testing.T) {
+	lock, err := manifest.ParseComposerLock([]byte(`{
+		"packages": [
+			{"name": "a/a", "version": "1.0.0", "require": {"psr/log": ">=1.0 <1.5"}},
+			{"name": "b/b", "version": "1.0.0", "require": {"PSR/log": "^1.2 || ^3.0"}},
+			{"name": "psr/log", "version": "1.2.0"}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	// Each requirer keeps its own constraint, they are combined without going through a string
+	constraints := constraintsOf("psr/log", lock, nil)
+	assert.Equal(t, []string{">=1.0 <1.5", "^1.2 || ^3.0"}, constraints)
+	assert.Equal(t, "(>=1.0 <1.5), (^1.2 || ^3.0)", joinConstraints(constraints))
+	constraint, err := parseConstraints(constraints)
+	assert.NoError(t, err)
+	assert.True(t, constraint.CheckString("1.3.0"))
+	assert.False(t, constraint.CheckString("1.1.0"))
+	assert.False(t, constraint.CheckString("3.0.0"))
+
+	assert.Equal(t, []string{"*"}, constraintsOf("other/package", lock, nil))
+}
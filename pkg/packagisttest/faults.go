@@ -0,0 +1,111 @@
+package packagisttest
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault 表示注入的一个故障，用来测试超时、重试以及错误处理
+type Fault struct {
+
+	// 只对路径以 PathPrefix 开头的请求生效，为空的时候对所有请求生效
+	PathPrefix string
+
+	// 响应之前等待的时间，可以和其它的故障一起使用
+	Latency time.Duration
+
+	// 不处理请求，直接返回这个状态码，比如 500、502、503
+	StatusCode int
+
+	// 响应体只写出一半就断开连接，Content-Length 仍然是完整的长度
+	Truncate bool
+
+	// 生效的次数，0 表示一直生效
+	Times int
+}
+
+// InjectFault 注入一个故障，多个故障同时匹配的时候使用最早注入的那个
+func (x *Server) InjectFault(fault *Fault) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	copied := *fault
+	x.faults = append(x.faults, &copied)
+}
+
+// ClearFaults 清除所有注入的故障
+func (x *Server) ClearFaults() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.faults = nil
+}
+
+// SetRateLimit 限制每个时间窗口内最多处理 limit 个请求，超出的请求返回 429 以及 Retry-After，
+// limit 小于等于 0 的时候不限流
+func (x *Server) SetRateLimit(limit int, window time.Duration) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.rateLimit = limit
+	x.rateWindow = window
+	x.rateStart = time.Time{}
+	x.rateCount = 0
+}
+
+// 需要持有锁
+func (x *Server) takeFault(path string) *Fault {
+	for i, fault := range x.faults {
+		if !strings.HasPrefix(path, fault.PathPrefix) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				x.faults = append(x.faults[:i:i], x.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// 需要持有锁，被限流的时候返回还需要等待多久
+func (x *Server) takeRateLimit() (time.Duration, bool) {
+	if x.rateLimit <= 0 {
+		return 0, false
+	}
+	now := x.options.Now()
+	if x.rateStart.IsZero() || now.Sub(x.rateStart) >= x.rateWindow {
+		x.rateStart = now
+		x.rateCount = 0
+	}
+	if x.rateCount >= x.rateLimit {
+		return x.rateWindow - now.Sub(x.rateStart), true
+	}
+	x.rateCount++
+	return 0, false
+}
+
+// truncatingWriter 先把响应缓存起来，结束的时候声明完整的 Content-Length 但只写出一半，
+// 客户端读取响应体的时候会遇到 unexpected EOF
+type truncatingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (x *truncatingWriter) WriteHeader(status int) {
+	x.status = status
+}
+
+func (x *truncatingWriter) Write(p []byte) (int, error) {
+	return x.body.Write(p)
+}
+
+func (x *truncatingWriter) flush() {
+	data := x.body.Bytes()
+	x.ResponseWriter.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	x.ResponseWriter.WriteHeader(x.status)
+	x.ResponseWriter.Write(data[:len(data)/2])
+}
@@ -0,0 +1,95 @@
+package packagisttest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_InjectFault(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.AddPackage(NewPackage("a/a", "1.0.0"))
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL, RetryDelay: time.Millisecond})
+	ctx := context.Background()
+
+	t.Run("status code", func(t *testing.T) {
+		// The client tries every request three times
+		server.InjectFault(&Fault{PathPrefix: "/packages/", StatusCode: http.StatusBadGateway, Times: 4})
+		_, err := repo.GetPackage(ctx, "a/a")
+		assert.EqualError(t, err, "response status code: 502")
+		// The fourth attempt fails and the fifth succeeds
+		_, err = repo.GetPackage(ctx, "a/a")
+		assert.NoError(t, err)
+		_, err = repo.GetPackage(ctx, "a/a")
+		assert.NoError(t, err)
+	})
+
+	t.Run("other paths are not affected", func(t *testing.T) {
+		server.InjectFault(&Fault{PathPrefix: "/statistics.json", StatusCode: http.StatusServiceUnavailable})
+		defer server.ClearFaults()
+		_, err := repo.GetPackage(ctx, "a/a")
+		assert.NoError(t, err)
+		_, err = repo.Statistics(ctx)
+		assert.EqualError(t, err, "response status code: 503")
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		server.InjectFault(&Fault{Latency: time.Second, Times: 1})
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := repo.GetPackage(timeout, "a/a")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		server.InjectFault(&Fault{Truncate: true, Times: 1})
+		response, err := server.Client().Get(server.URL + "/packages/a/a.json")
+		assert.NoError(t, err)
+		defer response.Body.Close()
+		_, err = repo.GetPackage(ctx, "a/a")
+		assert.NoError(t, err)
+
+		buffer := make([]byte, response.ContentLength)
+		_, err = response.Body.Read(buffer)
+		for err == nil {
+			_, err = response.Body.Read(buffer)
+		}
+		assert.EqualError(t, err, "unexpected EOF")
+	})
+}
+
+func TestServer_SetRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := NewServer(&Options{Now: func() time.Time { return now }})
+	defer server.Close()
+	server.SetRateLimit(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		response, _ := get(t, server, "/packages/list.json", nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+	response, body := get(t, server, "/packages/list.json", nil)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "60", response.Header.Get("Retry-After"))
+	assert.JSONEq(t, `{"status":"error","message":"Rate limit exceeded"}`, body)
+
+	now = now.Add(45 * time.Second)
+	response, _ = get(t, server, "/packages/list.json", nil)
+	assert.Equal(t, "15", response.Header.Get("Retry-After"))
+
+	// A new window starts
+	now = now.Add(15 * time.Second)
+	response, _ = get(t, server, "/packages/list.json", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	server.SetRateLimit(0, 0)
+	for i := 0; i < 5; i++ {
+		response, _ := get(t, server, "/packages/list.json", nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+}
@@ -0,0 +1,336 @@
+package packagisttest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+func (x *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	x.lock.Lock()
+	x.requests = append(x.requests, r.URL.RequestURI())
+	handler := x.handlers[r.URL.Path]
+	fault := x.takeFault(r.URL.Path)
+	retryAfter, limited := x.takeRateLimit()
+	x.lock.Unlock()
+
+	if fault != nil && fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if limited {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+		return
+	}
+	if fault != nil && fault.StatusCode != 0 {
+		writeError(w, fault.StatusCode, http.StatusText(fault.StatusCode))
+		return
+	}
+	if fault != nil && fault.Truncate {
+		truncating := &truncatingWriter{ResponseWriter: w, status: http.StatusOK}
+		defer truncating.flush()
+		w = truncating
+	}
+
+	if handler != nil {
+		handler(w, r)
+		return
+	}
+	x.route(w, r)
+}
+
+func (x *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	switch {
+	case path == "/packages/list.json":
+		x.handleList(w, r)
+	case path == "/statistics.json":
+		x.handleStatistics(w, r)
+	case path == "/metadata/changes.json":
+		x.handleChanges(w, r)
+	case path == "/api/security-advisories/" || path == "/api/security-advisories":
+		x.handleAdvisories(w, r)
+	case strings.HasPrefix(path, "/packages/") && strings.HasSuffix(path, "/stats.json"):
+		x.handlePackageStatistics(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/packages/"), "/stats.json"))
+	case strings.HasPrefix(path, "/packages/") && strings.HasSuffix(path, ".json"):
+		x.handlePackage(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/packages/"), ".json"))
+	case strings.HasPrefix(path, "/p2/") && strings.HasSuffix(path, "~dev.json"):
+		x.handleP2(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/p2/"), "~dev.json"), true)
+	case strings.HasPrefix(path, "/p2/") && strings.HasSuffix(path, ".json"):
+		x.handleP2(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/p2/"), ".json"), false)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// https://packagist.org/apidoc#list-packages
+func (x *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	vendor := strings.ToLower(r.URL.Query().Get("vendor"))
+	packageType := r.URL.Query().Get("type")
+
+	x.lock.Lock()
+	names := make([]string, 0, len(x.packages))
+	for _, name := range x.sortedPackageNames() {
+		info := x.packages[strings.ToLower(name)]
+		if vendor != "" && !strings.HasPrefix(strings.ToLower(name), vendor+"/") {
+			continue
+		}
+		if packageType != "" && info.Package.Type != packageType {
+			continue
+		}
+		names = append(names, name)
+	}
+	x.lock.Unlock()
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"packageNames": names})
+}
+
+func (x *Server) handleStatistics(w http.ResponseWriter, r *http.Request) {
+	x.lock.Lock()
+	totals := response.Totals{}
+	if x.statistics != nil {
+		totals = *x.statistics
+	} else {
+		for _, info := range x.packages {
+			totals.Downloads += int64(info.Package.Downloads.Total)
+			totals.Packages++
+			totals.Versions += len(info.Package.Versions)
+		}
+	}
+	x.lock.Unlock()
+
+	writeJSON(w, r, http.StatusOK, &response.StatisticsResponse{Totals: totals})
+}
+
+func (x *Server) handlePackage(w http.ResponseWriter, r *http.Request, packageName string) {
+	x.lock.Lock()
+	info, ok := x.packages[strings.ToLower(packageName)]
+	var body interface{}
+	if ok {
+		// 真实的仓库只返回 package 这一层
+		body = map[string]interface{}{"package": info.Package}
+	}
+	data, err := json.Marshal(body)
+	x.lock.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeBytes(w, r, http.StatusOK, data)
+}
+
+// Composer v2 的元数据，打了标签的版本和分支版本分开，版本列表使用 composer/2.0 压缩格式
+// https://packagist.org/apidoc#get-package-metadata-v2
+func (x *Server) handleP2(w http.ResponseWriter, r *http.Request, packageName string, dev bool) {
+	// 真实的仓库只接受小写的包名
+	x.lock.Lock()
+	info, ok := x.packages[packageName]
+	versions := make([]*composer_crawler.Version, 0)
+	if ok {
+		for _, version := range info.Package.Versions {
+			if version.IsDev() == dev {
+				versions = append(versions, version)
+			}
+		}
+	}
+	rawVersions := make([]map[string]json.RawMessage, 0, len(versions))
+	sortVersions(versions)
+	for _, version := range versions {
+		rawVersion := make(map[string]json.RawMessage)
+		copyJSON(version, &rawVersion)
+		rawVersions = append(rawVersions, rawVersion)
+	}
+	x.lock.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"packages": map[string]interface{}{packageName: repository.MinifyVersions(rawVersions)},
+		"minified": repository.MinifiedComposer2,
+	})
+}
+
+// 和 Packagist 一样，打了标签的版本从新到旧，分支版本按名字排序
+func sortVersions(versions []*composer_crawler.Version) {
+	sort.Slice(versions, func(i, j int) bool {
+		a, errA := semver.Parse(versions[i].Version)
+		b, errB := semver.Parse(versions[j].Version)
+		if errA != nil || errB != nil {
+			return versions[i].Version < versions[j].Version
+		}
+		if r := semver.Compare(a, b); r != 0 && !a.IsBranch() && !b.IsBranch() {
+			return r > 0
+		}
+		return versions[i].Version < versions[j].Version
+	})
+}
+
+func (x *Server) handlePackageStatistics(w http.ResponseWriter, r *http.Request, packageName string) {
+	x.lock.Lock()
+	key := strings.ToLower(packageName)
+	info, ok := x.packages[key]
+	statistics := x.packageStatistics[key]
+	if ok && statistics == nil {
+		versions := make([]string, 0, len(info.Package.Versions))
+		for version := range info.Package.Versions {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+		statistics = &response.PackageStatisticsResponse{
+			Downloads: response.PackageDownloads{
+				Total:   int64(info.Package.Downloads.Total),
+				Monthly: int64(info.Package.Downloads.Monthly),
+				Daily:   int64(info.Package.Downloads.Daily),
+			},
+			Versions: versions,
+			Date:     x.options.Now().UTC().Format("2006-01-02"),
+		}
+	}
+	x.lock.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, statistics)
+}
+
+// https://packagist.org/apidoc#track-package-updates
+func (x *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+	x.lock.Lock()
+	timestamp := x.timestamp()
+	changes := &response.ChangesResponse{Actions: make([]*response.ChangeAction, 0), Timestamp: timestamp}
+	status := http.StatusOK
+	switch {
+	case since <= 0:
+		changes.Error = "Invalid or missing ?since query parameter, make sure you use the timestamp returned by the last request"
+		status = http.StatusBadRequest
+	case since < x.resyncBefore:
+		changes.Actions = append(changes.Actions, &response.ChangeAction{Type: response.ChangeActionResync, Package: "*", Time: timestamp / 10000})
+	default:
+		for _, c := range x.changes {
+			if c.timestamp > since {
+				changes.Actions = append(changes.Actions, c.action)
+			}
+		}
+	}
+	x.lock.Unlock()
+
+	if changes.Error != "" {
+		writeJSON(w, r, status, map[string]interface{}{"error": changes.Error, "timestamp": changes.Timestamp})
+		return
+	}
+	writeJSON(w, r, status, changes)
+}
+
+// https://packagist.org/apidoc#list-security-advisories
+func (x *Server) handleAdvisories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	packageNames := append(query["packages[]"], query["packages"]...)
+	updatedSince := query.Get("updatedSince")
+	if len(packageNames) == 0 && updatedSince == "" {
+		writeError(w, http.StatusBadRequest, "Missing packages or updatedSince parameter")
+		return
+	}
+	// 和 Packagist 一样，updatedSince 是以秒为单位的 Unix 时间戳
+	var since int64
+	if updatedSince != "" {
+		value, err := strconv.ParseInt(updatedSince, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid updatedSince parameter")
+			return
+		}
+		since = value
+	}
+
+	x.lock.Lock()
+	result := &response.AdvisoriesResponse{Advisories: make(map[string][]*response.Advisory)}
+	keys := make([]string, 0)
+	if len(packageNames) > 0 {
+		for _, name := range packageNames {
+			keys = append(keys, strings.ToLower(name))
+		}
+	} else {
+		for key := range x.advisories {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		for _, stored := range x.advisories[key] {
+			if updatedSince != "" && stored.updatedAt.Unix() < since {
+				continue
+			}
+			advisory := stored.advisory
+			result.Advisories[advisory.PackageName] = append(result.Advisories[advisory.PackageName], advisory)
+		}
+	}
+	data, err := json.Marshal(result)
+	x.lock.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeBytes(w, r, http.StatusOK, data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(map[string]string{"status": "error", "message": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeBytes(w, r, status, data)
+}
+
+// 成功的响应都带着 ETag，请求的 If-None-Match 匹配的时候返回 304
+func writeBytes(w http.ResponseWriter, r *http.Request, status int, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusOK {
+		sum := sha1.Sum(data)
+		etag := `"` + hex.EncodeToString(sum[:]) + `"`
+		w.Header().Set("ETag", etag)
+		if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+			for _, candidate := range strings.Split(ifNoneMatch, ",") {
+				candidate = strings.TrimSpace(candidate)
+				if candidate == etag || candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,122 @@
+package packagisttest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, server *Server, path string, header map[string]string) (*http.Response, string) {
+	request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	assert.NoError(t, err)
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	response, err := server.Client().Do(request)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response, string(body)
+}
+
+func TestServer_ETag(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.AddPackage(NewPackage("a/a", "1.0.0"))
+
+	response, _ := get(t, server, "/packages/a/a.json", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	response, body := get(t, server, "/packages/a/a.json", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+	assert.Empty(t, body)
+
+	response, _ = get(t, server, "/packages/a/a.json", map[string]string{"If-None-Match": `"other", W/` + etag})
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+
+	// A new version changes the ETag
+	server.AddVersion("a/a", NewVersion("a/a", "1.1.0"))
+	response, _ = get(t, server, "/packages/a/a.json", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, etag, response.Header.Get("ETag"))
+}
+
+func TestServer_Routes(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.AddPackage(NewPackage("symfony/console"))
+	plugin := NewPackage("symfony/flex")
+	plugin.Package.Type = "composer-plugin"
+	server.AddPackage(plugin)
+	server.AddPackage(NewPackage("monolog/monolog"))
+
+	testCases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/packages/list.json?vendor=symfony", http.StatusOK, `{"packageNames":["symfony/console","symfony/flex"]}`},
+		{"/packages/list.json?type=composer-plugin", http.StatusOK, `{"packageNames":["symfony/flex"]}`},
+		{"/packages/not/exists.json", http.StatusNotFound, `{"status":"error","message":"Package not found"}`},
+		{"/api/security-advisories/", http.StatusBadRequest, `{"status":"error","message":"Missing packages or updatedSince parameter"}`},
+		{"/api/security-advisories/?updatedSince=yesterday", http.StatusBadRequest, `{"status":"error","message":"Invalid updatedSince parameter"}`},
+		{"/api/security-advisories/?packages[]=a/b", http.StatusOK, `{"advisories":{}}`},
+		{"/nope", http.StatusNotFound, `{"status":"error","message":"Not found"}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.path, func(t *testing.T) {
+			response, body := get(t, server, testCase.path, nil)
+			assert.Equal(t, testCase.status, response.StatusCode)
+			assert.JSONEq(t, testCase.body, body)
+		})
+	}
+}
+
+func TestServer_HandleFunc(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.HandleFunc("/packages/list.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"packageNames": ["custom/package"]}`))
+	})
+
+	_, body := get(t, server, "/packages/list.json", nil)
+	assert.JSONEq(t, `{"packageNames": ["custom/package"]}`, body)
+}
+
+func TestServer_P2(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	info := NewPackage("Monolog/Monolog", "2.9.2", "3.5.0", "3.5.0-RC1", "2.x-dev")
+	main := NewVersion("Monolog/Monolog", "dev-main")
+	main.Extra = json.RawMessage(`{"branch-alias": {"dev-main": "3.x-dev"}}`)
+	info.Package.Versions[main.Version] = main
+	server.AddPackage(info)
+
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	ctx := context.Background()
+	all, err := repo.GetPackageAllVersions(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	names := make([]string, 0, len(all))
+	for _, version := range all {
+		names = append(names, version.Version)
+	}
+	assert.Equal(t, []string{"3.5.0", "3.5.0-RC1", "2.9.2", "2.x-dev", "dev-main"}, names)
+	assert.Equal(t, "3.x-dev", all[4].BranchAlias())
+	assert.Equal(t, "Monolog/Monolog", all[2].Name)
+
+	response, body := get(t, server, "/p2/monolog/monolog.json", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, body, `"minified":"composer/2.0"`)
+
+	response, _ = get(t, server, "/p2/not/exists~dev.json", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
@@ -0,0 +1,265 @@
+package packagisttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// Options 假仓库的配置
+type Options struct {
+
+	// 获取当前时间，changes 的时间戳以及统计的日期都从这里来，为空的时候使用 time.Now
+	Now func() time.Time
+}
+
+// Server 是一个在进程内运行的假 Packagist 仓库，用来写不依赖网络的测试。
+// 包、版本、安全公告、下载统计都可以在测试中随时修改，包的增删改会自动记录到 changes 中
+type Server struct {
+
+	// 仓库的地址，作为 repository.Options 的 ServerUrl 使用
+	URL string
+
+	server  *httptest.Server
+	options *Options
+
+	lock              sync.Mutex
+	packages          map[string]*composer_crawler.ComposerPackageInfo
+	packageStatistics map[string]*response.PackageStatisticsResponse
+	advisories        map[string][]*storedAdvisory
+	statistics        *response.Totals
+	handlers          map[string]http.HandlerFunc
+
+	// changes 的时间戳，单位是 1/10000 秒
+	changes       []*change
+	lastTimestamp int64
+	resyncBefore  int64
+	faults        []*Fault
+	rateLimit     int
+	rateWindow    time.Duration
+	rateStart     time.Time
+	rateCount     int
+	requests      []string
+}
+
+// storedAdvisory 是一条安全公告以及它最后一次被添加或者修改的时间，updatedSince 按这个时间过滤
+type storedAdvisory struct {
+	advisory  *response.Advisory
+	updatedAt time.Time
+}
+
+type change struct {
+	action    *response.ChangeAction
+	timestamp int64
+}
+
+// NewServer 启动一个空的假仓库，用完之后需要调用 Close
+func NewServer(options *Options) *Server {
+	if options == nil {
+		options = &Options{}
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	x := &Server{
+		options:           options,
+		packages:          make(map[string]*composer_crawler.ComposerPackageInfo),
+		packageStatistics: make(map[string]*response.PackageStatisticsResponse),
+		advisories:        make(map[string][]*storedAdvisory),
+		handlers:          make(map[string]http.HandlerFunc),
+	}
+	x.server = httptest.NewServer(http.HandlerFunc(x.serveHTTP))
+	x.URL = x.server.URL
+	return x
+}
+
+// Close 关闭假仓库
+func (x *Server) Close() {
+	x.server.Close()
+}
+
+// Client 返回访问假仓库使用的 http.Client
+func (x *Server) Client() *http.Client {
+	return x.server.Client()
+}
+
+// NewPackage 创建一个只有基本信息的包，每个版本都带着 GitHub 上的 source 和 zip dist，方便在测试中直接使用
+func NewPackage(name string, versions ...string) *composer_crawler.ComposerPackageInfo {
+	info := &composer_crawler.ComposerPackageInfo{PackageName: name, PackageNameLowercase: strings.ToLower(name)}
+	info.Package.Name = name
+	info.Package.Type = "library"
+	info.Package.Repository = "https://github.com/" + name
+	info.Package.Versions = make(map[string]*composer_crawler.Version, len(versions))
+	for _, version := range versions {
+		info.Package.Versions[version] = NewVersion(name, version)
+	}
+	return info
+}
+
+// NewVersion 创建包的一个版本
+func NewVersion(name, version string) *composer_crawler.Version {
+	v := &composer_crawler.Version{Name: name, Version: version, Type: "library", License: []string{"MIT"}}
+	v.Source.Type = "git"
+	v.Source.URL = "https://github.com/" + name + ".git"
+	v.Dist.Type = "zip"
+	v.Dist.URL = "https://api.github.com/repos/" + name + "/zipball/" + version
+	return v
+}
+
+// AddPackage 添加或者替换一个包，会记录一条 update 的 change
+func (x *Server) AddPackage(info *composer_crawler.ComposerPackageInfo) {
+	copied := copyPackage(info)
+	if copied.Package.Name == "" {
+		copied.Package.Name = info.PackageName
+	}
+	if copied.Package.Versions == nil {
+		copied.Package.Versions = make(map[string]*composer_crawler.Version)
+	}
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.packages[strings.ToLower(copied.Package.Name)] = copied
+	x.recordChange(response.ChangeActionUpdate, copied.Package.Name)
+}
+
+// AddVersion 给包添加或者替换一个版本，包不存在的时候会自动创建，会记录一条 update 的 change
+func (x *Server) AddVersion(packageName string, version *composer_crawler.Version) {
+	copied := &composer_crawler.Version{}
+	copyJSON(version, copied)
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	info, ok := x.packages[strings.ToLower(packageName)]
+	if !ok {
+		info = NewPackage(packageName)
+		x.packages[strings.ToLower(packageName)] = info
+	}
+	info.Package.Versions[copied.Version] = copied
+	x.recordChange(response.ChangeActionUpdate, info.Package.Name)
+}
+
+// RemovePackage 删除一个包，会记录一条 delete 的 change
+func (x *Server) RemovePackage(packageName string) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	key := strings.ToLower(packageName)
+	if info, ok := x.packages[key]; ok {
+		packageName = info.Package.Name
+	}
+	delete(x.packages, key)
+	delete(x.packageStatistics, key)
+	x.recordChange(response.ChangeActionDelete, packageName)
+}
+
+// SetPackageStatistics 设置 stats.json 返回的下载统计，没有设置的时候使用包本身的下载量
+func (x *Server) SetPackageStatistics(packageName string, statistics *response.PackageStatisticsResponse) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.packageStatistics[strings.ToLower(packageName)] = statistics
+}
+
+// AddAdvisory 添加一条安全公告，PackageName 是必须的。AdvisoryID 相同的公告会被替换，用来模拟公告被修改，
+// 更新时间是 Options.Now，和 Packagist 一样 updatedSince 按更新时间而不是报告时间过滤
+func (x *Server) AddAdvisory(advisory *response.Advisory) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	key := strings.ToLower(advisory.PackageName)
+	stored := &storedAdvisory{advisory: advisory, updatedAt: x.options.Now()}
+	for i, existing := range x.advisories[key] {
+		if existing.advisory.AdvisoryID == advisory.AdvisoryID {
+			x.advisories[key][i] = stored
+			return
+		}
+	}
+	x.advisories[key] = append(x.advisories[key], stored)
+}
+
+// SetStatistics 设置 statistics.json 返回的统计数据，为空的时候根据仓库中的包计算
+func (x *Server) SetStatistics(totals *response.Totals) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.statistics = totals
+}
+
+// TruncateChanges 丢弃已经记录的 changes，之后使用更早的时间戳请求 changes 会收到 resync，
+// 用来模拟时间戳太旧需要重新全量同步的情况
+func (x *Server) TruncateChanges() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.changes = nil
+	x.resyncBefore = x.timestamp()
+}
+
+// Timestamp 返回 changes 当前的时间戳，单位是 1/10000 秒
+func (x *Server) Timestamp() int64 {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.timestamp()
+}
+
+// HandleFunc 注册自定义的路由，优先于内置的路由，故障注入和限流同样对它生效
+func (x *Server) HandleFunc(path string, handler http.HandlerFunc) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.handlers[path] = handler
+}
+
+// Requests 返回收到的所有请求的 URI（路径加上查询参数），按收到的顺序排列
+func (x *Server) Requests() []string {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return append([]string{}, x.requests...)
+}
+
+// 需要持有锁
+func (x *Server) recordChange(actionType response.ChangeActionType, packageName string) {
+	timestamp := x.timestamp()
+	x.changes = append(x.changes, &change{
+		action:    &response.ChangeAction{Type: actionType, Package: packageName, Time: timestamp / 10000},
+		timestamp: timestamp,
+	})
+}
+
+// 时间戳是严格递增的，即使时钟没有走，两次变化也不会拿到相同的时间戳
+func (x *Server) timestamp() int64 {
+	timestamp := x.options.Now().UnixNano() / int64(100*time.Microsecond)
+	if timestamp <= x.lastTimestamp {
+		timestamp = x.lastTimestamp + 1
+	}
+	x.lastTimestamp = timestamp
+	return timestamp
+}
+
+// 需要持有锁
+func (x *Server) sortedPackageNames() []string {
+	names := make([]string, 0, len(x.packages))
+	for _, info := range x.packages {
+		names = append(names, info.Package.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 保存一份副本，测试中之后对原来的对象的修改不会影响到仓库
+func copyPackage(info *composer_crawler.ComposerPackageInfo) *composer_crawler.ComposerPackageInfo {
+	copied := &composer_crawler.ComposerPackageInfo{}
+	copyJSON(info, copied)
+	return copied
+}
+
+func copyJSON(from, to interface{}) {
+	data, err := json.Marshal(from)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,163 @@
+package packagisttest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Packages(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	ctx := context.Background()
+
+	monolog := NewPackage("monolog/monolog", "3.5.0", "2.9.2")
+	monolog.Package.Downloads.Total = 900
+	monolog.Package.Downloads.Monthly = 90
+	server.AddPackage(monolog)
+	server.AddVersion("symfony/console", NewVersion("symfony/console", "v6.4.0"))
+
+	// Changes to the original object after adding do not leak into the server
+	monolog.Package.Description = "changed"
+
+	packages, err := repo.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*repository.Package{{Name: "monolog/monolog"}, {Name: "symfony/console"}}, packages)
+
+	info, err := repo.GetPackage(ctx, "Monolog/Monolog")
+	assert.NoError(t, err)
+	assert.Equal(t, "monolog/monolog", info.PackageName)
+	assert.Equal(t, "", info.Package.Description)
+	assert.Len(t, info.Package.Versions, 2)
+	assert.Equal(t, "zip", info.Package.Versions["3.5.0"].Dist.Type)
+
+	_, err = repo.GetPackage(ctx, "not/exists")
+	assert.True(t, errors.Is(err, repository.ErrPackageNotFound))
+
+	statistics, err := repo.Statistics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, response.Totals{Downloads: 900, Packages: 2, Versions: 3}, statistics.Totals)
+
+	server.SetStatistics(&response.Totals{Downloads: 1, Packages: 2, Versions: 3})
+	statistics, err = repo.Statistics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), statistics.Totals.Downloads)
+
+	packageStatistics, err := repo.GetPackageStatistics(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(90), packageStatistics.Downloads.Monthly)
+	assert.Equal(t, []string{"2.9.2", "3.5.0"}, packageStatistics.Versions)
+
+	server.SetPackageStatistics("monolog/monolog", &response.PackageStatisticsResponse{Downloads: response.PackageDownloads{Daily: 7}})
+	packageStatistics, err = repo.GetPackageStatistics(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), packageStatistics.Downloads.Daily)
+
+	server.RemovePackage("monolog/monolog")
+	_, err = repo.GetPackageStatistics(ctx, "monolog/monolog")
+	assert.True(t, errors.Is(err, repository.ErrPackageNotFound))
+}
+
+func TestServer_Changes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := NewServer(&Options{Now: func() time.Time { return now }})
+	defer server.Close()
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	ctx := context.Background()
+
+	// Without since the server answers with the current timestamp
+	changes, err := repo.ListChanges(ctx, 0)
+	assert.True(t, errors.Is(err, repository.ErrInvalidChangesSince))
+	since := changes.Timestamp
+	assert.Equal(t, now.Unix()*10000, since)
+
+	server.AddPackage(NewPackage("a/a", "1.0.0"))
+	server.AddVersion("a/a", NewVersion("a/a", "1.1.0"))
+	server.RemovePackage("b/b")
+
+	changes, err = repo.ListChanges(ctx, since)
+	assert.NoError(t, err)
+	assert.Equal(t, []*response.ChangeAction{
+		{Type: response.ChangeActionUpdate, Package: "a/a", Time: now.Unix()},
+		{Type: response.ChangeActionUpdate, Package: "a/a", Time: now.Unix()},
+		{Type: response.ChangeActionDelete, Package: "b/b", Time: now.Unix()},
+	}, changes.Actions)
+	// Timestamps keep increasing even when the clock stands still
+	assert.Greater(t, changes.Timestamp, since)
+
+	latest := changes.Timestamp
+	changes, err = repo.ListChanges(ctx, latest)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Actions)
+
+	server.TruncateChanges()
+	changes, err = repo.ListChanges(ctx, latest)
+	assert.NoError(t, err)
+	if assert.Len(t, changes.Actions, 1) {
+		assert.Equal(t, response.ChangeActionResync, changes.Actions[0].Type)
+	}
+	changes, err = repo.ListChanges(ctx, server.Timestamp())
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Actions)
+}
+
+func TestServer_Advisories(t *testing.T) {
+	now := time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := NewServer(&Options{Now: func() time.Time { return now }})
+	defer server.Close()
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+	ctx := context.Background()
+
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-2", PackageName: "symfony/http-kernel", ReportedAt: "2012-01-01 00:00:00"})
+	now = time.Date(2023, 2, 1, 8, 0, 0, 0, time.UTC)
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-1", PackageName: "symfony/http-kernel", ReportedAt: "2023-02-01 08:00:00", Severity: response.SeverityMedium})
+	now = time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-3", PackageName: "guzzlehttp/guzzle", ReportedAt: "2023-05-01 00:00:00"})
+
+	advisories, err := repo.ListAdvisories(ctx, "symfony/http-kernel")
+	assert.NoError(t, err)
+	assert.Len(t, advisories, 2)
+
+	result, err := repo.ListAdvisoriesForPackages(ctx, []string{"symfony/http-kernel", "guzzlehttp/guzzle", "other/package"})
+	assert.NoError(t, err)
+	assert.Len(t, result.Advisories, 2)
+
+	result, err = repo.ListSecurityAdvisories(ctx, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, result.Advisories["symfony/http-kernel"], 1)
+	assert.Len(t, result.Advisories["guzzlehttp/guzzle"], 1)
+
+	// updatedSince filters on the update time: an old advisory that changed is returned again, replacing the old copy
+	now = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-2", PackageName: "symfony/http-kernel", ReportedAt: "2012-01-01 00:00:00", AffectedVersions: "<2.0"})
+	result, err = repo.ListSecurityAdvisories(ctx, time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	if assert.Len(t, result.Advisories["symfony/http-kernel"], 1) {
+		assert.Equal(t, "<2.0", result.Advisories["symfony/http-kernel"][0].AffectedVersions)
+	}
+	assert.Empty(t, result.Advisories["guzzlehttp/guzzle"])
+	advisories, err = repo.ListAdvisories(ctx, "symfony/http-kernel")
+	assert.NoError(t, err)
+	assert.Len(t, advisories, 2)
+
+	// updatedSince is in seconds, a millisecond timestamp lies far in the future and matches nothing
+	_, body := get(t, server, "/api/security-advisories/?updatedSince="+strconv.FormatInt(time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(), 10), nil)
+	assert.JSONEq(t, `{"advisories": {}}`, body)
+}
+
+func TestServer_Requests(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL})
+
+	_, _ = repo.List(context.Background())
+	_, _ = repo.ListAdvisoriesForPackages(context.Background(), []string{"a/b"})
+	assert.Equal(t, []string{"/packages/list.json", "/api/security-advisories/?packages%5B%5D=a%2Fb"}, server.Requests())
+}
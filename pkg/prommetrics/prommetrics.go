@@ -0,0 +1,116 @@
+package prommetrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+)
+
+// DefaultNamespace 指标名字的默认前缀
+const DefaultNamespace = "composer_crawler"
+
+// DefaultBuckets 请求耗时直方图默认的桶，单位是秒，大的包的元数据和 dist 可能需要好几秒
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Options Prometheus 指标的配置
+type Options struct {
+
+	// 指标名字的前缀，为空的时候使用 DefaultNamespace
+	Namespace string
+
+	// 注册指标的 Registerer，为空的时候使用 prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+
+	// 请求耗时直方图的桶，为空的时候使用 DefaultBuckets
+	Buckets []float64
+}
+
+// Metrics 把仓库请求的指标导出到 Prometheus，实现了 repository.Metrics，所有的指标都带着 operation 标签（Repository 的方法名）：
+//
+//	composer_crawler_requests_total{operation,method,status}    请求数，没有拿到响应的请求 status 是 error
+//	composer_crawler_request_duration_seconds{operation}       请求耗时
+//	composer_crawler_response_bytes_total{operation}           读取的响应体字节数
+//	composer_crawler_cache_requests_total{operation,result}    CDN 或者缓存代理的命中情况，result 是 hit 或者 miss
+//	composer_crawler_retries_total{operation}                  重试次数
+//
+// 缓存命中率可以这样计算：sum(rate(composer_crawler_cache_requests_total{result="hit"}[5m])) / sum(rate(composer_crawler_cache_requests_total[5m]))
+type Metrics struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	bytes     *prometheus.CounterVec
+	cache     *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+}
+
+var _ repository.Metrics = (*Metrics)(nil)
+
+// New 创建并注册指标，同一个 Registerer 上重复注册会返回错误
+func New(options *Options) (*Metrics, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	if options.Namespace == "" {
+		options.Namespace = DefaultNamespace
+	}
+	if options.Registerer == nil {
+		options.Registerer = prometheus.DefaultRegisterer
+	}
+	if len(options.Buckets) == 0 {
+		options.Buckets = DefaultBuckets
+	}
+
+	x := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests sent to the composer repository, retries included.",
+		}, []string{"operation", "method", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: options.Namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time from sending a request until its response body was read.",
+			Buckets:   options.Buckets,
+		}, []string{"operation"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "response_bytes_total",
+			Help:      "Bytes of response bodies read from the composer repository.",
+		}, []string{"operation"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "cache_requests_total",
+			Help:      "Responses that reported a cache status, by result.",
+		}, []string{"operation", "result"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Name:      "retries_total",
+			Help:      "Number of retried requests.",
+		}, []string{"operation"}),
+	}
+	for _, collector := range []prometheus.Collector{x.requests, x.durations, x.bytes, x.cache, x.retries} {
+		if err := options.Registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// ObserveRequest 实现 repository.Metrics
+func (x *Metrics) ObserveRequest(observation *repository.RequestObservation) {
+	status := "error"
+	if observation.StatusCode != 0 {
+		status = strconv.Itoa(observation.StatusCode)
+	}
+	x.requests.WithLabelValues(observation.Operation, observation.Method, status).Inc()
+	x.durations.WithLabelValues(observation.Operation).Observe(observation.Duration.Seconds())
+	x.bytes.WithLabelValues(observation.Operation).Add(float64(observation.BytesReceived))
+	if observation.Cache != repository.CacheUnknown {
+		x.cache.WithLabelValues(observation.Operation, string(observation.Cache)).Inc()
+	}
+}
+
+// ObserveRetry 实现 repository.Metrics
+func (x *Metrics) ObserveRetry(operation string) {
+	x.retries.WithLabelValues(operation).Inc()
+}
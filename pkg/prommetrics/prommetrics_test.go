@@ -0,0 +1,81 @@
+package prommetrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/scagogogo/composer-crawler/pkg/packagisttest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	server := packagisttest.NewServer(nil)
+	defer server.Close()
+	server.AddPackage(packagisttest.NewPackage("monolog/monolog", "3.5.0"))
+	server.HandleFunc("/statistics.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "MISS, HIT")
+		w.Write([]byte(`{"totals": {"downloads": 1}}`))
+	})
+
+	registry := prometheus.NewRegistry()
+	metrics, err := New(&Options{Registerer: registry})
+	assert.NoError(t, err)
+	repo := repository.NewRepository(&repository.Options{ServerUrl: server.URL, Metrics: metrics, RetryDelay: time.Millisecond})
+	ctx := context.Background()
+
+	_, err = repo.GetPackage(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	_, err = repo.Statistics(ctx)
+	assert.NoError(t, err)
+	// The first two tries fail and the third succeeds
+	server.InjectFault(&packagisttest.Fault{PathPrefix: "/packages/list.json", StatusCode: http.StatusServiceUnavailable, Times: 2})
+	_, err = repo.List(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("GetPackage", "GET", "200")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.requests.WithLabelValues("List", "GET", "503")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("List", "GET", "200")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.retries.WithLabelValues("List")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.cache.WithLabelValues("Statistics", "hit")))
+	assert.Greater(t, testutil.ToFloat64(metrics.bytes.WithLabelValues("GetPackage")), float64(100))
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics.durations))
+
+	expected := `
+# HELP composer_crawler_retries_total Number of retried requests.
+# TYPE composer_crawler_retries_total counter
+composer_crawler_retries_total{operation="List"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "composer_crawler_retries_total"))
+}
+
+func TestMetrics_TransportError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := New(&Options{Registerer: registry, Namespace: "mirror"})
+	assert.NoError(t, err)
+	repo := repository.NewRepository(&repository.Options{
+		ServerUrl: "http://127.0.0.1:1",
+		Metrics:   metrics,
+		MaxTries:  1,
+	})
+	_, err = repo.Statistics(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("Statistics", "GET", "error")))
+
+	count, err := testutil.GatherAndCount(registry, "mirror_requests_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestNew_AlreadyRegistered(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := New(&Options{Registerer: registry})
+	assert.NoError(t, err)
+	_, err = New(&Options{Registerer: registry})
+	assert.Error(t, err)
+}
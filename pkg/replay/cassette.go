@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// CassetteVersion 夹具文件格式的版本
+const CassetteVersion = 1
+
+// Cassette 是一个夹具文件，按顺序保存录制的请求和响应
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 表示录制的一次请求和它的响应
+type Interaction struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+
+	// 回放的时候是否已经被使用过
+	used bool
+}
+
+// Request 录制的请求，回放的时候按照 Method 和 URL 匹配
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// Response 录制的响应
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+
+	// 文本的响应体原样保存，方便在代码评审中查看，二进制的响应体保存在 BodyBase64 中
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"body_base64,omitempty"`
+}
+
+// SetBody 设置响应体，自动选择文本还是 base64
+func (x *Response) SetBody(body []byte) {
+	if utf8.Valid(body) {
+		x.Body, x.BodyBase64 = string(body), ""
+	} else {
+		x.Body, x.BodyBase64 = "", base64.StdEncoding.EncodeToString(body)
+	}
+}
+
+// GetBody 返回响应体
+func (x *Response) GetBody() ([]byte, error) {
+	if x.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(x.BodyBase64)
+	}
+	return []byte(x.Body), nil
+}
+
+// ReadCassette 读取夹具文件
+func ReadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// WriteCassette 写入夹具文件，目录不存在的时候会自动创建
+func WriteCassette(path string, cassette *Cassette) error {
+	if cassette.Version == 0 {
+		cassette.Version = CassetteVersion
+	}
+	// 版本约束中的 <、> 不转义，夹具文件更容易阅读
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cassette); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
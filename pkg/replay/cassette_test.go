@@ -0,0 +1,47 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_Body(t *testing.T) {
+	response := &Response{}
+	response.SetBody([]byte(`{"require": {"php": ">=8.1"}}`))
+	assert.Equal(t, `{"require": {"php": ">=8.1"}}`, response.Body)
+	assert.Empty(t, response.BodyBase64)
+
+	binary := []byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe}
+	response.SetBody(binary)
+	assert.Empty(t, response.Body)
+	assert.Equal(t, "UEsDBP/+", response.BodyBase64)
+	body, err := response.GetBody()
+	assert.NoError(t, err)
+	assert.Equal(t, binary, body)
+}
+
+func TestWriteCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "cassette.json")
+	response := &Response{StatusCode: 200}
+	response.SetBody([]byte(`{"php": ">=8.1 <9.0 & more"}`))
+	assert.NoError(t, WriteCassette(path, &Cassette{Interactions: []*Interaction{
+		{Request: &Request{Method: "GET", URL: "https://packagist.org/packages/a/b.json"}, Response: response},
+	}}))
+
+	// Version constraints stay readable in the fixture
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `>=8.1 <9.0 & more`)
+
+	cassette, err := ReadCassette(path)
+	assert.NoError(t, err)
+	assert.Equal(t, CassetteVersion, cassette.Version)
+	assert.Equal(t, "https://packagist.org/packages/a/b.json", cassette.Interactions[0].Request.URL)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = ReadCassette(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,216 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mode 表示 Transport 是在录制还是在回放
+type Mode string
+
+const (
+	// ModeRecord 把真实的请求发出去，同时把响应录制下来，Save 的时候写入夹具文件
+	ModeRecord Mode = "record"
+
+	// ModeReplay 只从夹具文件中回放，不会发出任何真实的请求
+	ModeReplay Mode = "replay"
+)
+
+// ErrUnexpectedRequest 回放的时候遇到了夹具文件中没有录制的请求
+var ErrUnexpectedRequest = errors.New("unexpected request")
+
+// ModeFromEnv 根据环境变量决定模式，环境变量的值是 record 的时候录制，否则回放，
+// 这样平时的测试都是离线的，需要更新夹具的时候设置一下环境变量重新跑一遍就可以了
+func ModeFromEnv(name string) Mode {
+	if strings.EqualFold(os.Getenv(name), string(ModeRecord)) {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Options 录制回放的配置
+type Options struct {
+
+	// 录制还是回放，为空的时候是回放
+	Mode Mode
+
+	// 夹具文件的路径，必填
+	Path string
+
+	// 录制的时候真正发出请求使用的 Transport，为空的时候使用 http.DefaultTransport
+	Transport http.RoundTripper
+
+	// 录制的时候需要替换掉的请求头和响应头，为空的时候使用 DefaultSecretHeaders
+	SecretHeaders []string
+
+	// 录制的时候需要替换掉的查询参数，为空的时候使用 DefaultSecretQueryParameters
+	SecretQueryParameters []string
+
+	// 保存之前对每个录制的请求做额外的处理，比如替换掉响应体中的敏感信息
+	Scrub func(interaction *Interaction)
+}
+
+// Transport 是一个可以录制和回放的 http.RoundTripper，设置到 repository.Options 的 Transport 中使用
+type Transport struct {
+	options *Options
+
+	lock     sync.Mutex
+	cassette *Cassette
+}
+
+// NewTransport 创建录制回放的 Transport，回放模式下会立即读取夹具文件
+func NewTransport(options *Options) (*Transport, error) {
+	if options == nil || options.Path == "" {
+		return nil, errors.New("replay: fixture path is required")
+	}
+	if options.Mode == "" {
+		options.Mode = ModeReplay
+	}
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	if options.SecretHeaders == nil {
+		options.SecretHeaders = DefaultSecretHeaders
+	}
+	if options.SecretQueryParameters == nil {
+		options.SecretQueryParameters = DefaultSecretQueryParameters
+	}
+
+	x := &Transport{options: options, cassette: &Cassette{Version: CassetteVersion}}
+	switch options.Mode {
+	case ModeRecord:
+	case ModeReplay:
+		cassette, err := ReadCassette(options.Path)
+		if err != nil {
+			return nil, fmt.Errorf("replay: read fixture: %w", err)
+		}
+		x.cassette = cassette
+	default:
+		return nil, fmt.Errorf("replay: unknown mode %q", options.Mode)
+	}
+	return x, nil
+}
+
+// Mode 返回当前的模式
+func (x *Transport) Mode() Mode {
+	return x.options.Mode
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (x *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if x.options.Mode == ModeRecord {
+		return x.record(request)
+	}
+	return x.replay(request)
+}
+
+func (x *Transport) record(request *http.Request) (*http.Response, error) {
+	response, err := x.options.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := &Interaction{
+		Request: &Request{
+			Method: request.Method,
+			URL:    scrubURL(request.URL.String(), x.options.SecretQueryParameters),
+			Header: scrubHeader(request.Header, x.options.SecretHeaders),
+		},
+		Response: &Response{
+			StatusCode: response.StatusCode,
+			Header:     scrubHeader(response.Header, x.options.SecretHeaders),
+		},
+	}
+	interaction.Response.SetBody(body)
+	if x.options.Scrub != nil {
+		x.options.Scrub(interaction)
+	}
+
+	x.lock.Lock()
+	x.cassette.Interactions = append(x.cassette.Interactions, interaction)
+	x.lock.Unlock()
+	return response, nil
+}
+
+// 按录制的顺序回放同一个请求的响应，全部用完之后一直返回最后一个
+func (x *Transport) replay(request *http.Request) (*http.Response, error) {
+	method := request.Method
+	targetUrl := scrubURL(request.URL.String(), x.options.SecretQueryParameters)
+
+	x.lock.Lock()
+	var matched *Interaction
+	for _, interaction := range x.cassette.Interactions {
+		if interaction.Request.Method != method || interaction.Request.URL != targetUrl {
+			continue
+		}
+		matched = interaction
+		if !interaction.used {
+			break
+		}
+	}
+	if matched != nil {
+		matched.used = true
+	}
+	x.lock.Unlock()
+
+	if matched == nil {
+		return nil, fmt.Errorf("replay: %w: %s %s is not in %s", ErrUnexpectedRequest, method, targetUrl, x.options.Path)
+	}
+	body, err := matched.Response.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("replay: decode body of %s %s: %w", method, targetUrl, err)
+	}
+	header := matched.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// Scrub 可能修改过响应体，长度以实际的响应体为准
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        strconv.Itoa(matched.Response.StatusCode) + " " + http.StatusText(matched.Response.StatusCode),
+		StatusCode:    matched.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+// Unused 返回回放的时候没有被使用过的录制，可以用来检查夹具文件中是否有多余的内容
+func (x *Transport) Unused() []*Interaction {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	unused := make([]*Interaction, 0)
+	for _, interaction := range x.cassette.Interactions {
+		if !interaction.used {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// Save 录制模式下把录制的内容写入夹具文件，回放模式下什么也不做
+func (x *Transport) Save() error {
+	if x.options.Mode != ModeRecord {
+		return nil
+	}
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return WriteCassette(x.options.Path, x.cassette)
+}
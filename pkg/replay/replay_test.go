@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/packagisttest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_RecordAndReplay(t *testing.T) {
+	server := packagisttest.NewServer(nil)
+	server.AddPackage(packagisttest.NewPackage("monolog/monolog", "3.5.0"))
+	server.AddAdvisory(&response.Advisory{AdvisoryID: "PKSA-1", PackageName: "monolog/monolog", AffectedVersions: ">=1.0,<1.1"})
+	path := filepath.Join(t.TempDir(), "fixtures", "monolog.json")
+	ctx := context.Background()
+
+	recorder, err := NewTransport(&Options{Mode: ModeRecord, Path: path})
+	assert.NoError(t, err)
+	repo := repository.NewRepository(&repository.Options{
+		ServerUrl: server.URL,
+		Transport: recorder,
+		Auth:      &repository.Auth{Bearer: map[string]string{hostOf(server.URL): "secret-token"}},
+	})
+	info, err := repo.GetPackage(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Equal(t, "monolog/monolog", info.PackageName)
+	advisories, err := repo.ListAdvisories(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Len(t, advisories, 1)
+	assert.NoError(t, recorder.Save())
+	server.Close()
+
+	// The secret never reaches the fixture
+	cassette, err := ReadCassette(path)
+	assert.NoError(t, err)
+	if assert.Len(t, cassette.Interactions, 2) {
+		assert.Equal(t, Redacted, cassette.Interactions[0].Request.Header.Get("Authorization"))
+		assert.Equal(t, "/api/security-advisories/?packages=monolog/monolog", strings.TrimPrefix(cassette.Interactions[1].Request.URL, server.URL))
+		assert.Contains(t, cassette.Interactions[1].Response.Body, `"advisoryId":"PKSA-1"`)
+	}
+
+	// Replaying works with the server gone
+	player, err := NewTransport(&Options{Path: path})
+	assert.NoError(t, err)
+	assert.Equal(t, ModeReplay, player.Mode())
+	repo = repository.NewRepository(&repository.Options{ServerUrl: server.URL, Transport: player, RetryDelay: time.Millisecond})
+	advisories, err = repo.ListAdvisories(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Len(t, advisories, 1)
+	assert.Len(t, player.Unused(), 1)
+	info, err = repo.GetPackage(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	assert.Equal(t, "zip", info.Package.Versions["3.5.0"].Dist.Type)
+	assert.Empty(t, player.Unused())
+
+	// Requests that were not recorded fail
+	_, err = repo.GetPackage(ctx, "symfony/console")
+	assert.True(t, errors.Is(err, ErrUnexpectedRequest))
+	assert.NoError(t, player.Save())
+}
+
+func TestTransport_ReplayOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.json")
+	assert.NoError(t, WriteCassette(path, &Cassette{Interactions: []*Interaction{
+		{Request: &Request{Method: http.MethodGet, URL: "https://packagist.org/statistics.json"}, Response: &Response{StatusCode: http.StatusServiceUnavailable}},
+		{Request: &Request{Method: http.MethodGet, URL: "https://packagist.org/statistics.json"}, Response: &Response{StatusCode: http.StatusOK, Body: `{"totals": {"downloads": 1}}`}},
+	}}))
+	player, err := NewTransport(&Options{Path: path})
+	assert.NoError(t, err)
+
+	// The same request gets the recorded responses in order, then the last one repeats
+	for _, expected := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		request, _ := http.NewRequest(http.MethodGet, "https://packagist.org/statistics.json", nil)
+		response, err := player.RoundTrip(request)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, response.StatusCode)
+	}
+
+	// The repository client retries the 503 and gets the recorded success
+	player, err = NewTransport(&Options{Path: path})
+	assert.NoError(t, err)
+	repo := repository.NewRepository(&repository.Options{Transport: player, RetryDelay: time.Millisecond})
+	statistics, err := repo.Statistics(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), statistics.Totals.Downloads)
+}
+
+func TestNewTransport(t *testing.T) {
+	_, err := NewTransport(nil)
+	assert.EqualError(t, err, "replay: fixture path is required")
+
+	_, err = NewTransport(&Options{Path: filepath.Join(t.TempDir(), "missing.json")})
+	assert.ErrorContains(t, err, "replay: read fixture")
+
+	_, err = NewTransport(&Options{Path: "x.json", Mode: "live"})
+	assert.EqualError(t, err, `replay: unknown mode "live"`)
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv("REPLAY_TEST_MODE", "RECORD")
+	assert.Equal(t, ModeRecord, ModeFromEnv("REPLAY_TEST_MODE"))
+	t.Setenv("REPLAY_TEST_MODE", "")
+	assert.Equal(t, ModeReplay, ModeFromEnv("REPLAY_TEST_MODE"))
+}
+
+func hostOf(rawUrl string) string {
+	return strings.TrimPrefix(rawUrl, "http://")
+}
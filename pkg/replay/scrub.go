@@ -0,0 +1,77 @@
+package replay
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted 替换掉敏感信息之后的值
+const Redacted = "[REDACTED]"
+
+// DefaultSecretHeaders 默认会被替换掉的请求头和响应头
+var DefaultSecretHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"Private-Token",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// DefaultSecretQueryParameters 默认会被替换掉的查询参数，不区分大小写
+var DefaultSecretQueryParameters = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"key",
+	"password",
+	"secret",
+	"token",
+}
+
+// 去掉 URL 中的账号密码，替换掉敏感的查询参数
+func scrubURL(rawUrl string, secretQueryParameters []string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	u.User = nil
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	// 逐个参数处理，保持参数原来的顺序和编码，这样回放的时候才能匹配上
+	parameters := strings.Split(u.RawQuery, "&")
+	for i, parameter := range parameters {
+		name := parameter
+		if index := strings.IndexByte(parameter, '='); index >= 0 {
+			name = parameter[:index]
+		}
+		decoded, err := url.QueryUnescape(name)
+		if err != nil {
+			continue
+		}
+		for _, secret := range secretQueryParameters {
+			if strings.EqualFold(decoded, secret) {
+				parameters[i] = name + "=" + url.QueryEscape(Redacted)
+				break
+			}
+		}
+	}
+	u.RawQuery = strings.Join(parameters, "&")
+	return u.String()
+}
+
+func scrubHeader(header http.Header, secretHeaders []string) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	scrubbed := header.Clone()
+	for _, name := range secretHeaders {
+		if _, ok := scrubbed[http.CanonicalHeaderKey(name)]; ok {
+			scrubbed.Set(name, Redacted)
+		}
+	}
+	return scrubbed
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+)
+
+// Auth 表示访问私有仓库以及下载 dist 时使用的认证信息，格式与 composer 的 auth.json 相同，key 都是域名
+// https://getcomposer.org/doc/articles/authentication-for-private-packages.md
+type Auth struct {
+	HttpBasic   map[string]*manifest.HttpBasic `json:"http-basic,omitempty"`
+	Bearer      map[string]string              `json:"bearer,omitempty"`
+	GithubOauth map[string]string              `json:"github-oauth,omitempty"`
+	GitlabOauth map[string]string              `json:"gitlab-oauth,omitempty"`
+	GitlabToken map[string]GitlabToken         `json:"gitlab-token,omitempty"`
+}
+
+// GitlabToken 表示 gitlab 的 private token，auth.json 中可能是字符串，也可能是 {"username": "...", "token": "..."}
+type GitlabToken string
+
+func (x *GitlabToken) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var token struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(data, &token); err != nil {
+			return err
+		}
+		*x = GitlabToken(token.Token)
+		return nil
+	}
+	var token string
+	if err := json.Unmarshal(data, &token); err != nil {
+		return err
+	}
+	*x = GitlabToken(token)
+	return nil
+}
+
+// ParseAuthJSON 解析 auth.json 的内容
+func ParseAuthJSON(data []byte) (*Auth, error) {
+	auth := &Auth{}
+	if err := json.Unmarshal(data, auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// ReadAuthJSON 从文件读取并解析 auth.json
+func ReadAuthJSON(filepath string) (*Auth, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAuthJSON(data)
+}
+
+// 根据请求的域名设置认证信息，找不到对应的认证信息时什么也不做
+func (x *Auth) apply(request *http.Request) {
+	if x == nil {
+		return
+	}
+	host := strings.ToLower(request.URL.Host)
+	hostname := strings.ToLower(request.URL.Hostname())
+	lookup := func(m map[string]string) (string, bool) {
+		for _, key := range []string{host, hostname} {
+			if value, ok := m[key]; ok && value != "" {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	for _, key := range []string{host, hostname} {
+		if basic, ok := x.HttpBasic[key]; ok && basic != nil {
+			request.SetBasicAuth(basic.Username, basic.Password)
+			return
+		}
+	}
+	if token, ok := lookup(x.Bearer); ok {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+
+	// api.github.com、codeload.github.com 使用 github.com 的 token
+	githubHost := hostname
+	if strings.HasSuffix(hostname, ".github.com") {
+		githubHost = "github.com"
+	}
+	if token, ok := x.GithubOauth[githubHost]; ok && token != "" {
+		request.Header.Set("Authorization", "token "+token)
+		return
+	}
+	if token, ok := lookup(x.GitlabOauth); ok {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	for _, key := range []string{host, hostname} {
+		if token, ok := x.GitlabToken[key]; ok && token != "" {
+			request.Header.Set("PRIVATE-TOKEN", string(token))
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func hostOf(rawUrl string) string {
+	u, _ := url.Parse(rawUrl)
+	return u.Host
+}
+
+func TestParseAuthJSON(t *testing.T) {
+	auth, err := ParseAuthJSON([]byte(`{
+		"http-basic": {"repo.example.org": {"username": "user", "password": "secret"}},
+		"bearer": {"private.example.org": "bearer-token"},
+		"github-oauth": {"github.com": "gh-token"},
+		"gitlab-oauth": {"gitlab.com": "gl-oauth"},
+		"gitlab-token": {"gitlab.example.org": "gl-token", "gitlab.corp.org": {"username": "bot", "token": "corp-token"}}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", auth.HttpBasic["repo.example.org"].Password)
+	assert.Equal(t, GitlabToken("corp-token"), auth.GitlabToken["gitlab.corp.org"])
+
+	tests := []struct {
+		url    string
+		header string
+		want   string
+	}{
+		{"https://private.example.org/dists/a.zip", "Authorization", "Bearer bearer-token"},
+		{"https://api.github.com/repos/a/b/zipball/abc", "Authorization", "token gh-token"},
+		{"https://codeload.github.com/a/b/legacy.zip/abc", "Authorization", "token gh-token"},
+		{"https://gitlab.com/api/v4/projects/1/repository/archive.zip", "Authorization", "Bearer gl-oauth"},
+		{"https://gitlab.example.org/api/v4/projects/1/repository/archive.zip", "PRIVATE-TOKEN", "gl-token"},
+		{"https://example.com/a.zip", "Authorization", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			assert.NoError(t, err)
+			auth.apply(request)
+			assert.Equal(t, tt.want, request.Header.Get(tt.header))
+		})
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://repo.example.org/packages.json", nil)
+	auth.apply(request)
+	username, password, ok := request.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	_, err = ParseAuthJSON([]byte(`{"gitlab-token": {"gitlab.com": 1}}`))
+	assert.Error(t, err)
+}
+
+func TestRepository_AuthAppliedToMetadataRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"downloads": {"total": 1}}`))
+	}))
+	defer server.Close()
+
+	repo := NewRepository(&Options{ServerUrl: server.URL, Auth: &Auth{
+		HttpBasic: map[string]*manifest.HttpBasic{hostOf(server.URL): {Username: "user", Password: "secret"}},
+	}})
+	statistics, err := repo.GetPackageStatistics(context.Background(), "acme/private")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), statistics.Downloads.Total)
+}
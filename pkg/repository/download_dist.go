@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrShasumMismatch 下载的 dist 与元数据中的 shasum 不一致，此时数据已经写入了 dst，调用方应该丢弃
+var ErrShasumMismatch = errors.New("dist shasum mismatch")
+
+// ErrUnsupportedDistType 不支持的 dist 类型，目前只支持 zip、tar 和 path
+var ErrUnsupportedDistType = errors.New("unsupported dist type")
+
+// ErrPathDistNotAllowed path 类型的 dist 会读取本地的文件，仓库的元数据不可信，所以默认拒绝，
+// 需要设置 Options.PathDistBaseDir，并且只能读取这个目录下的文件
+var ErrPathDistNotAllowed = errors.New("path dist not allowed")
+
+// 跟随重定向的最大次数
+const maxDistRedirects = 10
+
+// DistDownloadResult 表示一次 dist 下载的结果
+type DistDownloadResult struct {
+	// dist 的类型：zip、tar、path
+	Type string `json:"type"`
+	// 最终下载的地址（跟随重定向之后），path 类型时是本地路径
+	URL string `json:"url"`
+	// 写入 dst 的字节数
+	Size int64 `json:"size"`
+	// 实际下载内容的 SHA-1，元数据中有 shasum 的时候会和它比较
+	SHA1 string `json:"sha1"`
+	// 实际下载内容的 SHA-256
+	SHA256 string `json:"sha256"`
+	// 是否校验过 shasum，元数据中没有 shasum 的时候为 false
+	Verified bool `json:"verified"`
+}
+
+// DownloadDist 下载版本的 dist 并流式写入 dst，会跟随 GitHub/GitLab 的 zipball 重定向，每一跳都按照域名重新设置认证信息，
+// 元数据中有 shasum 的时候会校验 SHA-1，不一致时返回 ErrShasumMismatch，SHA-256 则总是会计算并返回。
+// path 类型只有设置了 Options.PathDistBaseDir 才允许，如果指向的是目录，则会以不压缩的 tar 格式写入 dst
+func (x *Repository) DownloadDist(ctx context.Context, version *composer_crawler.Version, dst io.Writer) (_ *DistDownloadResult, err error) {
+	if version == nil || version.Dist.URL == "" {
+		return nil, fmt.Errorf("%w: version has no dist", ErrUnsupportedDistType)
+	}
+
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	writer := io.MultiWriter(dst, sha1Hash, sha256Hash)
+
+	ctx, finish := x.startOperation(ctx, "DownloadDist", packageAttribute(version.Name),
+		attribute.String("composer.version", version.Version), attribute.String("composer.dist.type", version.Dist.Type))
+	defer func() { finish(err) }()
+
+	result := &DistDownloadResult{Type: version.Dist.Type}
+	switch version.Dist.Type {
+	case "zip", "tar":
+		result.URL, result.Size, err = x.downloadDistURL(ctx, version.Dist.URL, writer)
+	case "path":
+		result.URL = version.Dist.URL
+		var distPath string
+		if distPath, err = x.resolveDistPath(version.Dist.URL); err == nil {
+			result.Size, err = copyDistPath(distPath, writer)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDistType, version.Dist.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.SHA1 = hex.EncodeToString(sha1Hash.Sum(nil))
+	result.SHA256 = hex.EncodeToString(sha256Hash.Sum(nil))
+	if version.Dist.Shasum != "" {
+		result.Verified = true
+		if !strings.EqualFold(version.Dist.Shasum, result.SHA1) {
+			x.log(ctx, slog.LevelWarn, "dist shasum mismatch", slog.String("url", result.URL),
+				slog.String("version", version.Version), slog.String("expected", version.Dist.Shasum), slog.String("actual", result.SHA1))
+			return result, fmt.Errorf("%w: expected %s, got %s", ErrShasumMismatch, version.Dist.Shasum, result.SHA1)
+		}
+	}
+	return result, nil
+}
+
+// 下载远程的 dist，返回最终的地址和写入的字节数
+func (x *Repository) downloadDistURL(ctx context.Context, distUrl string, writer io.Writer) (string, int64, error) {
+	// 私有仓库的 dist 地址可能是相对地址
+	base, err := url.Parse(x.options.ServerUrl)
+	if err != nil {
+		return "", 0, err
+	}
+	target, err := base.Parse(distUrl)
+	if err != nil {
+		return "", 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	x.prepareRequest(request)
+
+	client, err := x.newDistClient()
+	if err != nil {
+		return "", 0, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer response.Body.Close()
+	finalUrl := response.Request.URL.String()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return finalUrl, 0, fmt.Errorf("download dist %s: response status code: %d", finalUrl, response.StatusCode)
+	}
+
+	size, err := io.Copy(writer, response.Body)
+	if err != nil {
+		return finalUrl, size, err
+	}
+	return finalUrl, size, nil
+}
+
+// 下载 dist 使用的客户端，重定向到别的域名的时候不能把原来的认证信息带过去
+func (x *Repository) newDistClient() (*http.Client, error) {
+	transport, err := x.transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= maxDistRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxDistRedirects)
+			}
+			request.Header.Del("Authorization")
+			request.Header.Del("PRIVATE-TOKEN")
+			x.options.Auth.apply(request)
+			return nil
+		},
+	}, nil
+}
+
+// 把 path 类型的 dist 解析为 Options.PathDistBaseDir 下的真实路径，相对路径相对于这个目录，
+// 解析符号链接之后不在这个目录下的路径会被拒绝
+func (x *Repository) resolveDistPath(distPath string) (string, error) {
+	if x.options.PathDistBaseDir == "" {
+		return "", fmt.Errorf("%w: %s, set Options.PathDistBaseDir to allow local paths", ErrPathDistNotAllowed, distPath)
+	}
+	base, err := filepath.Abs(x.options.PathDistBaseDir)
+	if err != nil {
+		return "", err
+	}
+	if base, err = filepath.EvalSymlinks(base); err != nil {
+		return "", err
+	}
+	target := strings.TrimPrefix(distPath, "file://")
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(base, target)
+	}
+	if target, err = filepath.EvalSymlinks(target); err != nil {
+		return "", err
+	}
+	relative, err := filepath.Rel(base, target)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", ErrPathDistNotAllowed, distPath, base)
+	}
+	return target, nil
+}
+
+// 复制 path 类型的 dist，文件直接复制，目录则打包成 tar
+func copyDistPath(path string, writer io.Writer) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		file, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		return io.Copy(writer, file)
+	}
+
+	counter := &countingWriter{writer: writer}
+	tarWriter := tar.NewWriter(counter)
+	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(path, filePath)
+		if err != nil || relative == "." {
+			return err
+		}
+		// 和 composer 一样不打包 VCS 目录
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".svn" || info.Name() == ".hg") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return counter.n, err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return counter.n, err
+	}
+	return counter.n, nil
+}
+
+type countingWriter struct {
+	writer io.Writer
+	n      int64
+}
+
+func (x *countingWriter) Write(p []byte) (int, error) {
+	n, err := x.writer.Write(p)
+	x.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDistVersion(distType, url, shasum string) *composer_crawler.Version {
+	version := &composer_crawler.Version{}
+	version.Dist.Type = distType
+	version.Dist.URL = url
+	version.Dist.Shasum = shasum
+	return version
+}
+
+func sha1Hex(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRepository_DownloadDist(t *testing.T) {
+	content := []byte("PK fake zipball content")
+
+	// codeload serves the archive and only accepts its own credentials
+	codeload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "codeload-token" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(content)
+	}))
+	defer codeload.Close()
+
+	// api answers with a redirect, like GitHub's zipball endpoint
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, codeload.URL+"/legacy.zip/abc", http.StatusFound)
+	}))
+	defer api.Close()
+
+	repo := NewRepository(&Options{
+		ServerUrl: api.URL,
+		Auth: &Auth{
+			Bearer:      map[string]string{hostOf(api.URL): "api-token"},
+			GitlabToken: map[string]GitlabToken{hostOf(codeload.URL): "codeload-token"},
+		},
+	})
+
+	t.Run("follows redirect and verifies shasum", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		result, err := repo.DownloadDist(context.Background(), newDistVersion("zip", api.URL+"/zipball/abc", sha1Hex(content)), buff)
+		assert.NoError(t, err)
+		assert.Equal(t, content, buff.Bytes())
+		assert.Equal(t, codeload.URL+"/legacy.zip/abc", result.URL)
+		assert.Equal(t, int64(len(content)), result.Size)
+		assert.True(t, result.Verified)
+		sum := sha256.Sum256(content)
+		assert.Equal(t, hex.EncodeToString(sum[:]), result.SHA256)
+	})
+
+	t.Run("relative url without shasum", func(t *testing.T) {
+		result, err := repo.DownloadDist(context.Background(), newDistVersion("zip", "/zipball/abc", ""), io.Discard)
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Equal(t, sha1Hex(content), result.SHA1)
+	})
+
+	t.Run("shasum mismatch", func(t *testing.T) {
+		result, err := repo.DownloadDist(context.Background(), newDistVersion("zip", api.URL+"/zipball/abc", "0000000000000000000000000000000000000000"), io.Discard)
+		assert.True(t, errors.Is(err, ErrShasumMismatch))
+		assert.Equal(t, sha1Hex(content), result.SHA1)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := NewRepository(&Options{ServerUrl: api.URL}).DownloadDist(context.Background(), newDistVersion("zip", api.URL+"/zipball/abc", ""), io.Discard)
+		assert.ErrorContains(t, err, "401")
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		_, err := repo.DownloadDist(context.Background(), newDistVersion("phar", api.URL+"/x.phar", ""), io.Discard)
+		assert.True(t, errors.Is(err, ErrUnsupportedDistType))
+	})
+}
+
+func TestRepository_DownloadDist_Path(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"name":"acme/local"}`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src", "Foo.php"), []byte("<?php class Foo {}"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0o644))
+
+	repo := NewRepository(&Options{PathDistBaseDir: dir})
+
+	t.Run("file", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		result, err := repo.DownloadDist(context.Background(), newDistVersion("path", filepath.Join(dir, "composer.json"), ""), buff)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"acme/local"}`, buff.String())
+		assert.Equal(t, int64(buff.Len()), result.Size)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		result, err := repo.DownloadDist(context.Background(), newDistVersion("path", dir, ""), buff)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(buff.Len()), result.Size)
+
+		var names []string
+		reader := tar.NewReader(buff)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			names = append(names, header.Name)
+		}
+		assert.Equal(t, []string{"composer.json", "src/", "src/Foo.php"}, names)
+	})
+
+	t.Run("relative", func(t *testing.T) {
+		buff := &bytes.Buffer{}
+		_, err := repo.DownloadDist(context.Background(), newDistVersion("path", "src/Foo.php", ""), buff)
+		assert.NoError(t, err)
+		assert.Equal(t, "<?php class Foo {}", buff.String())
+	})
+}
+
+func TestRepository_DownloadDist_PathNotAllowed(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "packages")
+	secret := filepath.Join(root, "secret.txt")
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+	assert.NoError(t, os.WriteFile(secret, []byte("secret"), 0o644))
+	assert.NoError(t, os.Symlink(secret, filepath.Join(dir, "link.txt")))
+
+	// Rejected by default
+	_, err := NewRepository(nil).DownloadDist(context.Background(), newDistVersion("path", secret, ""), io.Discard)
+	assert.True(t, errors.Is(err, ErrPathDistNotAllowed))
+
+	repo := NewRepository(&Options{PathDistBaseDir: dir})
+	for _, distPath := range []string{secret, "file://" + secret, "../secret.txt", filepath.Join(dir, "..", "secret.txt"), "link.txt"} {
+		buff := &bytes.Buffer{}
+		_, err := repo.DownloadDist(context.Background(), newDistVersion("path", distPath, ""), buff)
+		assert.True(t, errors.Is(err, ErrPathDistNotAllowed), distPath)
+		assert.Empty(t, buff.String(), distPath)
+	}
+}
+
+func TestRepository_DownloadDist_Transport(t *testing.T) {
+	content := []byte("zip content")
+	requested := make([]string, 0)
+	repo := NewRepository(&Options{Transport: RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requested = append(requested, request.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(content)), Request: request}, nil
+	})})
+
+	buffer := &bytes.Buffer{}
+	result, err := repo.DownloadDist(context.Background(), newDistVersion("zip", "https://dist.example.com/a.zip", sha1Hex(content)), buffer)
+	assert.NoError(t, err)
+	assert.True(t, result.Verified)
+	assert.Equal(t, content, buffer.Bytes())
+	assert.Equal(t, []string{"https://dist.example.com/a.zip"}, requested)
+}
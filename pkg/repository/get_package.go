@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+)
+
+// ErrPackageNotFound 仓库中不存在给定的包
+var ErrPackageNotFound = errors.New("package not found")
+
+// GetPackage 获取包的详细信息，包括所有的版本、维护者以及下载量等
+// https://packagist.org/packages/[vendor]/[package].json
+func (x *Repository) GetPackage(ctx context.Context, packageName string) (_ *composer_crawler.ComposerPackageInfo, err error) {
+	ctx, finish := x.startOperation(ctx, "GetPackage", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
+	targetUrl := fmt.Sprintf("%s/packages/%s.json", x.options.ServerUrl, packageName)
+	info, err := getJson[*composer_crawler.ComposerPackageInfo](ctx, x, targetUrl)
+	if err != nil {
+		return nil, err
+	}
+	// 包不存在的时候返回的是 {"status":"error","message":"Package not found"}
+	if info == nil || info.Package.Name == "" {
+		x.log(ctx, slog.LevelDebug, "package not found", slog.String("url", targetUrl))
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+	}
+	info.PackageName = info.Package.Name
+	info.PackageNameLowercase = strings.ToLower(info.Package.Name)
+	return info, nil
+}
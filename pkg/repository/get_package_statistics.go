@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// GetPackageStatistics 获取单个包的下载统计，包不存在的时候返回 ErrPackageNotFound
+// https://packagist.org/packages/[vendor]/[package]/stats.json
+func (x *Repository) GetPackageStatistics(ctx context.Context, packageName string) (_ *response.PackageStatisticsResponse, err error) {
+	ctx, finish := x.startOperation(ctx, "GetPackageStatistics", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
+	targetUrl := fmt.Sprintf("%s/packages/%s/stats.json", x.options.ServerUrl, packageName)
+	bytes, err := x.getBytes(ctx, targetUrl)
+	if err != nil {
+		return nil, err
+	}
+	// 包不存在的时候返回的是 {"status":"error","message":"Package not found"}
+	status, err := decodeJson[*struct {
+		Status string `json:"status"`
+	}](ctx, x, targetUrl, bytes)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status == "error" {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+	}
+	return decodeJson[*response.PackageStatisticsResponse](ctx, x, targetUrl, bytes)
+}
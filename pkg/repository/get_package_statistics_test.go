@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_GetPackageStatistics(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/packages/monolog/monolog/stats.json": `{
+			"downloads": {"total": 900000000, "monthly": 20000000, "daily": 700000},
+			"versions": ["3.5.0", "2.9.2"],
+			"date": "2011-09-27"
+		}`,
+		"/packages/not/exists/stats.json": `{"status":"error","message":"Package not found"}`,
+	})
+	defer server.Close()
+
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+
+	t.Run("successful request", func(t *testing.T) {
+		statistics, err := repo.GetPackageStatistics(context.Background(), "monolog/monolog")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(900000000), statistics.Downloads.Total)
+		assert.Equal(t, int64(20000000), statistics.Downloads.Monthly)
+		assert.Equal(t, []string{"3.5.0", "2.9.2"}, statistics.Versions)
+	})
+
+	t.Run("package not found", func(t *testing.T) {
+		_, err := repo.GetPackageStatistics(context.Background(), "not/exists")
+		assert.True(t, errors.Is(err, ErrPackageNotFound))
+	})
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_GetPackage(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/packages/Monolog/Monolog.json": `{
+			"package": {
+				"name": "Monolog/Monolog",
+				"description": "Sends your logs to files, sockets, inboxes, databases and various web services",
+				"time": "2011-09-27T00:35:19+00:00",
+				"maintainers": [{"name": "Seldaek", "avatar_url": "https://example.com/avatar.png"}],
+				"versions": {
+					"2.9.1": {
+						"name": "monolog/monolog",
+						"version": "2.9.1",
+						"version_normalized": "2.9.1.0",
+						"license": ["MIT"],
+						"require": {"php": ">=7.2"}
+					}
+				},
+				"type": "library",
+				"repository": "https://github.com/Seldaek/monolog",
+				"downloads": {"total": 100, "monthly": 10, "daily": 1},
+				"favers": 20
+			}
+		}`,
+		"/packages/not/exists.json": `{"status":"error","message":"Package not found"}`,
+	})
+	defer server.Close()
+
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+
+	t.Run("successful request", func(t *testing.T) {
+		info, err := repo.GetPackage(context.Background(), "Monolog/Monolog")
+		assert.NoError(t, err)
+		if assert.NotNil(t, info) {
+			assert.Equal(t, "Monolog/Monolog", info.PackageName)
+			assert.Equal(t, "monolog/monolog", info.PackageNameLowercase)
+			assert.Equal(t, "library", info.Package.Type)
+			assert.Equal(t, 100, info.Package.Downloads.Total)
+			assert.Len(t, info.Package.Maintainers, 1)
+			assert.Equal(t, "2.9.1.0", info.Package.Versions["2.9.1"].VersionNormalized)
+		}
+	})
+
+	t.Run("package not found", func(t *testing.T) {
+		info, err := repo.GetPackage(context.Background(), "not/exists")
+		assert.True(t, errors.Is(err, ErrPackageNotFound))
+		assert.Nil(t, info)
+	})
+
+	t.Run("route not found", func(t *testing.T) {
+		info, err := repo.GetPackage(context.Background(), "other/package")
+		assert.True(t, errors.Is(err, ErrPackageNotFound))
+		assert.Nil(t, info)
+	})
+}
+
+func TestNewRepository(t *testing.T) {
+	assert.Equal(t, DefaultServerUrl, NewRepository(nil).options.ServerUrl)
+	assert.Equal(t, DefaultServerUrl, NewRepository(&Options{Proxy: "http://127.0.0.1:7890"}).options.ServerUrl)
+	assert.Equal(t, "http://localhost", NewRepository(&Options{ServerUrl: "http://localhost"}).options.ServerUrl)
+}
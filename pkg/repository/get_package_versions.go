@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+)
+
+// MinifiedComposer2 Composer v2 元数据压缩格式的标记，每个版本只保存和上一个版本不同的字段
+const MinifiedComposer2 = "composer/2.0"
+
+// 压缩格式中表示这个字段在上一个版本中有，这个版本中没有
+const minifiedUnset = "__unset"
+
+// p2Response 表示 Composer v2 的元数据 p2/[vendor]/[package].json
+type p2Response struct {
+	Packages map[string][]map[string]json.RawMessage `json:"packages"`
+	Minified string                                  `json:"minified"`
+}
+
+// GetPackageVersions 从 Composer v2 的元数据中获取包所有打了标签的版本（包括 alpha、beta、RC），按发布的先后倒序，
+// 分支版本在 GetPackageDevVersions 中，包不存在的时候返回 ErrPackageNotFound
+// https://packagist.org/apidoc#get-package-metadata-v2
+func (x *Repository) GetPackageVersions(ctx context.Context, packageName string) (_ []*composer_crawler.Version, err error) {
+	ctx, finish := x.startOperation(ctx, "GetPackageVersions", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
+	return x.getP2Versions(ctx, packageName, "")
+}
+
+// GetPackageDevVersions 获取包的分支版本，比如 dev-main、2.x-dev，它们在单独的 p2/[vendor]/[package]~dev.json 中
+func (x *Repository) GetPackageDevVersions(ctx context.Context, packageName string) (_ []*composer_crawler.Version, err error) {
+	ctx, finish := x.startOperation(ctx, "GetPackageDevVersions", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
+	return x.getP2Versions(ctx, packageName, "~dev")
+}
+
+// GetPackageAllVersions 获取包打了标签的版本和分支版本，打了标签的版本在前。
+// 有的镜像没有 ~dev.json，这种情况下只返回打了标签的版本
+func (x *Repository) GetPackageAllVersions(ctx context.Context, packageName string) (_ []*composer_crawler.Version, err error) {
+	ctx, finish := x.startOperation(ctx, "GetPackageAllVersions", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
+	versions, err := x.GetPackageVersions(ctx, packageName)
+	if err != nil {
+		return nil, err
+	}
+	devVersions, err := x.GetPackageDevVersions(ctx, packageName)
+	if err != nil && !errors.Is(err, ErrPackageNotFound) {
+		return nil, err
+	}
+	return append(versions, devVersions...), nil
+}
+
+func (x *Repository) getP2Versions(ctx context.Context, packageName, suffix string) ([]*composer_crawler.Version, error) {
+	// p2 的地址和 key 都是小写的包名
+	packageName = strings.ToLower(packageName)
+	targetUrl := fmt.Sprintf("%s/p2/%s%s.json", x.options.ServerUrl, packageName, suffix)
+	metadata, err := getJson[*p2Response](ctx, x, targetUrl)
+	if err != nil {
+		return nil, err
+	}
+	// 包不存在的时候返回的是 404 以及 {"status":"error","message":"Not Found"}
+	rawVersions, ok := metadata.Packages[packageName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+	}
+	if metadata.Minified == MinifiedComposer2 {
+		rawVersions = ExpandMinifiedVersions(rawVersions)
+	}
+
+	versions := make([]*composer_crawler.Version, 0, len(rawVersions))
+	for _, rawVersion := range rawVersions {
+		bytes, err := json.Marshal(rawVersion)
+		if err != nil {
+			return nil, err
+		}
+		version, err := decodeJson[*composer_crawler.Version](ctx, x, targetUrl, bytes)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// ExpandMinifiedVersions 还原 composer/2.0 压缩格式的版本列表：每个版本继承上一个版本的所有字段，
+// 值为 "__unset" 的字段表示删除。返回的每个版本都是新的 map，不会修改传入的参数
+func ExpandMinifiedVersions(versions []map[string]json.RawMessage) []map[string]json.RawMessage {
+	expanded := make([]map[string]json.RawMessage, 0, len(versions))
+	var previous map[string]json.RawMessage
+	for _, version := range versions {
+		current := make(map[string]json.RawMessage, len(previous)+len(version))
+		for key, value := range previous {
+			current[key] = value
+		}
+		for key, value := range version {
+			if string(value) == `"`+minifiedUnset+`"` {
+				delete(current, key)
+			} else {
+				current[key] = value
+			}
+		}
+		expanded = append(expanded, current)
+		previous = current
+	}
+	return expanded
+}
+
+// MinifyVersions 把版本列表压缩为 composer/2.0 格式，和 ExpandMinifiedVersions 互为逆操作
+func MinifyVersions(versions []map[string]json.RawMessage) []map[string]json.RawMessage {
+	minified := make([]map[string]json.RawMessage, 0, len(versions))
+	var previous map[string]json.RawMessage
+	for _, version := range versions {
+		current := make(map[string]json.RawMessage)
+		for key, value := range version {
+			if previousValue, ok := previous[key]; !ok || string(previousValue) != string(value) {
+				current[key] = value
+			}
+		}
+		for key := range previous {
+			if _, ok := version[key]; !ok {
+				current[key] = json.RawMessage(`"` + minifiedUnset + `"`)
+			}
+		}
+		minified = append(minified, current)
+		previous = version
+	}
+	return minified
+}
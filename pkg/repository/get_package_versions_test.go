@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Abridged from https://repo.packagist.org/p2/monolog/monolog.json and ~dev.json
+const (
+	p2Versions = `{
+  "packages": {
+    "monolog/monolog": [
+      {
+        "name": "monolog/monolog",
+        "description": "Sends your logs to files, sockets, inboxes, databases and various web services",
+        "version": "3.5.0",
+        "version_normalized": "3.5.0.0",
+        "license": ["MIT"],
+        "time": "2023-10-27T15:32:31+00:00",
+        "require": {"php": ">=8.1", "psr/log": "^2.0 || ^3.0"},
+        "extra": {"branch-alias": {"dev-main": "3.x-dev"}},
+        "type": "library"
+      },
+      {
+        "version": "3.5.0-RC1",
+        "version_normalized": "3.5.0.0-RC1",
+        "time": "2023-10-20T10:00:00+00:00"
+      },
+      {
+        "version": "2.9.2",
+        "version_normalized": "2.9.2.0",
+        "time": "2023-10-27T15:25:26+00:00",
+        "require": {"php": ">=7.2", "psr/log": "^1.0.1 || ^2.0 || ^3.0"},
+        "extra": "__unset"
+      }
+    ]
+  },
+  "minified": "composer/2.0"
+}`
+	p2DevVersions = `{
+  "packages": {
+    "monolog/monolog": [
+      {
+        "name": "monolog/monolog",
+        "version": "dev-main",
+        "version_normalized": "dev-main",
+        "extra": {"branch-alias": {"dev-main": "3.x-dev"}},
+        "type": "library"
+      },
+      {
+        "version": "2.x-dev",
+        "version_normalized": "2.9999999.9999999.9999999-dev",
+        "extra": {"branch-alias": {"dev-main": "2.x-dev"}}
+      }
+    ]
+  },
+  "minified": "composer/2.0"
+}`
+)
+
+func TestRepository_GetPackageVersions(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/p2/monolog/monolog.json":     p2Versions,
+		"/p2/monolog/monolog~dev.json": p2DevVersions,
+	})
+	defer server.Close()
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+	ctx := context.Background()
+
+	versions, err := repo.GetPackageVersions(ctx, "Monolog/Monolog")
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 3) {
+		// Fields are inherited from the previous version
+		assert.Equal(t, "monolog/monolog", versions[1].Name)
+		assert.Equal(t, "3.5.0-RC1", versions[1].Version)
+		assert.Equal(t, ">=8.1", versions[1].Require["php"])
+		assert.Equal(t, "3.x-dev", versions[1].BranchAliases()["dev-main"])
+		assert.Equal(t, "RC", versions[1].Stability().String())
+
+		// and "__unset" removes them
+		assert.Equal(t, "library", versions[2].Type)
+		assert.Nil(t, versions[2].Extra)
+		assert.Equal(t, ">=7.2", versions[2].Require["php"])
+		assert.Equal(t, 2023, versions[2].Time.Year())
+	}
+
+	devVersions, err := repo.GetPackageDevVersions(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	if assert.Len(t, devVersions, 2) {
+		assert.Equal(t, "3.x-dev", devVersions[0].BranchAlias())
+		assert.True(t, devVersions[1].IsDev())
+		assert.Equal(t, "library", devVersions[1].Type)
+	}
+
+	all, err := repo.GetPackageAllVersions(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	names := make([]string, 0, len(all))
+	for _, version := range all {
+		names = append(names, version.Version)
+	}
+	assert.Equal(t, []string{"3.5.0", "3.5.0-RC1", "2.9.2", "dev-main", "2.x-dev"}, names)
+}
+
+func TestRepository_GetPackageVersions_NotFound(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/p2/psr/log.json": `{"packages": {"psr/log": [{"name": "psr/log", "version": "3.0.0"}]}, "minified": "composer/2.0"}`,
+	})
+	defer server.Close()
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+	ctx := context.Background()
+
+	_, err := repo.GetPackageVersions(ctx, "not/exists")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	_, err = repo.GetPackageAllVersions(ctx, "not/exists")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+
+	// A mirror without ~dev.json still returns the tagged versions
+	all, err := repo.GetPackageAllVersions(ctx, "psr/log")
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func TestMinifyVersions(t *testing.T) {
+	response := &p2Response{}
+	assert.NoError(t, json.Unmarshal([]byte(p2Versions), response))
+	expanded := ExpandMinifiedVersions(response.Packages["monolog/monolog"])
+	assert.Len(t, expanded[2], 8)
+	assert.NotContains(t, expanded[2], "extra")
+
+	minified := MinifyVersions(expanded)
+	assert.Equal(t, expanded, ExpandMinifiedVersions(minified))
+	assert.Equal(t, json.RawMessage(`"__unset"`), minified[2]["extra"])
+	assert.NotContains(t, minified[1], "name")
+}
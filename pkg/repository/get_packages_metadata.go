@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+)
+
+// DefaultBatchConcurrency 批量获取包信息时默认的并发数，Packagist 希望客户端不要有太高的并发
+const DefaultBatchConcurrency = 8
+
+// BatchOptions 批量获取包信息的配置
+type BatchOptions struct {
+
+	// 同时进行的请求数，为空的时候使用 DefaultBatchConcurrency
+	Concurrency int
+
+	// 每秒最多开始的请求数，为空的时候不限制，只受 Concurrency 的约束
+	RequestsPerSecond float64
+
+	// 每个包处理完之后的回调，成功和失败都会调用，在调用 GetPackagesMetadata 的 goroutine 中依次被调用，
+	// 结果不会被保留，回调返回之后包信息就可以被回收，所以内存占用和包的数量无关
+	OnResult func(result *PackageResult)
+}
+
+// PackageResult 表示批量获取中一个包的结果
+type PackageResult struct {
+	PackageName string
+
+	// 包的信息，失败的时候为 nil
+	Info *composer_crawler.ComposerPackageInfo
+
+	// 获取失败的原因，包不存在的时候是 ErrPackageNotFound
+	Err error
+}
+
+// BatchStats 表示一次批量获取的统计信息
+type BatchStats struct {
+	Total     int
+	Succeeded int
+	Failed    int
+
+	// 每个失败的包的错误，单个包失败不会中止整个批次
+	Errors map[string]error
+}
+
+// GetPackagesMetadata 并发获取多个包的详细信息，结果通过 OnResult 按完成的顺序流式返回。
+// 单个包的失败记录在 BatchStats.Errors 中，不会中止整个批次；ctx 结束的时候不再发出新的请求，
+// 等进行中的请求结束之后返回 ctx 的错误，因为 ctx 结束而失败的包不会交给 OnResult，也不计入统计
+func (x *Repository) GetPackagesMetadata(ctx context.Context, packageNames []string, options *BatchOptions) (*BatchStats, error) {
+	if options == nil {
+		options = &BatchOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	jobs := make(chan string)
+	results := make(chan *PackageResult)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for packageName := range jobs {
+				info, err := x.GetPackage(ctx, packageName)
+				results <- &PackageResult{PackageName: packageName, Info: info, Err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if options.RequestsPerSecond > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / options.RequestsPerSecond))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i, packageName := range packageNames {
+			if tick != nil && i > 0 {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- packageName:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	stats := &BatchStats{Total: len(packageNames), Errors: make(map[string]error)}
+	for result := range results {
+		if result.Err != nil && ctx.Err() != nil {
+			continue
+		}
+		if result.Err != nil {
+			stats.Failed++
+			stats.Errors[result.PackageName] = result.Err
+		} else {
+			stats.Succeeded++
+		}
+		if options.OnResult != nil {
+			options.OnResult(result)
+		}
+	}
+	return stats, ctx.Err()
+}
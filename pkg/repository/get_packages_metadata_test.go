@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newBatchServer serves every package except "vendor/missing" (404) and "vendor/broken" (500),
+// recording the highest number of requests in flight at the same time
+func newBatchServer(delay time.Duration) (*httptest.Server, *int32) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/packages/"), ".json")
+		switch name {
+		case "vendor/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status": "error", "message": "Package not found"}`))
+		case "vendor/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(fmt.Sprintf(`{"package": {"name": "%s"}}`, name)))
+		}
+	}))
+	return server, &maxInFlight
+}
+
+func TestRepository_GetPackagesMetadata(t *testing.T) {
+	server, maxInFlight := newBatchServer(5 * time.Millisecond)
+	defer server.Close()
+	repo := NewRepository(&Options{ServerUrl: server.URL, MaxTries: 1})
+
+	names := []string{"vendor/missing", "vendor/broken"}
+	for i := 0; i < 20; i++ {
+		names = append(names, fmt.Sprintf("vendor/package-%d", i))
+	}
+	received := make(map[string]*PackageResult)
+	stats, err := repo.GetPackagesMetadata(context.Background(), names, &BatchOptions{
+		Concurrency: 3,
+		OnResult: func(result *PackageResult) {
+			received[result.PackageName] = result
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 22, stats.Total)
+	assert.Equal(t, 20, stats.Succeeded)
+	assert.Equal(t, 2, stats.Failed)
+	assert.True(t, errors.Is(stats.Errors["vendor/missing"], ErrPackageNotFound))
+	assert.Contains(t, stats.Errors["vendor/broken"].Error(), "500")
+	assert.LessOrEqual(t, atomic.LoadInt32(maxInFlight), int32(3))
+
+	assert.Len(t, received, 22)
+	assert.Equal(t, "vendor/package-7", received["vendor/package-7"].Info.PackageName)
+	assert.Nil(t, received["vendor/missing"].Info)
+}
+
+func TestRepository_GetPackagesMetadata_Cancel(t *testing.T) {
+	server, _ := newBatchServer(time.Millisecond)
+	defer server.Close()
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+
+	names := make([]string, 0)
+	for i := 0; i < 1000; i++ {
+		names = append(names, fmt.Sprintf("vendor/package-%d", i))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var count int
+	stats, err := repo.GetPackagesMetadata(ctx, names, &BatchOptions{
+		Concurrency: 4,
+		OnResult: func(result *PackageResult) {
+			count++
+			if count == 10 {
+				cancel()
+			}
+		},
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, count, stats.Succeeded+stats.Failed)
+	assert.Less(t, count, 20)
+	assert.Empty(t, stats.Errors)
+}
+
+func TestRepository_GetPackagesMetadata_RequestsPerSecond(t *testing.T) {
+	server, _ := newBatchServer(0)
+	defer server.Close()
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+
+	start := time.Now()
+	stats, err := repo.GetPackagesMetadata(context.Background(), []string{"a/a", "b/b", "c/c", "d/d", "e/e"}, &BatchOptions{
+		RequestsPerSecond: 100,
+		OnResult: func(result *PackageResult) {
+			assert.NoError(t, result.Err)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Succeeded)
+	// Four intervals of 10ms between the five requests
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRepository_GetPackagesMetadata_Empty(t *testing.T) {
+	stats, err := NewRepository(nil).GetPackagesMetadata(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+}
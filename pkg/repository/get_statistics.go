@@ -7,7 +7,10 @@ import (
 )
 
 // Statistics 获取仓库中的组件下载统计信息
-func (x *Repository) Statistics(ctx context.Context) (*response.StatisticsResponse, error) {
+func (x *Repository) Statistics(ctx context.Context) (_ *response.StatisticsResponse, err error) {
+	ctx, finish := x.startOperation(ctx, "Statistics")
+	defer func() { finish(err) }()
+
 	targetUrl := fmt.Sprintf("%s/statistics.json", x.options.ServerUrl)
 	return getJson[*response.StatisticsResponse](ctx, x, targetUrl)
 }
@@ -3,15 +3,12 @@ package repository
 import (
 	"context"
 	"os"
-
-	"github.com/crawler-go-go-go/go-requests"
 )
 
 // DownloadIndex 下载整个的索引文件
 // https://packagist.org/packages/list.json
 func DownloadIndex(ctx context.Context) ([]byte, error) {
-	targetUrl := "https://packagist.org/packages/list.json"
-	return requests.GetBytes(ctx, targetUrl)
+	return NewRepository(nil).DownloadIndex(ctx)
 }
 
 // DownloadIndexToFile 下载索引文件到本地文件
@@ -22,3 +19,11 @@ func DownloadIndexToFile(ctx context.Context, filepath string) error {
 	}
 	return os.WriteFile(filepath, indexBytes, os.ModePerm)
 }
+
+// DownloadIndex 下载仓库的索引文件，和同名的函数不同，它会使用仓库的地址、代理和认证信息
+func (x *Repository) DownloadIndex(ctx context.Context) (_ []byte, err error) {
+	ctx, finish := x.startOperation(ctx, "DownloadIndex")
+	defer func() { finish(err) }()
+
+	return x.getBytes(ctx, x.options.ServerUrl+"/packages/list.json")
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -87,3 +88,14 @@ func TestDownloadIndexToFile_Error(t *testing.T) {
 	// err := DownloadIndexToFile(context.Background(), invalidPath)
 	// assert.Error(t, err)
 }
+
+func TestRepository_DownloadIndex(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/packages/list.json": `{"packageNames":["monolog/monolog"]}`,
+	})
+	defer server.Close()
+
+	data, err := NewRepository(&Options{ServerUrl: server.URL}).DownloadIndex(context.Background())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"packageNames":["monolog/monolog"]}`, string(data))
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// ErrInvalidChangesSince since 缺失或者太旧了，返回的响应中带着当前的时间戳，可以用它开始下一次增量同步
+var ErrInvalidChangesSince = errors.New("invalid changes since")
+
+// ListChanges 获取给定时间戳之后元数据发生变化的包，since 的单位是 1/10000 秒，
+// since 为 0 或者无效的时候返回 ErrInvalidChangesSince，同时返回带着当前时间戳的响应
+// https://packagist.org/apidoc#track-package-updates
+func (x *Repository) ListChanges(ctx context.Context, since int64) (_ *response.ChangesResponse, err error) {
+	ctx, finish := x.startOperation(ctx, "ListChanges", attribute.Int64("composer.changes.since", since))
+	defer func() { finish(err) }()
+
+	targetUrl := fmt.Sprintf("%s/metadata/changes.json", x.options.ServerUrl)
+	if since > 0 {
+		targetUrl = fmt.Sprintf("%s?since=%d", targetUrl, since)
+	}
+	bytes, err := x.getBytes(ctx, targetUrl, http.StatusOK, http.StatusBadRequest)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decodeJson[*response.ChangesResponse](ctx, x, targetUrl, bytes)
+	if err != nil {
+		return nil, err
+	}
+	if changes.Error != "" {
+		return changes, fmt.Errorf("%w: %s", ErrInvalidChangesSince, changes.Error)
+	}
+	return changes, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_ListChanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/changes.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("since") != "16142636890000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Invalid or missing \"since\" query parameter","timestamp":16142636899999}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"actions":[
+			{"type":"update","package":"vendor/a","time":1614263690},
+			{"type":"delete","package":"vendor/b","time":1614263691}
+		],"timestamp":16142636900000}`))
+	}))
+	defer server.Close()
+
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+
+	t.Run("successful request", func(t *testing.T) {
+		changes, err := repo.ListChanges(context.Background(), 16142636890000)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(16142636900000), changes.Timestamp)
+		assert.Equal(t, []*response.ChangeAction{
+			{Type: response.ChangeActionUpdate, Package: "vendor/a", Time: 1614263690},
+			{Type: response.ChangeActionDelete, Package: "vendor/b", Time: 1614263691},
+		}, changes.Actions)
+	})
+
+	t.Run("missing since", func(t *testing.T) {
+		changes, err := repo.ListChanges(context.Background(), 0)
+		assert.True(t, errors.Is(err, ErrInvalidChangesSince))
+		assert.Equal(t, int64(16142636899999), changes.Timestamp)
+	})
+}
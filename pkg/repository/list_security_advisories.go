@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/scagogogo/composer-crawler/pkg/response"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // List security advisories
@@ -13,16 +16,22 @@ import (
 
 // https://packagist.org/api/security-advisories/?updatedSince=[timestamp]&packages[]=[vendor/package]
 
-// ListSecurityAdvisories 查询给定时间之后被报告的漏洞
-// https://packagist.org/api/security-advisories/?updatedSince=2023-05-22 19:49:11
-func (x *Repository) ListSecurityAdvisories(ctx context.Context, updatedSince time.Time) (*response.AdvisoriesResponse, error) {
-	targetUrl := fmt.Sprintf("%s/api/security-advisories/?updatedSince=%d", x.options.ServerUrl, updatedSince.UnixMilli())
+// ListSecurityAdvisories 查询给定时间之后新增或者更新的漏洞，updatedSince 以秒为单位的 Unix 时间戳发送
+// https://packagist.org/api/security-advisories/?updatedSince=1684784951
+func (x *Repository) ListSecurityAdvisories(ctx context.Context, updatedSince time.Time) (_ *response.AdvisoriesResponse, err error) {
+	ctx, finish := x.startOperation(ctx, "ListSecurityAdvisories", attribute.String("composer.advisories.updated_since", updatedSince.UTC().Format(time.RFC3339)))
+	defer func() { finish(err) }()
+
+	targetUrl := fmt.Sprintf("%s/api/security-advisories/?updatedSince=%d", x.options.ServerUrl, updatedSince.Unix())
 	return getJson[*response.AdvisoriesResponse](ctx, x, targetUrl)
 }
 
 // ListAdvisories 获取给定包上的所有漏洞
 // https://packagist.org/api/security-advisories/?packages=craftcms/cms
-func (x *Repository) ListAdvisories(ctx context.Context, packageName string) ([]*response.Advisory, error) {
+func (x *Repository) ListAdvisories(ctx context.Context, packageName string) (_ []*response.Advisory, err error) {
+	ctx, finish := x.startOperation(ctx, "ListAdvisories", packageAttribute(packageName))
+	defer func() { finish(err) }()
+
 	targetUrl := fmt.Sprintf("%s/api/security-advisories/?packages=%s", x.options.ServerUrl, packageName)
 	json, err := getJson[*response.AdvisoriesResponse](ctx, x, targetUrl)
 	if err != nil {
@@ -30,3 +39,17 @@ func (x *Repository) ListAdvisories(ctx context.Context, packageName string) ([]
 	}
 	return json.Advisories[packageName], nil
 }
+
+// ListAdvisoriesForPackages 一次性获取多个包上的所有漏洞，返回的结果以包名为键
+// https://packagist.org/api/security-advisories/?packages[]=craftcms/cms&packages[]=symfony/http-kernel
+func (x *Repository) ListAdvisoriesForPackages(ctx context.Context, packageNames []string) (_ *response.AdvisoriesResponse, err error) {
+	ctx, finish := x.startOperation(ctx, "ListAdvisoriesForPackages", packagesAttribute(packageNames))
+	defer func() { finish(err) }()
+
+	query := make([]string, 0, len(packageNames))
+	for _, packageName := range packageNames {
+		query = append(query, url.QueryEscape("packages[]")+"="+url.QueryEscape(packageName))
+	}
+	targetUrl := fmt.Sprintf("%s/api/security-advisories/?%s", x.options.ServerUrl, strings.Join(query, "&"))
+	return getJson[*response.AdvisoriesResponse](ctx, x, targetUrl)
+}
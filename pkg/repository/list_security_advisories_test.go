@@ -39,8 +39,10 @@ func TestRepository_ListSecurityAdvisories(t *testing.T) {
 	}`
 
 	// Create test server
+	var updatedSince string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/api/security-advisories/" {
+			updatedSince = r.URL.Query().Get("updatedSince")
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte(mockAdvisoriesResponse))
 		} else {
@@ -64,6 +66,8 @@ func TestRepository_ListSecurityAdvisories(t *testing.T) {
 		result, err := repo.ListSecurityAdvisories(context.Background(), testTime)
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
+		// updatedSince is a Unix timestamp in seconds
+		assert.Equal(t, "1672531200", updatedSince)
 
 		// Verify the response content
 		assert.Contains(t, result.Advisories, "vendor/package1")
@@ -250,3 +254,25 @@ func TestRepository_ListAdvisories(t *testing.T) {
 		assert.Nil(t, advisories)
 	})
 }
+
+func TestRepository_ListAdvisoriesForPackages(t *testing.T) {
+	var gotPackages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPackages = r.URL.Query()["packages[]"]
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"advisories": {
+				"vendor/package1": [{"advisoryId": "PKSA-1", "packageName": "vendor/package1", "affectedVersions": "<2.0.0"}],
+				"vendor/package2": [{"advisoryId": "PKSA-2", "packageName": "vendor/package2", "affectedVersions": ">=1.0,<1.1"}]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	repo := NewRepository(&Options{ServerUrl: server.URL})
+	result, err := repo.ListAdvisoriesForPackages(context.Background(), []string{"vendor/package1", "vendor/package2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor/package1", "vendor/package2"}, gotPackages)
+	assert.Len(t, result.Advisories, 2)
+	assert.Equal(t, "PKSA-2", result.Advisories["vendor/package2"][0].AdvisoryID)
+}
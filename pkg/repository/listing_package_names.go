@@ -18,7 +18,10 @@ type PackageListResponse struct {
 //	   ...
 //	 ]
 //	}
-func (x *Repository) List(ctx context.Context) ([]*Package, error) {
+func (x *Repository) List(ctx context.Context) (_ []*Package, err error) {
+	ctx, finish := x.startOperation(ctx, "List")
+	defer func() { finish(err) }()
+
 	targetUrl := fmt.Sprintf("%s/packages/list.json", x.options.ServerUrl)
 	response, err := getJson[*PackageListResponse](ctx, x, targetUrl)
 	if err != nil {
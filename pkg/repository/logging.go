@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// 没有设置 Options.Logger 的时候使用，丢弃所有的日志
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (x discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return x }
+func (x discardHandler) WithGroup(string) slog.Handler           { return x }
+
+var discardLogger = slog.New(discardHandler{})
+
+func (x *Repository) logger() *slog.Logger {
+	if x.options.Logger != nil {
+		return x.options.Logger
+	}
+	return discardLogger
+}
+
+// 每条日志都带上发出请求的方法名以及包名
+func logAttributes(ctx context.Context, attributes ...slog.Attr) []slog.Attr {
+	attributes = append(attributes, slog.String("operation", operationFromContext(ctx)))
+	if packageName, ok := ctx.Value(packageContextKey{}).(string); ok {
+		attributes = append(attributes, slog.String("package", packageName))
+	}
+	return attributes
+}
+
+func (x *Repository) log(ctx context.Context, level slog.Level, message string, attributes ...slog.Attr) {
+	logger := x.logger()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, message, logAttributes(ctx, attributes...)...)
+}
+
+// 解析 JSON 响应，失败的时候记录日志
+func decodeJson[T any](ctx context.Context, repository *Repository, targetUrl string, bytes []byte) (T, error) {
+	r, err := unmarshalJson[T](bytes)
+	if err != nil {
+		repository.log(ctx, slog.LevelWarn, "parse response failed",
+			slog.String("url", targetUrl), slog.Int("bytes", len(bytes)), slog.Any("error", err))
+	}
+	return r, err
+}
+
+// loggingTransport 记录每个请求的结果，正常的响应是 Debug，服务端错误、限流以及网络错误是 Warn
+type loggingTransport struct {
+	next       http.RoundTripper
+	repository *Repository
+}
+
+func (x *loggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	start := time.Now()
+	response, err := x.next.RoundTrip(request)
+	attributes := []slog.Attr{
+		slog.String("method", request.Method),
+		slog.String("url", request.URL.Redacted()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		x.repository.log(ctx, slog.LevelWarn, "request failed", append(attributes, slog.Any("error", err))...)
+		return nil, err
+	}
+
+	attributes = append(attributes, slog.Int("status", response.StatusCode))
+	if cache := cacheStatus(response); cache != CacheUnknown {
+		attributes = append(attributes, slog.String("cache", string(cache)))
+	}
+	level := slog.LevelDebug
+	if response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests {
+		level = slog.LevelWarn
+	}
+	x.repository.log(ctx, level, "request finished", attributes...)
+	return response, nil
+}
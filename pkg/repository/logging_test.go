@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeLogs parses the JSON lines written by slog.JSONHandler
+func decodeLogs(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	records := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRepository_Logger(t *testing.T) {
+	var statisticsTries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/statistics.json":
+			statisticsTries++
+			if statisticsTries == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.Write([]byte(`{"totals": {"downloads": 1}}`))
+		case "/packages/monolog/monolog.json":
+			w.Write([]byte(`{"package": `))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := NewRepository(&Options{ServerUrl: server.URL, Logger: logger, RetryDelay: time.Millisecond})
+	ctx := context.Background()
+
+	_, err := repo.Statistics(ctx)
+	assert.NoError(t, err)
+	_, err = repo.GetPackage(ctx, "monolog/monolog")
+	assert.Error(t, err)
+
+	records := decodeLogs(t, buffer)
+	messages := make([]string, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, record["msg"].(string))
+	}
+	assert.Equal(t, []string{"request finished", "retrying request", "request finished", "request finished", "parse response failed"}, messages)
+	if len(records) != 5 {
+		return
+	}
+
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, float64(http.StatusServiceUnavailable), records[0]["status"])
+	assert.Equal(t, "Statistics", records[0]["operation"])
+	assert.Equal(t, server.URL+"/statistics.json", records[0]["url"])
+	assert.Contains(t, records[0], "duration")
+
+	assert.Equal(t, "INFO", records[1]["level"])
+	assert.Equal(t, float64(2), records[1]["attempt"])
+
+	assert.Equal(t, "DEBUG", records[2]["level"])
+	assert.Equal(t, "hit", records[2]["cache"])
+	assert.NotContains(t, records[2], "package")
+
+	assert.Equal(t, "monolog/monolog", records[3]["package"])
+	assert.Equal(t, "WARN", records[4]["level"])
+	assert.Equal(t, "monolog/monolog", records[4]["package"])
+	assert.Equal(t, "GetPackage", records[4]["operation"])
+}
+
+func TestRepository_Logger_GaveUp(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelError}))
+	repo := NewRepository(&Options{ServerUrl: "http://127.0.0.1:1", Logger: logger, MaxTries: 2, RetryDelay: time.Millisecond})
+	_, err := repo.List(context.Background())
+	assert.Error(t, err)
+
+	records := decodeLogs(t, buffer)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "request gave up", records[0]["msg"])
+		assert.Equal(t, float64(2), records[0]["max_tries"])
+		assert.Equal(t, "List", records[0]["operation"])
+	}
+}
+
+func TestRepository_Logger_Nil(t *testing.T) {
+	server := createMockServer(`{"packageNames": []}`)
+	defer server.Close()
+	// Without a logger nothing is logged and nothing panics
+	_, err := NewRepository(&Options{ServerUrl: server.URL}).List(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 创建 Tracer 时使用的名字
+const tracerName = "github.com/scagogogo/composer-crawler/pkg/repository"
+
+// OperationOther 不是在 Repository 的方法中发出的请求使用的 operation
+const OperationOther = "other"
+
+// CacheStatus 表示响应是否来自缓存，根据 CDN 或者缓存代理的响应头判断
+type CacheStatus string
+
+const (
+	// CacheUnknown 响应中没有缓存相关的信息
+	CacheUnknown CacheStatus = ""
+	CacheHit     CacheStatus = "hit"
+	CacheMiss    CacheStatus = "miss"
+)
+
+// RequestObservation 表示一次 HTTP 请求的指标，重试的每一次都是一个单独的请求
+type RequestObservation struct {
+
+	// 发出请求的 Repository 方法，比如 GetPackage、List，其它的请求是 OperationOther
+	Operation string
+
+	Method string
+
+	// 响应的状态码，请求没有拿到响应的时候是 0
+	StatusCode int
+
+	// 从发出请求到读完（或者关闭）响应体的时间
+	Duration time.Duration
+
+	// 读取到的响应体的字节数
+	BytesReceived int64
+
+	Cache CacheStatus
+
+	// 请求失败或者读取响应体失败的错误
+	Err error
+}
+
+// Metrics 接收每个请求的指标，可以导出到 Prometheus 等监控系统，实现需要是并发安全的
+type Metrics interface {
+
+	// ObserveRequest 每个请求结束的时候调用
+	ObserveRequest(observation *RequestObservation)
+
+	// ObserveRetry 每次重试之前调用
+	ObserveRetry(operation string)
+}
+
+type operationContextKey struct{}
+
+// 方法处理的包名，记录日志的时候使用
+type packageContextKey struct{}
+
+func operationFromContext(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationContextKey{}).(string); ok {
+		return operation
+	}
+	return OperationOther
+}
+
+// 每个 Repository 方法开始的时候调用，创建 span 并且把方法名放到 context 中，返回的函数在方法结束的时候调用
+func (x *Repository) startOperation(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, func(err error)) {
+	// 嵌套调用的时候（比如 DownloadIndex 函数）指标记在最外层的方法上
+	if _, ok := ctx.Value(operationContextKey{}).(string); !ok {
+		ctx = context.WithValue(ctx, operationContextKey{}, operation)
+	}
+	for _, attribute := range attributes {
+		if attribute.Key == packageAttributeKey {
+			ctx = context.WithValue(ctx, packageContextKey{}, attribute.Value.AsString())
+		}
+	}
+	tracerProvider := x.options.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	ctx, span := tracerProvider.Tracer(tracerName).Start(ctx, "Repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// 常用的 span 属性
+const packageAttributeKey = attribute.Key("composer.package")
+
+func packageAttribute(packageName string) attribute.KeyValue {
+	return packageAttributeKey.String(packageName)
+}
+
+func packagesAttribute(packageNames []string) attribute.KeyValue {
+	return attribute.StringSlice("composer.packages", packageNames)
+}
+
+// metricsTransport 在最外层统计每个请求的指标
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics Metrics
+}
+
+func (x *metricsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	observation := &RequestObservation{Operation: operationFromContext(request.Context()), Method: request.Method}
+	start := time.Now()
+	response, err := x.next.RoundTrip(request)
+	if err != nil {
+		observation.Duration = time.Since(start)
+		observation.Err = err
+		x.metrics.ObserveRequest(observation)
+		return nil, err
+	}
+	observation.StatusCode = response.StatusCode
+	observation.Cache = cacheStatus(response)
+	response.Body = &observedBody{ReadCloser: response.Body, observation: observation, start: start, metrics: x.metrics}
+	return response, nil
+}
+
+// 304、X-From-Cache（httpcache 等缓存代理）以及 X-Cache（Fastly 等 CDN）都认为是缓存
+func cacheStatus(response *http.Response) CacheStatus {
+	if response.StatusCode == http.StatusNotModified || response.Header.Get("X-From-Cache") == "1" {
+		return CacheHit
+	}
+	xCache := strings.ToUpper(response.Header.Get("X-Cache"))
+	switch {
+	case xCache == "":
+		return CacheUnknown
+	// 经过多层缓存的时候是 "MISS, HIT" 这样的形式，最后一层命中就算命中
+	case strings.HasSuffix(xCache, "HIT"):
+		return CacheHit
+	default:
+		return CacheMiss
+	}
+}
+
+// observedBody 统计读取的字节数，读完或者关闭的时候上报指标
+type observedBody struct {
+	io.ReadCloser
+	observation *RequestObservation
+	start       time.Time
+	metrics     Metrics
+	once        sync.Once
+}
+
+func (x *observedBody) Read(p []byte) (int, error) {
+	n, err := x.ReadCloser.Read(p)
+	x.observation.BytesReceived += int64(n)
+	if err == io.EOF {
+		x.report(nil)
+	} else if err != nil {
+		x.report(err)
+	}
+	return n, err
+}
+
+func (x *observedBody) Close() error {
+	x.report(nil)
+	return x.ReadCloser.Close()
+}
+
+func (x *observedBody) report(err error) {
+	x.once.Do(func() {
+		x.observation.Duration = time.Since(x.start)
+		x.observation.Err = err
+		x.metrics.ObserveRequest(x.observation)
+	})
+}
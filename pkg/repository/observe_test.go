@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type recordingMetrics struct {
+	lock         sync.Mutex
+	observations []*RequestObservation
+	retries      []string
+}
+
+func (x *recordingMetrics) ObserveRequest(observation *RequestObservation) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.observations = append(x.observations, observation)
+}
+
+func (x *recordingMetrics) ObserveRetry(operation string) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.retries = append(x.retries, operation)
+}
+
+func TestRepository_Tracing(t *testing.T) {
+	server := createMockServerWithRoutes(map[string]string{
+		"/packages/monolog/monolog.json": `{"package": {"name": "monolog/monolog"}}`,
+		"/packages/list.json":            `{"packageNames": ["monolog/monolog"]}`,
+	})
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	repo := NewRepository(&Options{ServerUrl: server.URL, TracerProvider: tracerProvider})
+	ctx := context.Background()
+
+	_, err := repo.GetPackage(ctx, "monolog/monolog")
+	assert.NoError(t, err)
+	_, err = repo.GetPackage(ctx, "not/exists")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	_, err = repo.List(ctx)
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "Repository.GetPackage", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, attribute.String("composer.package", "monolog/monolog"))
+		assert.Equal(t, codes.Unset, spans[0].Status.Code)
+
+		assert.Equal(t, codes.Error, spans[1].Status.Code)
+		assert.Contains(t, spans[1].Status.Description, "package not found")
+
+		assert.Equal(t, "Repository.List", spans[2].Name)
+	}
+}
+
+func TestRepository_Metrics(t *testing.T) {
+	var tries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tries++
+		if tries == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("X-Cache", "HIT")
+		w.Write([]byte(`{"totals": {"downloads": 1}}`))
+	}))
+	defer server.Close()
+
+	metrics := &recordingMetrics{}
+	repo := NewRepository(&Options{ServerUrl: server.URL, Metrics: metrics, RetryDelay: time.Millisecond})
+	_, err := repo.Statistics(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Statistics"}, metrics.retries)
+	if assert.Len(t, metrics.observations, 2) {
+		assert.Equal(t, http.StatusBadGateway, metrics.observations[0].StatusCode)
+		assert.Equal(t, CacheUnknown, metrics.observations[0].Cache)
+
+		observation := metrics.observations[1]
+		assert.Equal(t, "Statistics", observation.Operation)
+		assert.Equal(t, http.MethodGet, observation.Method)
+		assert.Equal(t, http.StatusOK, observation.StatusCode)
+		assert.Equal(t, int64(len(`{"totals": {"downloads": 1}}`)), observation.BytesReceived)
+		assert.Equal(t, CacheHit, observation.Cache)
+		assert.Greater(t, observation.Duration.Nanoseconds(), int64(0))
+		assert.NoError(t, observation.Err)
+	}
+
+	// DownloadDist goes through the same transport
+	metrics.observations = nil
+	_, err = repo.DownloadDist(context.Background(), newDistVersion("zip", server.URL+"/a.zip", ""), &bytes.Buffer{})
+	assert.NoError(t, err)
+	if assert.Len(t, metrics.observations, 1) {
+		assert.Equal(t, "DownloadDist", metrics.observations[0].Operation)
+	}
+}
+
+func TestCacheStatus(t *testing.T) {
+	testCases := []struct {
+		status   int
+		header   map[string]string
+		expected CacheStatus
+	}{
+		{http.StatusOK, nil, CacheUnknown},
+		{http.StatusNotModified, nil, CacheHit},
+		{http.StatusOK, map[string]string{"X-From-Cache": "1"}, CacheHit},
+		{http.StatusOK, map[string]string{"X-Cache": "HIT"}, CacheHit},
+		{http.StatusOK, map[string]string{"X-Cache": "MISS, HIT"}, CacheHit},
+		{http.StatusOK, map[string]string{"X-Cache": "HIT, MISS"}, CacheMiss},
+		{http.StatusOK, map[string]string{"X-Cache": "miss"}, CacheMiss},
+	}
+	for _, testCase := range testCases {
+		response := &http.Response{StatusCode: testCase.status, Header: http.Header{}}
+		for key, value := range testCase.header {
+			response.Header.Set(key, value)
+		}
+		assert.Equal(t, testCase.expected, cacheStatus(response), testCase.header)
+	}
+}
+
+func TestObservedBody(t *testing.T) {
+	metrics := &recordingMetrics{}
+	transport := &metricsTransport{metrics: metrics, next: RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader([]byte("0123456789")))}, nil
+	})}
+	request, _ := http.NewRequest(http.MethodGet, "https://packagist.org/packages/list.json", nil)
+	response, err := transport.RoundTrip(request)
+	assert.NoError(t, err)
+
+	// Closing before reading everything still reports exactly once
+	buffer := make([]byte, 4)
+	_, _ = response.Body.Read(buffer)
+	assert.Empty(t, metrics.observations)
+	assert.NoError(t, response.Body.Close())
+	assert.NoError(t, response.Body.Close())
+	if assert.Len(t, metrics.observations, 1) {
+		assert.Equal(t, OperationOther, metrics.observations[0].Operation)
+		assert.Equal(t, int64(4), metrics.observations[0].BytesReceived)
+	}
+}
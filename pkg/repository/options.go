@@ -1,6 +1,52 @@
 package repository
 
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultServerUrl 官方仓库的地址
+const DefaultServerUrl = "https://packagist.org"
+
 type Options struct {
 	ServerUrl string
 	Proxy     string
+
+	// 访问私有仓库以及下载 dist 时使用的认证信息
+	Auth *Auth
+
+	// path 类型的 dist 只能读取这个目录下的文件，为空的时候拒绝所有 path 类型的 dist，
+	// 只应该在仓库是可信的（比如自己的 path 仓库）时候设置
+	PathDistBaseDir string
+
+	// 发送请求使用的 Transport，可以用来配置企业内部的根证书、mTLS、自定义 DNS，或者使用 replay.Transport 录制回放，
+	// 为空的时候使用标准库的默认 Transport。设置了之后 Proxy 不再生效，需要在 Transport 中自己配置代理
+	Transport http.RoundTripper
+
+	// 按顺序包装 Transport 的中间件，比如 RequestMutator、ResponseInspector，下载 dist 的请求同样会经过它们
+	Middlewares []Middleware
+
+	// 请求使用的 User-Agent，为空的时候使用 DefaultUserAgent
+	UserAgent string
+
+	// 每个请求最多尝试的次数，为空的时候使用 DefaultMaxTries
+	MaxTries int
+
+	// 第一次重试之前等待的时间，之后每次翻倍并加上随机抖动，为空的时候使用 DefaultRetryDelay
+	RetryDelay time.Duration
+
+	// 两次尝试之间最多等待的时间，Retry-After 要求等待更久的时候不再重试，为空的时候使用 DefaultMaxRetryDelay
+	MaxRetryDelay time.Duration
+
+	// 接收每个请求的指标，比如 prommetrics.Metrics，为空的时候不统计
+	Metrics Metrics
+
+	// 每个 Repository 方法都会创建一个 span，为空的时候使用 otel 全局的 TracerProvider
+	TracerProvider trace.TracerProvider
+
+	// 记录请求、重试、缓存命中以及解析失败的日志，为空的时候不记录
+	Logger *slog.Logger
 }
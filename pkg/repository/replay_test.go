@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/replay"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReplayRepository replays the packagist.org responses in testdata/fixtures, run the tests with
+// COMPOSER_CRAWLER_RECORD=record to record them again from the real server
+func newReplayRepository(t *testing.T, name string) *Repository {
+	transport, err := replay.NewTransport(&replay.Options{
+		Mode: replay.ModeFromEnv("COMPOSER_CRAWLER_RECORD"),
+		Path: filepath.Join("testdata", "fixtures", name+".json"),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, transport.Save())
+		if transport.Mode() == replay.ModeReplay {
+			assert.Empty(t, transport.Unused(), "fixture %s has unused interactions", name)
+		}
+	})
+	return NewRepository(&Options{Transport: transport})
+}
+
+func TestReplay_GetPackage(t *testing.T) {
+	repo := newReplayRepository(t, "get_package")
+	ctx := context.Background()
+
+	info, err := repo.GetPackage(ctx, "psr/log")
+	assert.NoError(t, err)
+	assert.Equal(t, "psr/log", info.PackageName)
+	assert.Equal(t, "https://github.com/php-fig/log", info.Package.Repository)
+	assert.NotEmpty(t, info.Package.Maintainers)
+	assert.Greater(t, info.Package.Downloads.Total, 0)
+
+	version := info.Package.Versions["1.1.4"]
+	if assert.NotNil(t, version) {
+		assert.Equal(t, "1.1.4.0", version.VersionNormalized)
+		assert.Equal(t, []string{"MIT"}, version.License)
+		assert.Equal(t, "zip", version.Dist.Type)
+		assert.Equal(t, "d49695b909c3b7628b6289db5479a1c204601f11", version.Dist.Reference)
+		assert.Equal(t, ">=5.3.0", version.Require["php"])
+		assert.False(t, version.Time.IsZero())
+	}
+	md5, err := info.ComputePackageInfoMd5()
+	assert.NoError(t, err)
+	assert.Len(t, md5, 32)
+
+	_, err = repo.GetPackage(ctx, "composer-crawler/does-not-exist")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+}
+
+func TestReplay_Statistics(t *testing.T) {
+	repo := newReplayRepository(t, "statistics")
+	ctx := context.Background()
+
+	statistics, err := repo.Statistics(ctx)
+	assert.NoError(t, err)
+	assert.Greater(t, statistics.Totals.Packages, 400000)
+	assert.Greater(t, statistics.Totals.Downloads, int64(0))
+
+	packageStatistics, err := repo.GetPackageStatistics(ctx, "psr/log")
+	assert.NoError(t, err)
+	assert.Greater(t, packageStatistics.Downloads.Total, int64(0))
+	assert.NotEmpty(t, packageStatistics.Versions)
+}
+
+func TestReplay_ListAdvisories(t *testing.T) {
+	repo := newReplayRepository(t, "advisories")
+
+	advisories, err := repo.ListAdvisories(context.Background(), "guzzlehttp/guzzle")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, advisories)
+	for _, advisory := range advisories {
+		assert.Equal(t, "guzzlehttp/guzzle", advisory.PackageName)
+		assert.NotEmpty(t, advisory.AdvisoryID)
+		assert.NotEmpty(t, advisory.AffectedVersions)
+		assert.False(t, advisory.ReportedAtTime().IsZero(), advisory.AdvisoryID)
+		assert.Empty(t, advisory.UnknownFields(), advisory.AdvisoryID)
+	}
+}
+
+func TestReplay_ListChanges(t *testing.T) {
+	repo := newReplayRepository(t, "changes")
+
+	changes, err := repo.ListChanges(context.Background(), 0)
+	assert.True(t, errors.Is(err, ErrInvalidChangesSince))
+	assert.Greater(t, changes.Timestamp, int64(0))
+}
@@ -3,7 +3,11 @@ package repository
 import (
 	"context"
 	"encoding/json"
-	"github.com/crawler-go-go-go/go-requests"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
 )
 
 // Repository 表示一个 composer 仓库
@@ -11,8 +15,25 @@ import (
 // https://packagist.org/apidoc#best-practices
 type Repository struct {
 	options *Options
+
+	// 第一次发送请求的时候根据 options 组装 Transport
+	transportOnce sync.Once
+	roundTripper  http.RoundTripper
+	transportErr  error
 }
 
+// NewRepository 创建一个仓库客户端，options 为空或者没有设置 ServerUrl 的时候使用官方仓库
+func NewRepository(options *Options) *Repository {
+	if options == nil {
+		options = &Options{}
+	}
+	if options.ServerUrl == "" {
+		options.ServerUrl = DefaultServerUrl
+	}
+	return &Repository{
+		options: options,
+	}
+}
 
 func getJson[T any](ctx context.Context, repository *Repository, targetUrl string) (T, error) {
 	bytes, err := repository.getBytes(ctx, targetUrl)
@@ -20,7 +41,7 @@ func getJson[T any](ctx context.Context, repository *Repository, targetUrl strin
 		var zero T
 		return zero, err
 	}
-	return unmarshalJson[T](bytes)
+	return decodeJson[T](ctx, repository, targetUrl, bytes)
 }
 
 func unmarshalJson[T any](bytes []byte) (T, error) {
@@ -33,13 +54,72 @@ func unmarshalJson[T any](bytes []byte) (T, error) {
 	return r, nil
 }
 
-// 内部使用统一的方法来请求
-func (x *Repository) getBytes(ctx context.Context, targetUrl string) ([]byte, error) {
-	options := requests.NewOptions[any, []byte](targetUrl, requests.BytesResponseHandler())
-	if x.options.Proxy != "" {
-		options.AppendRequestSetting(requests.RequestSettingProxy(x.options.Proxy))
+// 内部使用统一的方法来请求，默认只读取 200 和 404 的响应，可以通过 readResponseOnStatusCodeIn 指定其它的状态码，
+// 网络错误、429 以及 5xx 最多尝试 Options.MaxTries 次，每次重试之前按指数退避等待，其它的 4xx 不会重试
+func (x *Repository) getBytes(ctx context.Context, targetUrl string, readResponseOnStatusCodeIn ...int) ([]byte, error) {
+	if len(readResponseOnStatusCodeIn) == 0 {
+		readResponseOnStatusCodeIn = []int{http.StatusOK, http.StatusNotFound}
 	}
-	return requests.SendRequest[any, []byte](ctx, options)
-}
+	transport, err := x.transport()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
 
+	maxTries := x.options.MaxTries
+	if maxTries <= 0 {
+		maxTries = DefaultMaxTries
+	}
+	var lastErr error
+	for tries := 0; tries < maxTries; tries++ {
+		if tries > 0 && x.options.Metrics != nil {
+			x.options.Metrics.ObserveRetry(operationFromContext(ctx))
+		}
+		body, err := x.tryGetBytes(ctx, client, targetUrl, readResponseOnStatusCodeIn)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !isRetryable(err) || tries+1 >= maxTries {
+			break
+		}
+		delay, ok := x.retryDelay(tries, err)
+		if !ok {
+			break
+		}
+		x.log(ctx, slog.LevelInfo, "retrying request", slog.String("url", targetUrl),
+			slog.Int("attempt", tries+2), slog.Int("max_tries", maxTries), slog.Duration("delay", delay), slog.Any("error", err))
+		if err := sleepContext(ctx, delay); err != nil {
+			lastErr = err
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		x.log(ctx, slog.LevelError, "request gave up", slog.String("url", targetUrl),
+			slog.Int("max_tries", maxTries), slog.Any("error", lastErr))
+	}
+	return nil, lastErr
+}
 
+func (x *Repository) tryGetBytes(ctx context.Context, client *http.Client, targetUrl string, readResponseOnStatusCodeIn []int) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	x.prepareRequest(request)
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	for _, statusCode := range readResponseOnStatusCodeIn {
+		if statusCode == response.StatusCode {
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				return nil, fmt.Errorf("response status code: %d, read body error: %w", response.StatusCode, err)
+			}
+			return body, nil
+		}
+	}
+	return nil, newStatusError(response)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetryDelay 第一次重试之前默认等待的时间，之后每次翻倍
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	// DefaultMaxRetryDelay 两次尝试之间默认最多等待的时间
+	DefaultMaxRetryDelay = 30 * time.Second
+)
+
+// statusError 表示仓库返回了不期望的状态码，带着服务端通过 Retry-After 要求等待的时间
+type statusError struct {
+	statusCode int
+	retryAfter time.Duration
+}
+
+func (x *statusError) Error() string {
+	return fmt.Sprintf("response status code: %d", x.statusCode)
+}
+
+// 根据响应生成 statusError
+func newStatusError(response *http.Response) *statusError {
+	return &statusError{
+		statusCode: response.StatusCode,
+		retryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，可以是秒数也可以是 HTTP 日期，没有或者无法解析的时候返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// isRetryable 判断失败的请求是否值得重试：限流（429）、服务端错误（5xx）以及网络错误会重试，
+// 其它的 4xx 重试也不会有不同的结果，域名不存在同理
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode == http.StatusTooManyRequests || statusErr.statusCode >= http.StatusInternalServerError
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	return true
+}
+
+// retryDelay 返回第 retry 次重试（从 0 开始）之前需要等待的时间：指数退避并带上随机抖动，
+// 服务端给了 Retry-After 的时候按它的要求等待，要求等待的时间超过 Options.MaxRetryDelay 的时候返回 false，不再重试
+func (x *Repository) retryDelay(retry int, err error) (time.Duration, bool) {
+	delay, maxDelay := x.options.RetryDelay, x.options.MaxRetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxRetryDelay
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.retryAfter > 0 {
+		if statusErr.retryAfter > maxDelay {
+			return 0, false
+		}
+		return statusErr.retryAfter, true
+	}
+
+	for i := 0; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	// 在 [delay/2, delay] 之间随机，避免大量客户端同时重试
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1)), true
+}
+
+// sleepContext 等待 d，ctx 先结束的时候返回 ctx 的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_Retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		tries      int32
+	}{
+		{"server error", http.StatusBadGateway, 3},
+		{"too many requests", http.StatusTooManyRequests, 3},
+		{"forbidden", http.StatusForbidden, 1},
+		{"bad request", http.StatusBadRequest, 1},
+		{"unauthorized", http.StatusUnauthorized, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tries int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&tries, 1)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			repo := NewRepository(&Options{ServerUrl: server.URL, RetryDelay: time.Millisecond})
+			_, err := repo.Statistics(context.Background())
+			assert.EqualError(t, err, fmt.Sprintf("response status code: %d", tt.statusCode))
+			assert.Equal(t, tt.tries, atomic.LoadInt32(&tries))
+		})
+	}
+}
+
+func TestRepository_RetryAfter(t *testing.T) {
+	var tries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&tries, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"totals": {"downloads": 1}}`))
+	}))
+	defer server.Close()
+
+	// Retry-After wins over the much shorter backoff
+	repo := NewRepository(&Options{ServerUrl: server.URL, RetryDelay: time.Millisecond})
+	start := time.Now()
+	_, err := repo.Statistics(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tries))
+
+	// Waiting longer than MaxRetryDelay is not worth it, the request gives up right away
+	atomic.StoreInt32(&tries, 0)
+	repo = NewRepository(&Options{ServerUrl: server.URL, RetryDelay: time.Millisecond, MaxRetryDelay: 500 * time.Millisecond})
+	_, err = repo.Statistics(context.Background())
+	assert.EqualError(t, err, "response status code: 429")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tries))
+}
+
+func TestRepository_RetryCancelled(t *testing.T) {
+	var tries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tries, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The backoff stops as soon as the context is done
+	repo := NewRepository(&Options{ServerUrl: server.URL, RetryDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := repo.Statistics(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tries))
+}
+
+func TestRepository_RetryDelay(t *testing.T) {
+	repo := NewRepository(&Options{RetryDelay: 100 * time.Millisecond, MaxRetryDelay: time.Second})
+	err := &statusError{statusCode: http.StatusBadGateway}
+	for retry, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 20; i++ {
+			delay, ok := repo.retryDelay(retry, err)
+			assert.True(t, ok)
+			assert.GreaterOrEqual(t, delay, expected/2)
+			assert.LessOrEqual(t, delay, expected)
+		}
+	}
+
+	delay, ok := repo.retryDelay(0, &statusError{statusCode: http.StatusTooManyRequests, retryAfter: 700 * time.Millisecond})
+	assert.True(t, ok)
+	assert.Equal(t, 700*time.Millisecond, delay)
+	_, ok = repo.retryDelay(0, &statusError{statusCode: http.StatusTooManyRequests, retryAfter: time.Minute})
+	assert.False(t, ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 3 ", 3 * time.Second},
+		{"-1", 0},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// DefaultMaxTries 默认每个请求最多尝试的次数，网络错误、429 以及 5xx 会重试
+	DefaultMaxTries = 3
+
+	// DefaultUserAgent 默认的 User-Agent，Packagist 希望客户端在 User-Agent 中带上联系方式
+	// https://packagist.org/apidoc#best-practices
+	DefaultUserAgent = "composer-crawler (+https://github.com/scagogogo/composer-crawler)"
+)
+
+// Middleware 包装发送请求的 http.RoundTripper，可以修改请求、检查响应、打点或者记录日志，
+// 多个 Middleware 的时候第一个在最外层，最先看到请求，最后看到响应
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc 把函数适配成 http.RoundTripper
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (x RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return x(request)
+}
+
+// RequestMutator 在请求发出之前修改请求，比如签名、加上链路追踪的请求头，返回错误的时候请求不会发出。
+// 修改的是请求的副本，不会影响调用方的请求
+func RequestMutator(mutate func(request *http.Request) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			request = request.Clone(request.Context())
+			if err := mutate(request); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// ResponseInspector 在收到响应之后检查响应，返回错误的时候会关闭响应体并把错误返回给调用方
+func ResponseInspector(inspect func(response *http.Response) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				return nil, err
+			}
+			if err := inspect(response); err != nil {
+				io.Copy(io.Discard, response.Body)
+				response.Body.Close()
+				return nil, err
+			}
+			return response, nil
+		})
+	}
+}
+
+// 发送请求使用的 Transport：Options.Transport 或者带着代理配置的默认 Transport，再按顺序包上所有的 Middleware
+func (x *Repository) transport() (http.RoundTripper, error) {
+	x.transportOnce.Do(func() {
+		var transport http.RoundTripper = x.options.Transport
+		if transport == nil {
+			defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+			if x.options.Proxy != "" {
+				proxyUrl, err := url.Parse(x.options.Proxy)
+				if err != nil {
+					x.transportErr = fmt.Errorf("invalid proxy %q: %w", x.options.Proxy, err)
+					return
+				}
+				defaultTransport.Proxy = http.ProxyURL(proxyUrl)
+			}
+			transport = defaultTransport
+		}
+		for i := len(x.options.Middlewares) - 1; i >= 0; i-- {
+			transport = x.options.Middlewares[i](transport)
+		}
+		if x.options.Logger != nil {
+			transport = &loggingTransport{next: transport, repository: x}
+		}
+		if x.options.Metrics != nil {
+			transport = &metricsTransport{next: transport, metrics: x.options.Metrics}
+		}
+		x.roundTripper = transport
+	})
+	return x.roundTripper, x.transportErr
+}
+
+// 设置所有请求都需要的请求头
+func (x *Repository) prepareRequest(request *http.Request) {
+	userAgent := x.options.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	request.Header.Set("User-Agent", userAgent)
+	x.options.Auth.apply(request)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_Middlewares(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "packagist")
+		w.Write([]byte(`{"totals": {"downloads": 1}}`))
+	}))
+	defer server.Close()
+
+	order := make([]string, 0)
+	trace := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				response, err := next.RoundTrip(request)
+				order = append(order, name+" response")
+				return response, err
+			})
+		}
+	}
+	var signature, userAgent, upstream string
+	repo := NewRepository(&Options{
+		ServerUrl: server.URL,
+		Middlewares: []Middleware{
+			trace("outer"),
+			RequestMutator(func(request *http.Request) error {
+				signature = "signed:" + request.URL.Path
+				request.Header.Set("X-Signature", signature)
+				userAgent = request.Header.Get("User-Agent")
+				return nil
+			}),
+			trace("inner"),
+			ResponseInspector(func(response *http.Response) error {
+				upstream = response.Header.Get("X-Upstream")
+				return nil
+			}),
+		},
+	})
+
+	statistics, err := repo.Statistics(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), statistics.Totals.Downloads)
+	assert.Equal(t, []string{"outer request", "inner request", "inner response", "outer response"}, order)
+	assert.Equal(t, "signed:/statistics.json", signature)
+	assert.Equal(t, DefaultUserAgent, userAgent)
+	assert.Equal(t, "packagist", upstream)
+}
+
+func TestRequestMutator(t *testing.T) {
+	var seen http.Header
+	transport := RequestMutator(func(request *http.Request) error {
+		request.Header.Set("X-Added", "1")
+		return nil
+	})(RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		seen = request.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+
+	request, _ := http.NewRequest(http.MethodGet, "https://packagist.org/packages/list.json", nil)
+	_, err := transport.RoundTrip(request)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", seen.Get("X-Added"))
+	// The caller's request is left untouched
+	assert.Empty(t, request.Header.Get("X-Added"))
+
+	denied := errors.New("signing key missing")
+	transport = RequestMutator(func(request *http.Request) error {
+		return denied
+	})(RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		t.Fatal("the request must not be sent")
+		return nil, nil
+	}))
+	_, err = transport.RoundTrip(request)
+	assert.Equal(t, denied, err)
+}
+
+func TestResponseInspector(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html>maintenance</html>`))
+	}))
+	defer server.Close()
+
+	repo := NewRepository(&Options{
+		ServerUrl: server.URL,
+		MaxTries:  1,
+		Middlewares: []Middleware{ResponseInspector(func(response *http.Response) error {
+			if !strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
+				return errors.New("unexpected content type " + response.Header.Get("Content-Type"))
+			}
+			return nil
+		})},
+	})
+	_, err := repo.List(context.Background())
+	assert.ErrorContains(t, err, "unexpected content type text/html")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestRepository_Transport(t *testing.T) {
+	var userAgent string
+	var tries int
+	repo := NewRepository(&Options{
+		UserAgent:  "acme-mirror/1.0 (ops@example.com)",
+		MaxTries:   2,
+		RetryDelay: time.Millisecond,
+		Transport: RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			tries++
+			userAgent = request.Header.Get("User-Agent")
+			return nil, errors.New("connection refused")
+		}),
+	})
+	_, err := repo.Statistics(context.Background())
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 2, tries)
+	assert.Equal(t, "acme-mirror/1.0 (ops@example.com)", userAgent)
+
+	// Cancelled requests are not retried
+	tries = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.Statistics(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, tries)
+}
+
+func TestRepository_InvalidProxy(t *testing.T) {
+	repo := NewRepository(&Options{Proxy: "://bad proxy"})
+	_, err := repo.Statistics(context.Background())
+	assert.ErrorContains(t, err, `invalid proxy "://bad proxy"`)
+
+	_, err = repo.DownloadDist(context.Background(), newDistVersion("zip", "https://dist.example.com/a.zip", ""), &bytes.Buffer{})
+	assert.ErrorContains(t, err, `invalid proxy "://bad proxy"`)
+}
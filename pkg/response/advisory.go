@@ -1,5 +1,16 @@
 package response
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type AdvisoriesResponse struct {
 	Advisories map[string][]*Advisory `json:"advisories"`
 }
@@ -16,9 +27,206 @@ type Advisory struct {
 	ReportedAt         string     `json:"reportedAt"`
 	ComposerRepository string     `json:"composerRepository"`
 	Sources            []*Sources `json:"sources"`
+
+	// 漏洞的严重程度，较早的漏洞可能没有
+	Severity Severity `json:"severity,omitempty"`
+
+	// 模型中没有定义的字段，序列化的时候会原样写回去，保证不丢数据
+	unknownFields map[string]json.RawMessage
+
+	// composerRepository 是对象的时候的原始内容，ComposerRepository 没有被修改过的时候才会原样写回去
+	structuredRepository json.RawMessage
 }
 
 type Sources struct {
 	Name     string `json:"name"`
 	RemoteID string `json:"remoteId"`
 }
+
+// Severity 表示漏洞的严重程度
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Rank 返回严重程度的排序值，越严重值越大，未知的严重程度为 0
+func (x Severity) Rank() int {
+	switch Severity(strings.ToLower(string(x))) {
+	case SeverityLow:
+		return 1
+	case SeverityMedium, "moderate":
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+var (
+	cveRegex  = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+	ghsaRegex = regexp.MustCompile(`^GHSA(-[23456789cfghjmpqrvwx]{4}){3}$`)
+
+	// Packagist 返回的 reportedAt 出现过的各种格式，没有时区的按 UTC 处理
+	reportedAtLayouts = []string{
+		"2006-01-02 15:04:05",
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05 MST",
+		"2006-01-02",
+	}
+)
+
+// ParseReportedAt 解析 reportedAt 字段，兼容 Packagist 使用过的各种时间格式以及 unix 时间戳
+func ParseReportedAt(reportedAt string) (time.Time, error) {
+	reportedAt = strings.TrimSpace(reportedAt)
+	for _, layout := range reportedAtLayouts {
+		if t, err := time.Parse(layout, reportedAt); err == nil {
+			return t, nil
+		}
+	}
+	if timestamp, err := strconv.ParseInt(reportedAt, 10, 64); err == nil {
+		// 毫秒时间戳
+		if timestamp > 1e11 {
+			return time.UnixMilli(timestamp).UTC(), nil
+		}
+		return time.Unix(timestamp, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("can not parse reportedAt %q", reportedAt)
+}
+
+// ReportedAtTime 返回解析后的报告时间，无法解析时返回零值
+func (x *Advisory) ReportedAtTime() time.Time {
+	t, _ := ParseReportedAt(x.ReportedAt)
+	return t
+}
+
+// IsCVE 这个漏洞是否有 CVE 编号
+func (x *Advisory) IsCVE() bool {
+	return cveRegex.MatchString(strings.TrimSpace(x.Cve))
+}
+
+// GHSAID 返回这个漏洞在 GitHub Advisory Database 中的编号，没有时返回空字符串
+func (x *Advisory) GHSAID() string {
+	candidates := []string{x.AdvisoryID, x.RemoteID}
+	for _, source := range x.Sources {
+		candidates = append(candidates, source.RemoteID)
+	}
+	for _, candidate := range candidates {
+		if ghsaRegex.MatchString(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// FixedIn 返回修复了这个漏洞的版本，也就是受影响版本范围的各个上界
+// 比如 >=1.0.0,<1.2.3|>=2.0.0,<2.0.1 返回 1.2.3 和 2.0.1，上界是 <= 的范围说明还没有修复版本
+func (x *Advisory) FixedIn() []string {
+	result := make([]string, 0)
+	for _, alternative := range strings.Split(x.AffectedVersions, "|") {
+		for _, condition := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if strings.HasPrefix(condition, "<") && !strings.HasPrefix(condition, "<=") {
+				result = append(result, strings.TrimSpace(condition[1:]))
+			}
+		}
+	}
+	return result
+}
+
+// advisoryAlias 用来避免 UnmarshalJSON 和 MarshalJSON 的无限递归
+type advisoryAlias Advisory
+
+func (x *Advisory) UnmarshalJSON(data []byte) error {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	// composerRepository 有可能是一个对象，这种情况下只取其中的 url，原始内容保留下来
+	var structuredRepository json.RawMessage
+	if raw, ok := fields["composerRepository"]; ok && len(bytes.TrimSpace(raw)) > 0 && bytes.TrimSpace(raw)[0] == '{' {
+		structuredRepository = raw
+		delete(fields, "composerRepository")
+		data, _ = json.Marshal(fields)
+	}
+
+	alias := advisoryAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*x = Advisory(alias)
+
+	if structuredRepository != nil {
+		url, err := repositoryURL(structuredRepository)
+		if err != nil {
+			return err
+		}
+		x.ComposerRepository = url
+		x.structuredRepository = structuredRepository
+	}
+
+	for _, key := range []string{"advisoryId", "packageName", "remoteId", "title", "link", "cve", "affectedVersions",
+		"source", "reportedAt", "composerRepository", "sources", "severity"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		x.unknownFields = fields
+	}
+	return nil
+}
+
+func (x Advisory) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(advisoryAlias(x))
+	if err != nil || (len(x.unknownFields) == 0 && x.structuredRepository == nil) {
+		return data, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range x.unknownFields {
+		fields[key] = value
+	}
+	// ComposerRepository 被修改过的时候以它为准，原来的对象已经过时了
+	if x.structuredRepository != nil {
+		if url, err := repositoryURL(x.structuredRepository); err == nil && url == x.ComposerRepository {
+			fields["composerRepository"] = x.structuredRepository
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// repositoryURL 取出对象形式的 composerRepository 中的 url
+func repositoryURL(structuredRepository json.RawMessage) (string, error) {
+	repository := struct {
+		URL string `json:"url"`
+	}{}
+	if err := json.Unmarshal(structuredRepository, &repository); err != nil {
+		return "", err
+	}
+	return repository.URL, nil
+}
+
+// UnknownFields 返回模型中没有定义的字段
+func (x *Advisory) UnknownFields() map[string]json.RawMessage {
+	return x.unknownFields
+}
+
+// SortAdvisories 按严重程度从高到低排序，严重程度相同的按报告时间从新到旧排序
+func SortAdvisories(advisories []*Advisory) {
+	sort.SliceStable(advisories, func(i, j int) bool {
+		a, b := advisories[i], advisories[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		return a.ReportedAtTime().After(b.ReportedAtTime())
+	})
+}
@@ -3,6 +3,7 @@ package response
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -307,3 +308,124 @@ func TestSources_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestAdvisory_Severity(t *testing.T) {
+	var advisory *Advisory
+	err := json.Unmarshal([]byte(`{"advisoryId": "PKSA-1", "severity": "high", "cve": null}`), &advisory)
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityHigh, advisory.Severity)
+	assert.Equal(t, 3, advisory.Severity.Rank())
+	assert.Equal(t, "", advisory.Cve)
+
+	assert.Equal(t, 0, Severity("").Rank())
+	assert.Equal(t, 2, Severity("MODERATE").Rank())
+	assert.Equal(t, 4, SeverityCritical.Rank())
+}
+
+func TestParseReportedAt(t *testing.T) {
+	tests := []struct {
+		reportedAt string
+		want       time.Time
+		wantErr    bool
+	}{
+		{reportedAt: "2023-05-22 19:49:11", want: time.Date(2023, 5, 22, 19, 49, 11, 0, time.UTC)},
+		{reportedAt: "2023-05-22T19:49:11+00:00", want: time.Date(2023, 5, 22, 19, 49, 11, 0, time.UTC)},
+		{reportedAt: "2023-05-22T19:49:11", want: time.Date(2023, 5, 22, 19, 49, 11, 0, time.UTC)},
+		{reportedAt: "2023-01-15", want: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{reportedAt: "1684784951", want: time.Date(2023, 5, 22, 19, 49, 11, 0, time.UTC)},
+		{reportedAt: "1684784951000", want: time.Date(2023, 5, 22, 19, 49, 11, 0, time.UTC)},
+		{reportedAt: "yesterday", wantErr: true},
+		{reportedAt: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reportedAt, func(t *testing.T) {
+			got, err := ParseReportedAt(tt.reportedAt)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	advisory := &Advisory{ReportedAt: "2023-05-22 19:49:11"}
+	assert.Equal(t, 2023, advisory.ReportedAtTime().Year())
+	assert.True(t, (&Advisory{ReportedAt: "???"}).ReportedAtTime().IsZero())
+}
+
+func TestAdvisory_Helpers(t *testing.T) {
+	advisory := &Advisory{
+		AdvisoryID:       "PKSA-dmw8-jd8k-q3c6",
+		RemoteID:         "monolog/monolog/2017-11-13-1.yaml",
+		Cve:              "CVE-2017-1000001",
+		AffectedVersions: ">=1.8.0,<1.12.0|>=2.0.0,<=2.0.5|>=3.0.0,<3.0.1",
+		Sources: []*Sources{
+			{Name: "FriendsOfPHP/security-advisories", RemoteID: "monolog/monolog/2017-11-13-1.yaml"},
+			{Name: "GitHub", RemoteID: "GHSA-f7g7-pcxv-cvmx"},
+		},
+	}
+	assert.True(t, advisory.IsCVE())
+	assert.Equal(t, "GHSA-f7g7-pcxv-cvmx", advisory.GHSAID())
+	assert.Equal(t, []string{"1.12.0", "3.0.1"}, advisory.FixedIn())
+
+	empty := &Advisory{Cve: "not-a-cve"}
+	assert.False(t, empty.IsCVE())
+	assert.Equal(t, "", empty.GHSAID())
+	assert.Empty(t, empty.FixedIn())
+}
+
+func TestAdvisory_RoundTrip(t *testing.T) {
+	original := `{
+		"advisoryId": "PKSA-1",
+		"packageName": "vendor/package",
+		"remoteId": "GHSA-f7g7-pcxv-cvmx",
+		"title": "Title",
+		"link": "https://example.com",
+		"cve": "CVE-2023-1234",
+		"affectedVersions": "<1.0",
+		"source": "GitHub",
+		"reportedAt": "2023-05-22 19:49:11",
+		"composerRepository": {"url": "https://packagist.org", "name": "packagist.org"},
+		"severity": "medium",
+		"sources": [{"name": "GitHub", "remoteId": "GHSA-f7g7-pcxv-cvmx"}],
+		"newField": {"nested": [1, 2, 3]}
+	}`
+
+	var advisory *Advisory
+	assert.NoError(t, json.Unmarshal([]byte(original), &advisory))
+	assert.Equal(t, "https://packagist.org", advisory.ComposerRepository)
+	assert.Equal(t, SeverityMedium, advisory.Severity)
+	assert.Contains(t, advisory.UnknownFields(), "newField")
+
+	marshaled, err := json.Marshal(advisory)
+	assert.NoError(t, err)
+	assert.JSONEq(t, original, string(marshaled))
+
+	// Once ComposerRepository changes the stale object is no longer written back
+	advisory.ComposerRepository = "https://repo.example.com"
+	marshaled, err = json.Marshal(advisory)
+	assert.NoError(t, err)
+	fields := make(map[string]json.RawMessage)
+	assert.NoError(t, json.Unmarshal(marshaled, &fields))
+	assert.JSONEq(t, `"https://repo.example.com"`, string(fields["composerRepository"]))
+	assert.JSONEq(t, `{"nested": [1, 2, 3]}`, string(fields["newField"]))
+	assert.NotContains(t, advisory.UnknownFields(), "composerRepository")
+}
+
+func TestSortAdvisories(t *testing.T) {
+	advisories := []*Advisory{
+		{AdvisoryID: "old-high", Severity: SeverityHigh, ReportedAt: "2020-01-01 00:00:00"},
+		{AdvisoryID: "unknown", ReportedAt: "2023-01-01 00:00:00"},
+		{AdvisoryID: "critical", Severity: SeverityCritical, ReportedAt: "2019-01-01 00:00:00"},
+		{AdvisoryID: "new-high", Severity: SeverityHigh, ReportedAt: "2022-01-01 00:00:00"},
+	}
+	SortAdvisories(advisories)
+
+	ids := make([]string, 0)
+	for _, advisory := range advisories {
+		ids = append(ids, advisory.AdvisoryID)
+	}
+	assert.Equal(t, []string{"critical", "new-high", "old-high", "unknown"}, ids)
+}
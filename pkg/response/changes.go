@@ -0,0 +1,35 @@
+package response
+
+// ChangeActionType 表示包元数据变化的类型
+type ChangeActionType string
+
+const (
+	// ChangeActionUpdate 包的元数据有更新
+	ChangeActionUpdate ChangeActionType = "update"
+
+	// ChangeActionDelete 包被删除了
+	ChangeActionDelete ChangeActionType = "delete"
+
+	// ChangeActionResync since 太旧了，需要重新同步全部的包
+	ChangeActionResync ChangeActionType = "resync"
+)
+
+// ChangesResponse 表示元数据变化的增量信息
+type ChangesResponse struct {
+	Actions []*ChangeAction `json:"actions"`
+
+	// 下一次请求时作为 since 使用的时间戳，单位是 1/10000 秒
+	Timestamp int64 `json:"timestamp"`
+
+	// since 缺失或者无效的时候会返回错误信息，此时 Timestamp 是当前的时间戳
+	Error string `json:"error"`
+}
+
+// ChangeAction 表示一个包的一次变化
+type ChangeAction struct {
+	Type    ChangeActionType `json:"type"`
+	Package string           `json:"package"`
+
+	// 变化发生的时间，单位是秒
+	Time int64 `json:"time"`
+}
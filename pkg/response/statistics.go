@@ -8,3 +8,20 @@ type Totals struct {
 	Packages  int   `json:"packages"`
 	Versions  int   `json:"versions"`
 }
+
+// PackageStatisticsResponse 表示单个包的下载统计
+type PackageStatisticsResponse struct {
+	Downloads PackageDownloads `json:"downloads"`
+
+	// 有统计信息的版本
+	Versions []string `json:"versions"`
+
+	// 统计的日期
+	Date string `json:"date"`
+}
+
+type PackageDownloads struct {
+	Total   int64 `json:"total"`
+	Monthly int64 `json:"monthly"`
+	Daily   int64 `json:"daily"`
+}
@@ -0,0 +1,361 @@
+package risk
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/diff"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// SignalKind 表示风险信号的类型
+type SignalKind string
+
+const (
+	// SignalMaintainerAdded 和之前的快照相比新增了维护者
+	SignalMaintainerAdded SignalKind = "maintainer_added"
+
+	// SignalSourceURLChanged 相邻两个版本的源码仓库地址不同
+	SignalSourceURLChanged SignalKind = "source_url_changed"
+
+	// SignalReferenceMismatch 同一个版本的 dist reference 和 source reference 不一致
+	SignalReferenceMismatch SignalKind = "reference_mismatch"
+
+	// SignalDormantReleaseByNewMaintainer 沉寂很久之后由新的维护者发布了版本
+	SignalDormantReleaseByNewMaintainer SignalKind = "dormant_release_by_new_maintainer"
+
+	// SignalInstallHooks 安装时会执行代码，比如 composer 插件或者声明了 scripts
+	SignalInstallHooks SignalKind = "install_hooks"
+
+	// SignalNoLicense 没有声明许可证
+	SignalNoLicense SignalKind = "no_license"
+)
+
+// DefaultWeights 各种信号默认的分值
+var DefaultWeights = map[SignalKind]int{
+	SignalMaintainerAdded:               25,
+	SignalSourceURLChanged:              30,
+	SignalReferenceMismatch:             20,
+	SignalDormantReleaseByNewMaintainer: 35,
+	SignalInstallHooks:                  15,
+	SignalNoLicense:                     10,
+}
+
+const (
+	// DefaultDormancy 默认超过这么久没有发布版本算作沉寂
+	DefaultDormancy = 365 * 24 * time.Hour
+)
+
+// Level 表示风险等级
+type Level string
+
+const (
+	LevelNone   Level = "none"
+	LevelLow    Level = "low"
+	LevelMedium Level = "medium"
+	LevelHigh   Level = "high"
+)
+
+// Options 风险分析的配置
+type Options struct {
+
+	// 之前的快照，用来发现维护者的变化，为空的时候不检查维护者变化
+	Previous *composer_crawler.ComposerPackageInfo
+
+	// 超过这么久没有发布版本算作沉寂，为空的时候使用 DefaultDormancy
+	Dormancy time.Duration
+
+	// 覆盖默认的分值，设置为 0 表示忽略这种信号
+	Weights map[SignalKind]int
+}
+
+// Signal 表示一个风险信号
+type Signal struct {
+	Kind SignalKind `json:"kind"`
+
+	// 信号所在的版本，包级别的信号为空
+	Version string `json:"version,omitempty"`
+
+	Score       int    `json:"score"`
+	Explanation string `json:"explanation"`
+}
+
+// Report 表示一个包或者一个版本的风险报告
+type Report struct {
+	Package string `json:"package"`
+
+	// 只分析一个版本的时候才有值
+	Version string `json:"version,omitempty"`
+
+	// 总分，每种信号只计算一次最高分，最高 100
+	Score   int       `json:"score"`
+	Level   Level     `json:"level"`
+	Signals []*Signal `json:"signals"`
+}
+
+// Analyze 分析包的所有版本，返回包级别的风险报告
+func Analyze(info *composer_crawler.ComposerPackageInfo, options *Options) *Report {
+	if options == nil {
+		options = &Options{}
+	}
+	analyzer := &analyzer{info: info, options: options}
+	return newReport(info.Package.Name, "", analyzer.signals())
+}
+
+// AnalyzeVersion 只分析给定的版本，包级别的信号也会包含在内
+func AnalyzeVersion(info *composer_crawler.ComposerPackageInfo, version string, options *Options) (*Report, error) {
+	if _, ok := info.Package.Versions[version]; !ok {
+		return nil, fmt.Errorf("version %s not found in package %s", version, info.Package.Name)
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	analyzer := &analyzer{info: info, options: options}
+	signals := make([]*Signal, 0)
+	for _, signal := range analyzer.signals() {
+		if signal.Version == "" || signal.Version == version {
+			signals = append(signals, signal)
+		}
+	}
+	return newReport(info.Package.Name, version, signals), nil
+}
+
+// Explain 返回人类可读的解释，每个信号一行
+func (x *Report) Explain() string {
+	lines := make([]string, 0, len(x.Signals)+1)
+	lines = append(lines, fmt.Sprintf("%s: score %d (%s)", x.Package, x.Score, x.Level))
+	for _, signal := range x.Signals {
+		lines = append(lines, fmt.Sprintf("  +%d %s", signal.Score, signal.Explanation))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newReport(packageName, version string, signals []*Signal) *Report {
+	sort.SliceStable(signals, func(i, j int) bool {
+		if signals[i].Score != signals[j].Score {
+			return signals[i].Score > signals[j].Score
+		}
+		return signals[i].Kind < signals[j].Kind
+	})
+
+	maxScores := make(map[SignalKind]int)
+	for _, signal := range signals {
+		if signal.Score > maxScores[signal.Kind] {
+			maxScores[signal.Kind] = signal.Score
+		}
+	}
+	score := 0
+	for _, s := range maxScores {
+		score += s
+	}
+	if score > 100 {
+		score = 100
+	}
+	return &Report{Package: packageName, Version: version, Score: score, Level: levelOf(score), Signals: signals}
+}
+
+func levelOf(score int) Level {
+	switch {
+	case score == 0:
+		return LevelNone
+	case score < 20:
+		return LevelLow
+	case score < 50:
+		return LevelMedium
+	default:
+		return LevelHigh
+	}
+}
+
+type analyzer struct {
+	info    *composer_crawler.ComposerPackageInfo
+	options *Options
+}
+
+// 按发布时间排序的非 dev 版本
+type release struct {
+	name    string
+	version *composer_crawler.Version
+}
+
+func (x *analyzer) signals() []*Signal {
+	signals := make([]*Signal, 0)
+	releases := x.releases()
+
+	addedMaintainers := make([]string, 0)
+	if x.options.Previous != nil {
+		for _, change := range diff.Packages(x.options.Previous, x.info).Filter(diff.ChangeMaintainerAdded) {
+			addedMaintainers = append(addedMaintainers, change.Name)
+			signals = x.add(signals, SignalMaintainerAdded, "", "maintainer %q was added since the previous snapshot", change.Name)
+		}
+	}
+
+	for i, current := range releases {
+		version := current.version
+		if version.Dist.Reference != "" && version.Source.Reference != "" && version.Dist.Reference != version.Source.Reference {
+			signals = x.add(signals, SignalReferenceMismatch, current.name,
+				"dist reference %s does not match source reference %s", version.Dist.Reference, version.Source.Reference)
+		}
+		if i == 0 {
+			continue
+		}
+
+		previous := releases[i-1]
+		oldURL, newURL := normalizeURL(previous.version.Source.URL), normalizeURL(version.Source.URL)
+		if oldURL != "" && newURL != "" && oldURL != newURL {
+			signals = x.add(signals, SignalSourceURLChanged, current.name,
+				"source repository changed from %s in %s to %s", previous.version.Source.URL, previous.name, version.Source.URL)
+		}
+
+		gap := version.Time.Sub(previous.version.Time)
+		if !previous.version.Time.IsZero() && gap >= x.dormancy() {
+			newAuthors := newNames(authorNames(previous.version), authorNames(version))
+			if len(newAuthors) > 0 || (i == len(releases)-1 && len(addedMaintainers) > 0) {
+				who := append(newAuthors, addedMaintainers...)
+				signals = x.add(signals, SignalDormantReleaseByNewMaintainer, current.name,
+					"released %d days after %s by new maintainer(s) %s", int(gap.Hours()/24), previous.name, strings.Join(unique(who), ", "))
+			}
+		}
+	}
+
+	for _, name := range sortedVersionNames(x.info.Package.Versions) {
+		version := x.info.Package.Versions[name]
+		if hooks := installHooks(version); len(hooks) > 0 {
+			signals = x.add(signals, SignalInstallHooks, name, "runs code during installation: %s", strings.Join(hooks, ", "))
+		}
+	}
+
+	if len(releases) > 0 {
+		latest := releases[len(releases)-1]
+		if len(latest.version.License) == 0 {
+			signals = x.add(signals, SignalNoLicense, latest.name, "latest release %s declares no license", latest.name)
+		}
+	}
+	return signals
+}
+
+func (x *analyzer) add(signals []*Signal, kind SignalKind, version string, format string, args ...interface{}) []*Signal {
+	score := x.weight(kind)
+	if score <= 0 {
+		return signals
+	}
+	return append(signals, &Signal{Kind: kind, Version: version, Score: score, Explanation: fmt.Sprintf(format, args...)})
+}
+
+func (x *analyzer) weight(kind SignalKind) int {
+	if weight, ok := x.options.Weights[kind]; ok {
+		return weight
+	}
+	return DefaultWeights[kind]
+}
+
+func (x *analyzer) dormancy() time.Duration {
+	if x.options.Dormancy > 0 {
+		return x.options.Dormancy
+	}
+	return DefaultDormancy
+}
+
+func (x *analyzer) releases() []*release {
+	releases := make([]*release, 0)
+	for name, version := range x.info.Package.Versions {
+		if version == nil {
+			continue
+		}
+		if v, err := semver.Parse(name); err == nil && v.Stability() == semver.StabilityDev {
+			continue
+		}
+		releases = append(releases, &release{name: name, version: version})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if !releases[i].version.Time.Equal(releases[j].version.Time) {
+			return releases[i].version.Time.Before(releases[j].version.Time)
+		}
+		return releases[i].name < releases[j].name
+	})
+	return releases
+}
+
+// 安装时会执行代码的地方
+func installHooks(version *composer_crawler.Version) []string {
+	hooks := make([]string, 0)
+	if version == nil {
+		return hooks
+	}
+	if version.Type == "composer-plugin" {
+		hooks = append(hooks, "composer-plugin")
+	}
+	var class interface{}
+	if ok, err := version.ExtraValue("class", &class); err == nil && ok && class != nil {
+		hooks = append(hooks, "extra.class")
+	}
+	for _, name := range version.ScriptNames() {
+		hooks = append(hooks, "scripts."+name)
+	}
+	return hooks
+}
+
+func authorNames(version *composer_crawler.Version) map[string]bool {
+	names := make(map[string]bool)
+	for _, author := range version.Authors {
+		if author.Name != "" {
+			names[strings.ToLower(author.Name)] = true
+		}
+	}
+	return names
+}
+
+// 在 new 中但是不在 old 中的名字，old 为空的时候无法判断，返回空
+func newNames(old, new map[string]bool) []string {
+	result := make([]string, 0)
+	if len(old) == 0 {
+		return result
+	}
+	for name := range new {
+		if !old[name] {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func unique(names []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// 忽略协议、端口、大小写以及 .git 后缀的差异，git@host:path 这样的 scp 风格地址只有主机名后面的冒号会换成 /
+func normalizeURL(rawURL string) string {
+	rawURL = strings.ToLower(strings.TrimSpace(rawURL))
+	var normalized string
+	if at, colon := strings.Index(rawURL, "@"), strings.Index(rawURL, ":"); !strings.Contains(rawURL, "://") && at >= 0 && colon > at {
+		normalized = rawURL[at+1:colon] + "/" + rawURL[colon+1:]
+	} else if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		normalized = parsed.Hostname() + parsed.Path
+	} else {
+		normalized = rawURL
+	}
+	normalized = strings.TrimSuffix(normalized, "/")
+	return strings.TrimSuffix(normalized, ".git")
+}
+
+func sortedVersionNames(versions map[string]*composer_crawler.Version) []string {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
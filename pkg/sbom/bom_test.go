@@ -0,0 +1,136 @@
+package sbom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLock = `{
+	"content-hash": "d751713988987e9331980363e24189ce",
+	"packages": [
+		{
+			"name": "monolog/monolog",
+			"version": "2.9.1",
+			"source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git", "reference": "f259e2b15fb95494c83f52d3caad003bbf5ffaa1"},
+			"dist": {"type": "zip", "url": "https://api.github.com/repos/Seldaek/monolog/zipball/f259e2b15fb95494c83f52d3caad003bbf5ffaa1", "reference": "f259e2b15fb95494c83f52d3caad003bbf5ffaa1", "shasum": ""},
+			"require": {"php": ">=7.2", "psr/log": "^1.0.1 || ^2.0 || ^3.0"},
+			"license": ["MIT"]
+		},
+		{
+			"name": "psr/log",
+			"version": "3.0.0",
+			"dist": {"type": "zip", "url": "https://api.github.com/repos/php-fig/log/zipball/fe5ea303b0887d5caefd3d431c3e61ad47037001"}
+		}
+	],
+	"packages-dev": [
+		{
+			"name": "acme/private-tool",
+			"version": "1.0.0",
+			"dist": {"type": "path", "url": "../tool"},
+			"license": ["proprietary"]
+		}
+	]
+}`
+
+func newTestBOM(t *testing.T) *BOM {
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+	composerJSON, err := manifest.ParseComposerJSON([]byte(`{
+		"name": "acme/shop",
+		"version": "1.0.0",
+		"require": {"monolog/monolog": "^2.0"},
+		"require-dev": {"acme/private-tool": "*"}
+	}`))
+	assert.NoError(t, err)
+	return FromLock(lock, composerJSON)
+}
+
+func TestFromLock(t *testing.T) {
+	bom := newTestBOM(t)
+	assert.Equal(t, "acme/shop", bom.Name)
+	assert.Equal(t, "1.0.0", bom.Version)
+	assert.Equal(t, []string{"acme/private-tool", "monolog/monolog"}, bom.DirectDependencies)
+	if assert.Len(t, bom.Components, 3) {
+		monolog := bom.Components[0]
+		assert.Equal(t, []string{"psr/log"}, monolog.Dependencies)
+		assert.Equal(t, "f259e2b15fb95494c83f52d3caad003bbf5ffaa1", monolog.SourceReference)
+		assert.False(t, monolog.Dev)
+		assert.True(t, bom.Components[2].Dev)
+	}
+
+	// Without composer.json the packages that nobody requires are the direct dependencies
+	lock, err := manifest.ParseComposerLock([]byte(testLock))
+	assert.NoError(t, err)
+	bom = FromLock(lock, nil)
+	assert.Equal(t, "", bom.Name)
+	assert.Equal(t, []string{"acme/private-tool", "monolog/monolog"}, bom.DirectDependencies)
+}
+
+func TestBOM_Enrich(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/monolog/monolog.json":
+			w.Write([]byte(`{"package": {"name": "monolog/monolog", "versions": {
+				"2.9.1": {
+					"version": "2.9.1",
+					"version_normalized": "2.9.1.0",
+					"license": ["MIT"],
+					"authors": [{"name": "Jordi Boggiano", "email": "j.boggiano@seld.be"}],
+					"dist": {"type": "zip", "url": "https://example.com/monolog.zip", "shasum": "0123456789abcdef0123456789abcdef01234567"},
+					"source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git", "reference": "f259e2b15fb95494c83f52d3caad003bbf5ffaa1"}
+				}
+			}}}`))
+		case "/packages/psr/log.json":
+			w.Write([]byte(`{"package": {"name": "psr/log", "versions": {
+				"3.0.0": {"version": "3.0.0", "version_normalized": "3.0.0.0", "license": ["MIT"], "description": "Common interface for logging libraries"}
+			}}}`))
+		case "/api/security-advisories/":
+			w.Write([]byte(`{"advisories": {"monolog/monolog": [
+				{"advisoryId": "PKSA-1", "packageName": "monolog/monolog", "title": "Header injection", "cve": "CVE-2023-0001", "affectedVersions": ">=2.0.0,<2.9.2", "reportedAt": "2023-05-22 19:49:11"},
+				{"advisoryId": "PKSA-2", "packageName": "monolog/monolog", "title": "Old issue", "affectedVersions": ">=1.0.0,<1.12.0"}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":"error","message":"Package not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	bom := newTestBOM(t)
+	assert.NoError(t, bom.Enrich(context.Background(), repository.NewRepository(&repository.Options{ServerUrl: server.URL})))
+
+	monolog := bom.Components[0]
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", monolog.DistShasum)
+	assert.Equal(t, "Jordi Boggiano", monolog.Authors[0].Name)
+	assert.Len(t, monolog.Advisories, 2)
+	assert.True(t, monolog.Affects(monolog.Advisories[0]))
+	assert.False(t, monolog.Affects(monolog.Advisories[1]))
+
+	psrLog := bom.Components[1]
+	assert.Equal(t, []string{"MIT"}, psrLog.Licenses)
+	assert.Equal(t, "Common interface for logging libraries", psrLog.Description)
+	assert.Empty(t, psrLog.Advisories)
+
+	// Private packages are skipped
+	assert.Equal(t, []string{"proprietary"}, bom.Components[2].Licenses)
+}
+
+func TestComponent_Affects(t *testing.T) {
+	component := &Component{Name: "vendor/package", Version: "v1.5.0"}
+	assert.True(t, component.Affects(&response.Advisory{AffectedVersions: ">=1.0.0,<1.6.0|>=2.0.0,<2.0.3"}))
+	assert.False(t, component.Affects(&response.Advisory{AffectedVersions: ">=2.0.0,<2.0.3"}))
+	assert.True(t, component.Affects(&response.Advisory{AffectedVersions: "not a constraint ???"}))
+}
+
+func TestPURL(t *testing.T) {
+	assert.Equal(t, "pkg:composer/symfony/console@v6.2.0", PURL("symfony/console", "v6.2.0"))
+	assert.Equal(t, "pkg:composer/laravel/framework@dev-feature%2Ffoo", PURL("Laravel/Framework", "dev-feature/foo"))
+	assert.Equal(t, "pkg:composer/acme/shop", PURL("acme/shop", ""))
+}
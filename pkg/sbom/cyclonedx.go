@@ -0,0 +1,307 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+)
+
+// CycloneDX 1.5 JSON 格式的文档
+// https://cyclonedx.org/docs/1.5/json/
+type cycloneDXDocument struct {
+	BomFormat       string                    `json:"bomFormat"`
+	SpecVersion     string                    `json:"specVersion"`
+	SerialNumber    string                    `json:"serialNumber"`
+	Version         int                       `json:"version"`
+	Metadata        *cycloneDXMetadata        `json:"metadata"`
+	Components      []*cycloneDXComponent     `json:"components"`
+	Dependencies    []*cycloneDXDependency    `json:"dependencies"`
+	Vulnerabilities []*cycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp"`
+	Tools     *cycloneDXTools     `json:"tools"`
+	Component *cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []*cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                `json:"type"`
+	BomRef             string                `json:"bom-ref,omitempty"`
+	Author             string                `json:"author,omitempty"`
+	Group              string                `json:"group,omitempty"`
+	Name               string                `json:"name"`
+	Version            string                `json:"version,omitempty"`
+	Description        string                `json:"description,omitempty"`
+	Scope              string                `json:"scope,omitempty"`
+	Hashes             []*cycloneDXHash      `json:"hashes,omitempty"`
+	Licenses           []*cycloneDXLicense   `json:"licenses,omitempty"`
+	Purl               string                `json:"purl,omitempty"`
+	ExternalReferences []*cycloneDXReference `json:"externalReferences,omitempty"`
+	Properties         []*cycloneDXProperty  `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXLicense struct {
+	License *cycloneDXLicenseInfo `json:"license"`
+}
+
+type cycloneDXLicenseInfo struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type cycloneDXReference struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type cycloneDXVulnerability struct {
+	BomRef      string                    `json:"bom-ref,omitempty"`
+	ID          string                    `json:"id"`
+	Source      *cycloneDXSource          `json:"source,omitempty"`
+	References  []*cycloneDXVulnReference `json:"references,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Ratings     []*cycloneDXRating        `json:"ratings,omitempty"`
+	Published   string                    `json:"published,omitempty"`
+	Analysis    *cycloneDXAnalysis        `json:"analysis,omitempty"`
+	Affects     []*cycloneDXAffect        `json:"affects"`
+}
+
+type cycloneDXRating struct {
+	Source   *cycloneDXSource `json:"source,omitempty"`
+	Severity string           `json:"severity"`
+}
+
+type cycloneDXSource struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cycloneDXVulnReference struct {
+	ID     string           `json:"id"`
+	Source *cycloneDXSource `json:"source"`
+}
+
+type cycloneDXAnalysis struct {
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type cycloneDXAffect struct {
+	Ref      string                  `json:"ref"`
+	Versions []*cycloneDXAffectRange `json:"versions,omitempty"`
+}
+
+type cycloneDXAffectRange struct {
+	Version string `json:"version,omitempty"`
+	Range   string `json:"range,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// WriteCycloneDX 把物料清单以 CycloneDX 1.5 JSON 格式写出，已知的安全公告会作为 VEX 写到 vulnerabilities 中
+func (x *BOM) WriteCycloneDX(w io.Writer) error {
+	// 没有名字的项目没法生成有意义的 purl，只给一个 bom-ref
+	root := &cycloneDXComponent{Type: "application", BomRef: unnamedRoot, Name: x.rootName(), Version: x.Version}
+	if x.Name != "" {
+		root.Purl = PURL(x.Name, x.Version)
+		root.BomRef = root.Purl
+	}
+	rootRef := root.BomRef
+	document := &cycloneDXDocument{
+		BomFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: &cycloneDXMetadata{
+			Timestamp: x.Timestamp.UTC().Format(time.RFC3339),
+			Tools: &cycloneDXTools{
+				Components: []*cycloneDXComponent{{Type: "application", Group: "scagogogo", Name: "composer-crawler"}},
+			},
+			Component: root,
+		},
+		Components:   make([]*cycloneDXComponent, 0, len(x.Components)),
+		Dependencies: make([]*cycloneDXDependency, 0, len(x.Components)+1),
+	}
+
+	refs := make(map[string]string)
+	for _, component := range x.Components {
+		refs[strings.ToLower(component.Name)] = component.PURL()
+	}
+
+	document.Dependencies = append(document.Dependencies, &cycloneDXDependency{Ref: rootRef, DependsOn: refsOf(x.DirectDependencies, refs)})
+	for _, component := range x.Components {
+		document.Components = append(document.Components, component.toCycloneDX())
+		document.Dependencies = append(document.Dependencies, &cycloneDXDependency{
+			Ref:       component.PURL(),
+			DependsOn: refsOf(component.Dependencies, refs),
+		})
+		for _, advisory := range component.Advisories {
+			document.Vulnerabilities = append(document.Vulnerabilities, component.vulnerabilityToCycloneDX(advisory))
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func refsOf(names []string, refs map[string]string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if ref, ok := refs[strings.ToLower(name)]; ok {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+func (x *Component) toCycloneDX() *cycloneDXComponent {
+	group, name := splitPackageName(x.Name)
+	component := &cycloneDXComponent{
+		Type:        "library",
+		BomRef:      x.PURL(),
+		Group:       group,
+		Name:        name,
+		Version:     x.Version,
+		Description: x.Description,
+		Scope:       "required",
+		Purl:        x.PURL(),
+	}
+	if x.Dev {
+		component.Scope = "optional"
+	}
+
+	authors := make([]string, 0, len(x.Authors))
+	for _, author := range x.Authors {
+		if author.Email != "" {
+			authors = append(authors, fmt.Sprintf("%s <%s>", author.Name, author.Email))
+		} else {
+			authors = append(authors, author.Name)
+		}
+	}
+	component.Author = strings.Join(authors, ", ")
+
+	if x.DistShasum != "" {
+		component.Hashes = append(component.Hashes, &cycloneDXHash{Alg: "SHA-1", Content: x.DistShasum})
+	}
+
+	for _, license := range x.Licenses {
+		item := &cycloneDXLicense{License: &cycloneDXLicenseInfo{}}
+		if id := spdxLicenseId(license); id != "" {
+			item.License.ID = id
+		} else {
+			item.License.Name = license
+		}
+		component.Licenses = append(component.Licenses, item)
+	}
+
+	if x.SourceURL != "" {
+		component.ExternalReferences = append(component.ExternalReferences, &cycloneDXReference{Type: "vcs", URL: x.SourceURL})
+	}
+	if x.DistURL != "" {
+		component.ExternalReferences = append(component.ExternalReferences, &cycloneDXReference{Type: "distribution", URL: x.DistURL})
+	}
+	if x.Homepage != "" {
+		component.ExternalReferences = append(component.ExternalReferences, &cycloneDXReference{Type: "website", URL: x.Homepage})
+	}
+
+	if x.SourceReference != "" {
+		component.Properties = append(component.Properties, &cycloneDXProperty{Name: "composer:source:reference", Value: x.SourceReference})
+	}
+	if x.DistReference != "" {
+		component.Properties = append(component.Properties, &cycloneDXProperty{Name: "composer:dist:reference", Value: x.DistReference})
+	}
+	return component
+}
+
+func (x *Component) vulnerabilityToCycloneDX(advisory *response.Advisory) *cycloneDXVulnerability {
+	vulnerability := &cycloneDXVulnerability{
+		BomRef:      advisory.AdvisoryID + "@" + x.PURL(),
+		ID:          advisory.AdvisoryID,
+		Source:      &cycloneDXSource{Name: "Packagist", URL: advisory.Link},
+		Description: advisory.Title,
+		Affects:     []*cycloneDXAffect{{Ref: x.PURL()}},
+	}
+	affected := x.Affects(advisory)
+	if versRange, ok := VersRange(advisory.AffectedVersions); ok {
+		vulnerability.Affects[0].Versions = []*cycloneDXAffectRange{{Range: versRange, Status: "affected"}}
+	} else {
+		// 没法用 vers 表示的约束只给出安装的版本是否受影响，原始的约束在 analysis.detail 中
+		status := "unaffected"
+		if affected {
+			status = "affected"
+		}
+		vulnerability.Affects[0].Versions = []*cycloneDXAffectRange{{Version: x.Version, Status: status}}
+	}
+	if advisory.Cve != "" {
+		vulnerability.ID = advisory.Cve
+		vulnerability.References = append(vulnerability.References, &cycloneDXVulnReference{
+			ID:     advisory.AdvisoryID,
+			Source: &cycloneDXSource{Name: "Packagist", URL: advisory.Link},
+		})
+		vulnerability.Source = &cycloneDXSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + advisory.Cve}
+	}
+	for _, source := range advisory.Sources {
+		if source.RemoteID == "" || source.RemoteID == vulnerability.ID {
+			continue
+		}
+		vulnerability.References = append(vulnerability.References, &cycloneDXVulnReference{
+			ID:     source.RemoteID,
+			Source: &cycloneDXSource{Name: source.Name},
+		})
+	}
+	if advisory.Severity.Rank() > 0 {
+		vulnerability.Ratings = append(vulnerability.Ratings, &cycloneDXRating{
+			Source:   &cycloneDXSource{Name: "Packagist"},
+			Severity: strings.ToLower(string(advisory.Severity)),
+		})
+	}
+	if t := advisory.ReportedAtTime(); !t.IsZero() {
+		vulnerability.Published = t.UTC().Format(time.RFC3339)
+	}
+
+	if affected {
+		vulnerability.Analysis = &cycloneDXAnalysis{
+			State:  "in_triage",
+			Detail: fmt.Sprintf("installed version %s is within the affected range %s", x.Version, advisory.AffectedVersions),
+		}
+	} else {
+		// 只比较了版本范围，没有分析代码，所以不给出 justification
+		vulnerability.Analysis = &cycloneDXAnalysis{
+			State:  "not_affected",
+			Detail: fmt.Sprintf("version range check only: installed version %s is outside the affected range %s", x.Version, advisory.AffectedVersions),
+		}
+	}
+	return vulnerability
+}
+
+func splitPackageName(packageName string) (string, string) {
+	if index := strings.Index(packageName, "/"); index >= 0 {
+		return packageName[:index], packageName[index+1:]
+	}
+	return "", packageName
+}
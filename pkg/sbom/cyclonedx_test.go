@@ -0,0 +1,121 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBOM_WriteCycloneDX(t *testing.T) {
+	bom := newTestBOM(t)
+	bom.Components[0].DistShasum = "0123456789abcdef0123456789abcdef01234567"
+	bom.Components[0].Advisories = []*response.Advisory{
+		{AdvisoryID: "PKSA-1", Title: "Header injection", Cve: "CVE-2023-0001", AffectedVersions: ">=2.0.0,<2.9.2", Link: "https://example.com/PKSA-1",
+			Severity: response.SeverityHigh, ReportedAt: "2023-05-22T19:49:11+00:00",
+			Sources: []*response.Sources{{Name: "GitHub", RemoteID: "GHSA-xxxx-yyyy-zzzz"}}},
+		{AdvisoryID: "PKSA-2", Title: "Old issue", AffectedVersions: ">=1.0.0,<1.12.0"},
+	}
+
+	buff := &bytes.Buffer{}
+	assert.NoError(t, bom.WriteCycloneDX(buff))
+
+	document := &cycloneDXDocument{}
+	assert.NoError(t, json.Unmarshal(buff.Bytes(), document))
+	assert.Equal(t, "CycloneDX", document.BomFormat)
+	assert.Equal(t, "1.5", document.SpecVersion)
+	assert.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, document.SerialNumber)
+	assert.Equal(t, "pkg:composer/acme/shop@1.0.0", document.Metadata.Component.BomRef)
+
+	if assert.Len(t, document.Components, 3) {
+		monolog := document.Components[0]
+		assert.Equal(t, "monolog", monolog.Group)
+		assert.Equal(t, "monolog", monolog.Name)
+		assert.Equal(t, "pkg:composer/monolog/monolog@2.9.1", monolog.Purl)
+		assert.Equal(t, "required", monolog.Scope)
+		assert.Equal(t, []*cycloneDXHash{{Alg: "SHA-1", Content: "0123456789abcdef0123456789abcdef01234567"}}, monolog.Hashes)
+		assert.Equal(t, "MIT", monolog.Licenses[0].License.ID)
+
+		tool := document.Components[2]
+		assert.Equal(t, "optional", tool.Scope)
+		assert.Equal(t, "proprietary", tool.Licenses[0].License.Name)
+	}
+
+	assert.Equal(t, []string{"pkg:composer/acme/private-tool@1.0.0", "pkg:composer/monolog/monolog@2.9.1"}, document.Dependencies[0].DependsOn)
+	assert.Equal(t, []string{"pkg:composer/psr/log@3.0.0"}, document.Dependencies[1].DependsOn)
+
+	if assert.Len(t, document.Vulnerabilities, 2) {
+		affected := document.Vulnerabilities[0]
+		assert.Equal(t, "CVE-2023-0001", affected.ID)
+		assert.Equal(t, "in_triage", affected.Analysis.State)
+		assert.Equal(t, "pkg:composer/monolog/monolog@2.9.1", affected.Affects[0].Ref)
+		assert.Equal(t, "vers:composer/>=2.0.0|<2.9.2", affected.Affects[0].Versions[0].Range)
+		assert.Len(t, affected.References, 2)
+		assert.Equal(t, "high", affected.Ratings[0].Severity)
+		assert.Equal(t, "2023-05-22T19:49:11Z", affected.Published)
+
+		notAffected := document.Vulnerabilities[1]
+		assert.Equal(t, "PKSA-2", notAffected.ID)
+		assert.Equal(t, "not_affected", notAffected.Analysis.State)
+		assert.Equal(t, "version range check only: installed version 2.9.1 is outside the affected range >=1.0.0,<1.12.0", notAffected.Analysis.Detail)
+		assert.NotContains(t, buff.String(), "justification")
+		assert.Empty(t, notAffected.Ratings)
+	}
+}
+
+func TestBOM_WriteCycloneDX_Unnamed(t *testing.T) {
+	bom := newTestBOM(t)
+	bom.Name = ""
+	bom.Components[0].Advisories = []*response.Advisory{
+		{AdvisoryID: "PKSA-1", AffectedVersions: ">=2.0.0,<2.9.2,!=2.5.0"},
+	}
+
+	buff := &bytes.Buffer{}
+	assert.NoError(t, bom.WriteCycloneDX(buff))
+	document := &cycloneDXDocument{}
+	assert.NoError(t, json.Unmarshal(buff.Bytes(), document))
+
+	// An unnamed project gets a plain bom-ref instead of a made up purl
+	assert.Equal(t, "root", document.Metadata.Component.BomRef)
+	assert.Equal(t, "root", document.Metadata.Component.Name)
+	assert.Empty(t, document.Metadata.Component.Purl)
+	assert.Equal(t, "root", document.Dependencies[0].Ref)
+
+	// Constraints vers cannot express fall back to the installed version
+	if assert.Len(t, document.Vulnerabilities, 1) {
+		versions := document.Vulnerabilities[0].Affects[0].Versions
+		assert.Equal(t, []*cycloneDXAffectRange{{Version: "2.9.1", Status: "affected"}}, versions)
+	}
+}
+
+func TestVersRange(t *testing.T) {
+	cases := []struct {
+		constraint string
+		expected   string
+		ok         bool
+	}{
+		{"*", "vers:composer/*", true},
+		{">=2.0.0,<2.9.2", "vers:composer/>=2.0.0|<2.9.2", true},
+		{"<1.0", "vers:composer/<1.0", true},
+		{">1.0", "vers:composer/>1.0", true},
+		{"<=1.2.3", "vers:composer/<=1.2.3", true},
+		{"1.2.3", "vers:composer/1.2.3", true},
+		{">=3.0,<3.1|>=2.0,<2.5", "vers:composer/>=2.0|<2.5|>=3.0|<3.1", true},
+		{"<1.0|>=2.0", "vers:composer/<1.0|>=2.0", true},
+		{"1.0.0|1.0.1", "vers:composer/1.0.0|1.0.1", true},
+		{">=1.0,<2.0,!=1.5.0", "", false},
+		{">=1.0,<2.0|>=1.5,<3.0", "", false},
+		{">=1.0|>=2.0", "", false},
+		{"dev-main", "", false},
+		{"not a constraint", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.constraint, func(t *testing.T) {
+			versRange, ok := VersRange(c.constraint)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.expected, versRange)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package sbom
+
+import (
+	"github.com/scagogogo/composer-crawler/pkg/license"
+)
+
+// spdxLicenseId 返回 license 对应的 SPDX id，废弃的 id 和常见的别名会被转换为标准的 id，不认识的返回空字符串
+func spdxLicenseId(licenseName string) string {
+	return license.Lookup(licenseName)
+}
+
+// spdxLicenseExpression 把 composer 的 license 列表转换为 SPDX 表达式，composer 中多个 license 表示可以任选其一
+func spdxLicenseExpression(licenses []string) string {
+	expression := license.FromComposer(licenses)
+	if expression == nil {
+		return "NOASSERTION"
+	}
+	if expression.IsLeaf() {
+		return expression.SPDX()
+	}
+	return "(" + expression.SPDX() + ")"
+}
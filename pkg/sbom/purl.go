@@ -0,0 +1,24 @@
+package sbom
+
+import (
+	"net/url"
+	"strings"
+)
+
+// PURL 返回 composer 包的 Package URL，比如 pkg:composer/symfony/console@v6.2.0
+// https://github.com/package-url/purl-spec/blob/master/PURL-TYPES.rst#composer
+func PURL(packageName, version string) string {
+	builder := strings.Builder{}
+	builder.WriteString("pkg:composer/")
+	for i, part := range strings.Split(strings.ToLower(packageName), "/") {
+		if i > 0 {
+			builder.WriteString("/")
+		}
+		builder.WriteString(url.PathEscape(part))
+	}
+	if version != "" {
+		builder.WriteString("@")
+		builder.WriteString(url.PathEscape(version))
+	}
+	return builder.String()
+}
@@ -0,0 +1,228 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SPDX 2.3 JSON 格式的文档
+// https://spdx.github.io/spdx-spec/v2.3/
+type spdxDocument struct {
+	SpdxVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      *spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string            `json:"documentDescribes"`
+	Packages          []*spdxPackage      `json:"packages"`
+	Relationships     []*spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string             `json:"SPDXID"`
+	Name             string             `json:"name"`
+	VersionInfo      string             `json:"versionInfo,omitempty"`
+	Supplier         string             `json:"supplier,omitempty"`
+	Originator       string             `json:"originator,omitempty"`
+	DownloadLocation string             `json:"downloadLocation"`
+	FilesAnalyzed    bool               `json:"filesAnalyzed"`
+	Checksums        []*spdxChecksum    `json:"checksums,omitempty"`
+	Homepage         string             `json:"homepage,omitempty"`
+	SourceInfo       string             `json:"sourceInfo,omitempty"`
+	LicenseConcluded string             `json:"licenseConcluded"`
+	LicenseDeclared  string             `json:"licenseDeclared"`
+	CopyrightText    string             `json:"copyrightText"`
+	Description      string             `json:"description,omitempty"`
+	ExternalRefs     []*spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPurpose   string             `json:"primaryPackagePurpose,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	Comment           string `json:"comment,omitempty"`
+}
+
+type spdxRelationship struct {
+	SpdxElementId      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSpdxElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIdRegex = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// WriteSPDX 把物料清单以 SPDX 2.3 JSON 格式写出，已知的安全公告会作为 SECURITY 类型的外部引用
+func (x *BOM) WriteSPDX(w io.Writer) error {
+	rootId := spdxId(x.rootName(), x.Version)
+	document := &spdxDocument{
+		SpdxVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              x.rootName(),
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", spdxIdRegex.ReplaceAllString(x.rootName(), "-"), newUUID()),
+		CreationInfo: &spdxCreationInfo{
+			Created:  x.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: composer-crawler"},
+		},
+		DocumentDescribes: []string{rootId},
+		Packages:          make([]*spdxPackage, 0, len(x.Components)+1),
+		Relationships:     make([]*spdxRelationship, 0),
+	}
+
+	document.Packages = append(document.Packages, &spdxPackage{
+		SPDXID:           rootId,
+		Name:             x.rootName(),
+		VersionInfo:      x.Version,
+		DownloadLocation: "NOASSERTION",
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+		PrimaryPurpose:   "APPLICATION",
+	})
+	document.Relationships = append(document.Relationships, &spdxRelationship{
+		SpdxElementId:      "SPDXRef-DOCUMENT",
+		RelationshipType:   "DESCRIBES",
+		RelatedSpdxElement: rootId,
+	})
+
+	ids := make(map[string]string)
+	for _, component := range x.Components {
+		ids[strings.ToLower(component.Name)] = spdxId(component.Name, component.Version)
+	}
+
+	for _, component := range x.Components {
+		document.Packages = append(document.Packages, component.toSPDX())
+	}
+
+	for _, name := range x.DirectDependencies {
+		id := ids[strings.ToLower(name)]
+		if isDev(x.Components, name) {
+			document.Relationships = append(document.Relationships, &spdxRelationship{
+				SpdxElementId:      id,
+				RelationshipType:   "DEV_DEPENDENCY_OF",
+				RelatedSpdxElement: rootId,
+			})
+		} else {
+			document.Relationships = append(document.Relationships, &spdxRelationship{
+				SpdxElementId:      rootId,
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSpdxElement: id,
+			})
+		}
+	}
+	for _, component := range x.Components {
+		for _, dependency := range component.Dependencies {
+			document.Relationships = append(document.Relationships, &spdxRelationship{
+				SpdxElementId:      ids[strings.ToLower(component.Name)],
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSpdxElement: ids[strings.ToLower(dependency)],
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func isDev(components []*Component, name string) bool {
+	for _, component := range components {
+		if strings.EqualFold(component.Name, name) {
+			return component.Dev
+		}
+	}
+	return false
+}
+
+// spdxId 生成包的 SPDX 标识符，只能包含字母、数字、点和横线
+func spdxId(packageName, version string) string {
+	id := "SPDXRef-Package-" + spdxIdRegex.ReplaceAllString(packageName, "-")
+	if version != "" {
+		id += "-" + spdxIdRegex.ReplaceAllString(version, "-")
+	}
+	return id
+}
+
+func (x *Component) toSPDX() *spdxPackage {
+	vendor, _ := splitPackageName(x.Name)
+	spdxPackage := &spdxPackage{
+		SPDXID:           spdxId(x.Name, x.Version),
+		Name:             x.Name,
+		VersionInfo:      x.Version,
+		DownloadLocation: "NOASSERTION",
+		FilesAnalyzed:    false,
+		Homepage:         x.Homepage,
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  spdxLicenseExpression(x.Licenses),
+		CopyrightText:    "NOASSERTION",
+		Description:      x.Description,
+		PrimaryPurpose:   "LIBRARY",
+		ExternalRefs: []*spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  x.PURL(),
+		}},
+	}
+	if vendor != "" {
+		spdxPackage.Supplier = "Organization: " + vendor
+	}
+	if len(x.Authors) > 0 {
+		author := x.Authors[0]
+		if author.Email != "" {
+			spdxPackage.Originator = fmt.Sprintf("Person: %s (%s)", author.Name, author.Email)
+		} else {
+			spdxPackage.Originator = "Person: " + author.Name
+		}
+	}
+	if x.DistURL != "" {
+		spdxPackage.DownloadLocation = x.DistURL
+	} else if x.SourceURL != "" {
+		spdxPackage.DownloadLocation = x.SourceType + "+" + x.SourceURL
+		if x.SourceReference != "" {
+			spdxPackage.DownloadLocation += "@" + x.SourceReference
+		}
+	}
+	if x.DistShasum != "" {
+		spdxPackage.Checksums = append(spdxPackage.Checksums, &spdxChecksum{Algorithm: "SHA1", ChecksumValue: x.DistShasum})
+	}
+	if x.SourceReference != "" {
+		spdxPackage.SourceInfo = fmt.Sprintf("built from %s repository %s at reference %s", x.SourceType, x.SourceURL, x.SourceReference)
+	}
+	for _, advisory := range x.Advisories {
+		locator := advisory.Link
+		if locator == "" {
+			locator = "https://packagist.org/security-advisories/" + advisory.AdvisoryID
+		}
+		status := "not affected"
+		if x.Affects(advisory) {
+			status = "affected"
+		}
+		id := advisory.AdvisoryID
+		if advisory.Cve != "" {
+			id = advisory.Cve
+		}
+		spdxPackage.ExternalRefs = append(spdxPackage.ExternalRefs, &spdxExternalRef{
+			ReferenceCategory: "SECURITY",
+			ReferenceType:     "advisory",
+			ReferenceLocator:  locator,
+			Comment:           fmt.Sprintf("%s (%s): %s, affected versions %s", id, status, advisory.Title, advisory.AffectedVersions),
+		})
+	}
+	return spdxPackage
+}
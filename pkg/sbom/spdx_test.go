@@ -0,0 +1,67 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBOM_WriteSPDX(t *testing.T) {
+	bom := newTestBOM(t)
+	bom.Components[0].DistShasum = "0123456789abcdef0123456789abcdef01234567"
+	bom.Components[0].Authors = nil
+	bom.Components[0].Advisories = []*response.Advisory{
+		{AdvisoryID: "PKSA-1", Title: "Header injection", Cve: "CVE-2023-0001", AffectedVersions: ">=2.0.0,<2.9.2", Link: "https://example.com/PKSA-1"},
+	}
+
+	buff := &bytes.Buffer{}
+	assert.NoError(t, bom.WriteSPDX(buff))
+
+	document := &spdxDocument{}
+	assert.NoError(t, json.Unmarshal(buff.Bytes(), document))
+	assert.Equal(t, "SPDX-2.3", document.SpdxVersion)
+	assert.Equal(t, "SPDXRef-DOCUMENT", document.SPDXID)
+	assert.Equal(t, []string{"SPDXRef-Package-acme-shop-1.0.0"}, document.DocumentDescribes)
+
+	if assert.Len(t, document.Packages, 4) {
+		monolog := document.Packages[1]
+		assert.Equal(t, "SPDXRef-Package-monolog-monolog-2.9.1", monolog.SPDXID)
+		assert.Equal(t, "MIT", monolog.LicenseDeclared)
+		assert.Equal(t, "Organization: monolog", monolog.Supplier)
+		assert.Equal(t, []*spdxChecksum{{Algorithm: "SHA1", ChecksumValue: "0123456789abcdef0123456789abcdef01234567"}}, monolog.Checksums)
+		assert.Equal(t, "pkg:composer/monolog/monolog@2.9.1", monolog.ExternalRefs[0].ReferenceLocator)
+		assert.Equal(t, "SECURITY", monolog.ExternalRefs[1].ReferenceCategory)
+		assert.Equal(t, "https://example.com/PKSA-1", monolog.ExternalRefs[1].ReferenceLocator)
+		assert.Contains(t, monolog.ExternalRefs[1].Comment, "CVE-2023-0001 (affected)")
+
+		psrLog := document.Packages[2]
+		assert.Equal(t, "NOASSERTION", psrLog.LicenseDeclared)
+
+		tool := document.Packages[3]
+		assert.Equal(t, "LicenseRef-proprietary", tool.LicenseDeclared)
+		assert.Equal(t, "../tool", tool.DownloadLocation)
+	}
+
+	relationships := make([]string, 0)
+	for _, relationship := range document.Relationships {
+		relationships = append(relationships, relationship.SpdxElementId+" "+relationship.RelationshipType+" "+relationship.RelatedSpdxElement)
+	}
+	assert.Equal(t, []string{
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-acme-shop-1.0.0",
+		"SPDXRef-Package-acme-private-tool-1.0.0 DEV_DEPENDENCY_OF SPDXRef-Package-acme-shop-1.0.0",
+		"SPDXRef-Package-acme-shop-1.0.0 DEPENDS_ON SPDXRef-Package-monolog-monolog-2.9.1",
+		"SPDXRef-Package-monolog-monolog-2.9.1 DEPENDS_ON SPDXRef-Package-psr-log-3.0.0",
+	}, relationships)
+}
+
+func TestSpdxLicenseExpression(t *testing.T) {
+	assert.Equal(t, "NOASSERTION", spdxLicenseExpression(nil))
+	assert.Equal(t, "MIT", spdxLicenseExpression([]string{"mit"}))
+	assert.Equal(t, "(GPL-2.0-only OR GPL-3.0-only)", spdxLicenseExpression([]string{"GPL-2.0-only", "GPL-3.0-only"}))
+	assert.Equal(t, "LicenseRef-My-License", spdxLicenseExpression([]string{"My License"}))
+	assert.Equal(t, "(GPL-2.0-or-later OR MIT)", spdxLicenseExpression([]string{"GPL-2.0+", "(mit)"}))
+	assert.Equal(t, "(MIT AND (ISC OR Zlib))", spdxLicenseExpression([]string{"MIT and (ISC or Zlib)"}))
+}
@@ -0,0 +1,17 @@
+package sbom
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// newUUID 生成一个随机的 UUID v4，用于文档的序列号和命名空间
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
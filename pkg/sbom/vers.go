@@ -0,0 +1,98 @@
+package sbom
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// 一个“或”的分支对应的区间，没有下界或者上界的时候对应的字段为 nil
+type versInterval struct {
+	lower *semver.Condition
+	upper *semver.Condition
+}
+
+// VersRange 把 composer 的版本约束转换为 vers 格式的版本范围，比如 >=2.0,<2.9.2|>=3.0,<3.1 转换为
+// vers:composer/>=2.0|<2.9.2|>=3.0|<3.1，https://github.com/package-url/purl-spec/blob/master/VERSION-RANGE-SPEC.rst
+// vers 中的区间必须按版本排列并且互不重叠，包含 !=、分支版本或者区间有重叠的约束无法准确表示，返回 false
+func VersRange(affectedVersions string) (string, bool) {
+	constraint, err := semver.ParseConstraint(affectedVersions)
+	if err != nil {
+		return "", false
+	}
+	if constraint.IsAny() {
+		return "vers:composer/*", true
+	}
+
+	intervals := make([]*versInterval, 0)
+	for _, conditions := range constraint.Alternatives() {
+		interval := &versInterval{}
+		for _, condition := range conditions {
+			if condition.Version.IsBranch() {
+				return "", false
+			}
+			switch condition.Operator {
+			case ">=", ">":
+				if interval.lower != nil {
+					return "", false
+				}
+				interval.lower = condition
+			case "<", "<=":
+				if interval.upper != nil {
+					return "", false
+				}
+				interval.upper = condition
+			case "==":
+				if interval.lower != nil || interval.upper != nil {
+					return "", false
+				}
+				interval.lower, interval.upper = condition, condition
+			default:
+				return "", false
+			}
+		}
+		intervals = append(intervals, interval)
+	}
+
+	// 没有下界的区间排在最前面
+	sort.SliceStable(intervals, func(i, j int) bool {
+		if intervals[i].lower == nil || intervals[j].lower == nil {
+			return intervals[i].lower == nil && intervals[j].lower != nil
+		}
+		return intervals[i].lower.Version.LessThan(intervals[j].lower.Version)
+	})
+	for i := 1; i < len(intervals); i++ {
+		previous, current := intervals[i-1], intervals[i]
+		if previous.upper == nil || current.lower == nil {
+			return "", false
+		}
+		if !previous.upper.Version.LessThan(current.lower.Version) {
+			return "", false
+		}
+	}
+
+	parts := make([]string, 0, len(intervals)*2)
+	for _, interval := range intervals {
+		if interval.lower != nil && interval.lower == interval.upper {
+			parts = append(parts, versVersion(interval.lower.Version))
+			continue
+		}
+		if interval.lower != nil {
+			parts = append(parts, interval.lower.Operator+versVersion(interval.lower.Version))
+		}
+		if interval.upper != nil {
+			parts = append(parts, interval.upper.Operator+versVersion(interval.upper.Version))
+		}
+	}
+	return "vers:composer/" + strings.Join(parts, "|"), true
+}
+
+// versVersion 返回适合放在 vers 中的版本号，去掉约束解析时补上的 -dev 后缀和多余的第四段
+func versVersion(v *semver.Version) string {
+	if original := v.Original(); original != "" {
+		return strings.TrimSuffix(original, "-dev")
+	}
+	normalized := strings.TrimSuffix(v.Normalized(), "-dev")
+	return strings.TrimSuffix(normalized, ".0")
+}
@@ -0,0 +1,365 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	orSplitRegex        = regexp.MustCompile(`\s*\|\|?\s*`)
+	operatorSpaceRegex  = regexp.MustCompile(`(>=|<=|<>|!=|==|>|<|=|\^|~>|~)\s+`)
+	stabilityFlagRegex  = regexp.MustCompile(`(?i)^([^\s]*?)@(stable|RC|beta|alpha|dev)$`)
+	referenceRegex      = regexp.MustCompile(`(?i)^(dev-[^,\s@]+?|[^,\s@]+?\.x-dev)#.+$`)
+	tildeRegex          = regexp.MustCompile(`(?i)^~>?v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.(\d+))?` + modifierPattern + `$`)
+	caretRegex          = regexp.MustCompile(`(?i)^\^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.(\d+))?` + modifierPattern + `$`)
+	wildcardRegex       = regexp.MustCompile(`(?i)^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.[x*])+$`)
+	hyphenPartRegex     = regexp.MustCompile(`(?i)^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.(\d+))?` + modifierPattern + `$`)
+	operatorRegex       = regexp.MustCompile(`^(<>|!=|>=?|<=?|==?)?\s*(.*)$`)
+	modifierSuffixRegex = regexp.MustCompile(`(?i)-(stable|beta|b|RC|alpha|a|patch|pl|p)((?:[.-]?\d+)*)?([.-]?dev)?$`)
+)
+
+// Condition 表示一个最基本的比较条件，比如 >=1.0.0.0-dev
+type Condition struct {
+	// 比较运算符：==、!=、>、>=、<、<=
+	Operator string
+	Version  *Version
+}
+
+func (x *Condition) String() string {
+	return x.Operator + x.Version.Normalized()
+}
+
+// Check 判断版本是否满足这个条件
+func (x *Condition) Check(v *Version) bool {
+	// 分支版本只能判断是否相等
+	if v.IsBranch() || x.Version.IsBranch() {
+		equal := v.IsBranch() && x.Version.IsBranch() && strings.EqualFold(v.Branch(), x.Version.Branch())
+		switch x.Operator {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		default:
+			return false
+		}
+	}
+
+	r := Compare(v, x.Version)
+	switch x.Operator {
+	case "==":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	default:
+		return false
+	}
+}
+
+// Constraint 表示一个版本约束，比如 ^1.2 || ~2.0
+// 内部是多个“或”的分支，每个分支是多个需要同时满足的条件
+type Constraint struct {
+	pretty string
+	// 为空表示匹配所有版本
+	alternatives [][]*Condition
+}
+
+// ParseConstraint 解析 composer 的版本约束
+// https://getcomposer.org/doc/articles/versions.md#writing-version-constraints
+func ParseConstraint(constraint string) (*Constraint, error) {
+	pretty := strings.TrimSpace(constraint)
+	if pretty == "" {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+
+	result := &Constraint{pretty: pretty}
+	for _, orPart := range orSplitRegex.Split(pretty, -1) {
+		conditions, err := parseAndConstraint(orPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+		// 有一个分支能匹配所有版本，整个约束就能匹配所有版本
+		if len(conditions) == 0 {
+			result.alternatives = nil
+			return result, nil
+		}
+		result.alternatives = append(result.alternatives, conditions)
+	}
+	return result, nil
+}
+
+// MustParseConstraint 解析版本约束，失败时 panic
+func MustParseConstraint(constraint string) *Constraint {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Check 判断版本是否满足约束
+func (x *Constraint) Check(v *Version) bool {
+	if len(x.alternatives) == 0 {
+		return true
+	}
+	for _, conditions := range x.alternatives {
+		matched := true
+		for _, condition := range conditions {
+			if !condition.Check(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckString 判断版本字符串是否满足约束，无法解析的版本认为不满足
+func (x *Constraint) CheckString(version string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+	return x.Check(v)
+}
+
+// Alternatives 返回“或”关系的各个分支，每个分支内的条件需要同时满足，返回空表示匹配所有版本
+func (x *Constraint) Alternatives() [][]*Condition {
+	return x.alternatives
+}
+
+// IsAny 是否匹配所有版本
+func (x *Constraint) IsAny() bool {
+	return len(x.alternatives) == 0
+}
+
+// And 返回同时满足两个约束的新约束
+func (x *Constraint) And(other *Constraint) *Constraint {
+	if x.IsAny() {
+		return other
+	}
+	if other.IsAny() {
+		return x
+	}
+	result := &Constraint{pretty: fmt.Sprintf("(%s), (%s)", x.pretty, other.pretty)}
+	for _, a := range x.alternatives {
+		for _, b := range other.alternatives {
+			conditions := make([]*Condition, 0, len(a)+len(b))
+			conditions = append(conditions, a...)
+			conditions = append(conditions, b...)
+			result.alternatives = append(result.alternatives, conditions)
+		}
+	}
+	return result
+}
+
+func (x *Constraint) String() string {
+	return x.pretty
+}
+
+// parseAndConstraint 解析一个“与”关系的约束，比如 >=1.0 <2.0
+func parseAndConstraint(constraint string) ([]*Condition, error) {
+	constraint = strings.TrimSpace(constraint)
+	if match := aliasRegex.FindStringSubmatch(constraint); match != nil {
+		constraint = match[1]
+	}
+	constraint = operatorSpaceRegex.ReplaceAllString(constraint, "$1")
+
+	tokens := strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty constraint")
+	}
+
+	result := make([]*Condition, 0)
+	for i := 0; i < len(tokens); i++ {
+		// 连字符范围：1.0 - 2.0
+		if i+2 < len(tokens) && tokens[i+1] == "-" {
+			conditions, err := parseHyphenRange(tokens[i], tokens[i+2])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, conditions...)
+			i += 2
+			continue
+		}
+		conditions, err := parseSingleConstraint(tokens[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, conditions...)
+	}
+	return result, nil
+}
+
+// parseSingleConstraint 解析一个不含空格的约束，返回的条件需要同时满足，返回空表示匹配所有版本
+func parseSingleConstraint(constraint string) ([]*Condition, error) {
+	if match := stabilityFlagRegex.FindStringSubmatch(constraint); match != nil {
+		constraint = match[1]
+		if constraint == "" {
+			constraint = "*"
+		}
+	}
+	if match := referenceRegex.FindStringSubmatch(constraint); match != nil {
+		constraint = match[1]
+	}
+
+	switch strings.ToLower(constraint) {
+	case "*", "x", "v*", "*.*", "*.*.*":
+		return nil, nil
+	}
+
+	if match := tildeRegex.FindStringSubmatch(constraint); match != nil {
+		position := countParts(match)
+		lower, err := Parse(strings.TrimLeft(constraint, "~>") + devSuffixIfNoModifier(match))
+		if err != nil {
+			return nil, err
+		}
+		highPosition := position - 1
+		if highPosition < 1 {
+			highPosition = 1
+		}
+		return []*Condition{
+			{Operator: ">=", Version: lower},
+			{Operator: "<", Version: manipulateVersion(match, highPosition, 1)},
+		}, nil
+	}
+
+	if match := caretRegex.FindStringSubmatch(constraint); match != nil {
+		var position int
+		switch {
+		case match[1] != "0" || match[2] == "":
+			position = 1
+		case match[2] != "0" || match[3] == "":
+			position = 2
+		default:
+			position = 3
+		}
+		lower, err := Parse(constraint[1:] + devSuffixIfNoModifier(match))
+		if err != nil {
+			return nil, err
+		}
+		return []*Condition{
+			{Operator: ">=", Version: lower},
+			{Operator: "<", Version: manipulateVersion(match, position, 1)},
+		}, nil
+	}
+
+	if match := wildcardRegex.FindStringSubmatch(constraint); match != nil {
+		position := 1
+		if match[3] != "" {
+			position = 3
+		} else if match[2] != "" {
+			position = 2
+		}
+		lower := manipulateVersion(match, position, 0)
+		upper := manipulateVersion(match, position, 1)
+		if lower.Normalized() == "0.0.0.0-dev" {
+			return []*Condition{{Operator: "<", Version: upper}}, nil
+		}
+		return []*Condition{
+			{Operator: ">=", Version: lower},
+			{Operator: "<", Version: upper},
+		}, nil
+	}
+
+	match := operatorRegex.FindStringSubmatch(constraint)
+	operator, version := match[1], match[2]
+	v, err := Parse(version)
+	if err != nil {
+		return nil, err
+	}
+	// 没有稳定性后缀的 <1.0 和 >=1.0 需要包含 1.0 的预发布版本的边界
+	if (operator == "<" || operator == ">=") && !v.IsBranch() && !modifierSuffixRegex.MatchString(version) && v.modifier != modifierDev {
+		v = v.withModifier(modifierDev)
+	}
+	switch operator {
+	case "", "=":
+		operator = "=="
+	case "<>":
+		operator = "!="
+	}
+	return []*Condition{{Operator: operator, Version: v}}, nil
+}
+
+// parseHyphenRange 解析 1.0 - 2.0 这种范围，上界不完整的时候表示小于上界的下一个版本
+func parseHyphenRange(from, to string) ([]*Condition, error) {
+	fromMatch := hyphenPartRegex.FindStringSubmatch(from)
+	toMatch := hyphenPartRegex.FindStringSubmatch(to)
+	if fromMatch == nil || toMatch == nil {
+		return nil, fmt.Errorf("invalid hyphen range %s - %s", from, to)
+	}
+
+	lower, err := Parse(from + devSuffixIfNoModifier(fromMatch))
+	if err != nil {
+		return nil, err
+	}
+	result := []*Condition{{Operator: ">=", Version: lower}}
+
+	if (toMatch[2] != "" && toMatch[3] != "") || toMatch[5] != "" || toMatch[7] != "" {
+		upper, err := Parse(to)
+		if err != nil {
+			return nil, err
+		}
+		return append(result, &Condition{Operator: "<=", Version: upper}), nil
+	}
+	position := 1
+	if toMatch[2] != "" {
+		position = 2
+	}
+	return append(result, &Condition{Operator: "<", Version: manipulateVersion(toMatch, position, 1)}), nil
+}
+
+func countParts(match []string) int {
+	for i := 4; i >= 2; i-- {
+		if match[i] != "" {
+			return i
+		}
+	}
+	return 1
+}
+
+func devSuffixIfNoModifier(match []string) string {
+	if match[5] == "" && match[7] == "" {
+		return "-dev"
+	}
+	return ""
+}
+
+// manipulateVersion 把版本号中 position 位置的数字加上 increment，后面的位置置零，并且加上 -dev 后缀
+func manipulateVersion(match []string, position int, increment int64) *Version {
+	v := &Version{modifier: modifierDev}
+	for i := 0; i < 4 && i+1 < len(match); i++ {
+		n, _ := strconv.ParseInt(match[i+1], 10, 64)
+		v.parts[i] = n
+	}
+	for i := 3; i >= 0; i-- {
+		if i+1 > position {
+			v.parts[i] = 0
+		} else if i+1 == position {
+			v.parts[i] += increment
+		}
+	}
+	return v
+}
+
+func (x *Version) withModifier(modifier int) *Version {
+	v := *x
+	v.modifier = modifier
+	v.modifierNumber = nil
+	v.devSuffix = false
+	return &v
+}
@@ -0,0 +1,87 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		misses     []string
+	}{
+		{"*", []string{"1.0.0", "0.0.1", "dev-main"}, nil},
+		{"1.2.3", []string{"1.2.3", "v1.2.3.0"}, []string{"1.2.4"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0", "2.0.0-beta1"}},
+		{"^0.3", []string{"0.3.0", "0.3.9"}, []string{"0.4.0", "0.2.9"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2", []string{"1.2.0", "1.9.9"}, []string{"2.0.0", "1.1.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.5.0"}, []string{"2.0.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.99"}, []string{"1.3.0", "1.1.0"}},
+		{"1.*", []string{"1.0.0", "1.99.0"}, []string{"2.0.0"}},
+		{">=1.0 <2.0", []string{"1.0.0", "1.5.0"}, []string{"2.0.0", "0.9.0", "2.0.0-beta1"}},
+		{">= 1.0, < 2.0", []string{"1.5.0"}, []string{"2.0.0"}},
+		{"<2.0", []string{"1.9.9"}, []string{"2.0.0-alpha1"}},
+		{">1.0", []string{"1.0.1"}, []string{"1.0.0"}},
+		{"<=1.0", []string{"1.0.0"}, []string{"1.0.1"}},
+		{"!=1.0", []string{"1.0.1"}, []string{"1.0.0"}},
+		{"^1.0.1 || ^2.0 || ^3.0", []string{"1.0.1", "2.5.0", "3.0.0"}, []string{"1.0.0", "4.0.0"}},
+		{"^1.0|^2.0", []string{"2.0.0"}, []string{"3.0.0"}},
+		{"1.0 - 2.0", []string{"1.0.0", "2.0.5"}, []string{"2.1.0"}},
+		{"1.0.0 - 2.1.0", []string{"2.1.0"}, []string{"2.1.1"}},
+		{"^2.0@dev", []string{"2.0.0", "2.1.0-beta1"}, []string{"3.0.0"}},
+		{"dev-main", []string{"dev-main"}, []string{"1.0.0", "dev-develop"}},
+		{"dev-main#abc123", []string{"dev-main"}, nil},
+		{"1.0.x-dev", []string{"1.0.x-dev"}, []string{"1.0.0"}},
+		{"dev-main as 1.0.0", []string{"dev-main"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, version := range tt.matches {
+				assert.True(t, constraint.CheckString(version), "%s should match %s", tt.constraint, version)
+			}
+			for _, version := range tt.misses {
+				assert.False(t, constraint.CheckString(version), "%s should not match %s", tt.constraint, version)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, constraint := range []string{"", "  ", ">=foo bar", "^abc"} {
+		_, err := ParseConstraint(constraint)
+		assert.Error(t, err, constraint)
+	}
+}
+
+func TestConstraint_And(t *testing.T) {
+	constraint := MustParseConstraint("^1.0").And(MustParseConstraint(">=1.5 || ^3.0"))
+	assert.True(t, constraint.CheckString("1.6.0"))
+	assert.False(t, constraint.CheckString("1.4.0"))
+	assert.False(t, constraint.CheckString("3.0.0"))
+
+	any := MustParseConstraint("*")
+	assert.True(t, any.IsAny())
+	assert.Equal(t, constraint, any.And(constraint))
+	assert.Equal(t, constraint, constraint.And(any))
+}
+
+func TestConstraint_Alternatives(t *testing.T) {
+	constraint := MustParseConstraint("^1.2 || >=3.0 <3.5")
+	alternatives := constraint.Alternatives()
+	if assert.Len(t, alternatives, 2) {
+		assert.Equal(t, ">=1.2.0.0-dev", alternatives[0][0].String())
+		assert.Equal(t, "<2.0.0.0-dev", alternatives[0][1].String())
+		assert.Equal(t, ">=3.0.0.0-dev", alternatives[1][0].String())
+		assert.Equal(t, "<3.5.0.0-dev", alternatives[1][1].String())
+	}
+	assert.Equal(t, "^1.2 || >=3.0 <3.5", constraint.String())
+}
@@ -0,0 +1,61 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stability 表示版本的稳定性，值越大越稳定
+type Stability int
+
+const (
+	StabilityDev Stability = iota
+	StabilityAlpha
+	StabilityBeta
+	StabilityRC
+	StabilityStable
+)
+
+func (x Stability) String() string {
+	switch x {
+	case StabilityDev:
+		return "dev"
+	case StabilityAlpha:
+		return "alpha"
+	case StabilityBeta:
+		return "beta"
+	case StabilityRC:
+		return "RC"
+	case StabilityStable:
+		return "stable"
+	default:
+		return fmt.Sprintf("Stability(%d)", int(x))
+	}
+}
+
+// ParseStability 解析 minimum-stability 或者 @dev 这类标记中的稳定性，不区分大小写
+func ParseStability(stability string) (Stability, error) {
+	switch strings.ToLower(strings.TrimSpace(stability)) {
+	case "dev":
+		return StabilityDev, nil
+	case "alpha", "a":
+		return StabilityAlpha, nil
+	case "beta", "b":
+		return StabilityBeta, nil
+	case "rc":
+		return StabilityRC, nil
+	case "stable", "":
+		return StabilityStable, nil
+	default:
+		return StabilityStable, fmt.Errorf("unknown stability %q", stability)
+	}
+}
+
+// StabilityOf 返回版本字符串的稳定性，无法解析的版本当做 dev 处理
+func StabilityOf(version string) Stability {
+	v, err := Parse(version)
+	if err != nil {
+		return StabilityDev
+	}
+	return v.Stability()
+}
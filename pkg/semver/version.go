@@ -0,0 +1,380 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 版本修饰符的排序，与 PHP 的 version_compare 保持一致：dev < alpha < beta < RC < stable < patch
+const (
+	modifierDev = iota
+	modifierAlpha
+	modifierBeta
+	modifierRC
+	modifierStable
+	modifierPatch
+)
+
+// 分支版本号中的 x 会被替换为这个数字，比如 1.x-dev 被规范化为 1.9999999.9999999.9999999-dev
+const branchWildcard = 9999999
+
+var (
+	modifierPattern = `[._-]?(?:(stable|beta|b|RC|alpha|a|patch|pl|p)((?:[.-]?\d+)*)?)?([.-]?dev)?`
+	classicalRegex  = regexp.MustCompile(`(?i)^v?(\d{1,5})(\.\d+)?(\.\d+)?(\.\d+)?` + modifierPattern + `$`)
+	dateRegex       = regexp.MustCompile(`(?i)^v?(\d{4}(?:[.:-]?\d{2}){1,6}(?:[.:-]?\d{1,3}){0,2})` + modifierPattern + `$`)
+	branchRegex     = regexp.MustCompile(`(?i)^v?(\d+)(\.(?:\d+|[x*]))?(\.(?:\d+|[x*]))?(\.(?:\d+|[x*]))?$`)
+	devSuffixRegex  = regexp.MustCompile(`(?i)^(.*?)[.-]?dev$`)
+	aliasRegex      = regexp.MustCompile(`^([^,\s]+) +as +([^,\s]+)$`)
+	nonDigitRegex   = regexp.MustCompile(`\D`)
+	digitsRegex     = regexp.MustCompile(`\d+`)
+)
+
+// Version 表示一个被解析后的 composer 版本号
+type Version struct {
+	original string
+	// 分支名，只有 dev-xxx 这种分支版本才有
+	branch string
+	// 被规范化为四段的版本号
+	parts [4]int64
+	// 日期版本只有一段，但是数字可能很长，单独保存
+	date     string
+	modifier int
+	// 修饰符后面的数字，比如 beta2 中的 2
+	modifierNumber []int64
+	// 是否带有 -dev 后缀，比如 1.0.0-beta2-dev
+	devSuffix bool
+}
+
+// Parse 解析一个版本号，支持 composer 中所有合法的版本写法
+func Parse(version string) (*Version, error) {
+	original := version
+	version = strings.TrimSpace(version)
+
+	// 去掉内联别名 "1.0.x-dev as 1.0.0"
+	if match := aliasRegex.FindStringSubmatch(version); match != nil {
+		version = match[1]
+	}
+	// 去掉构建元数据
+	if index := strings.Index(version, "+"); index > 0 {
+		version = version[:index]
+	}
+	if version == "" {
+		return nil, fmt.Errorf("invalid version string %q", original)
+	}
+
+	lower := strings.ToLower(version)
+	if lower == "master" || lower == "trunk" || lower == "default" {
+		return &Version{original: original, branch: version, modifier: modifierDev}, nil
+	}
+	if strings.HasPrefix(lower, "dev-") {
+		return &Version{original: original, branch: version[4:], modifier: modifierDev}, nil
+	}
+
+	if match := classicalRegex.FindStringSubmatch(version); match != nil {
+		v := &Version{original: original}
+		for i := 0; i < 4; i++ {
+			part := strings.TrimPrefix(match[i+1], ".")
+			if part == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version string %q: %w", original, err)
+			}
+			v.parts[i] = n
+		}
+		v.setModifier(match[5], match[6], match[7])
+		return v, nil
+	}
+
+	if match := dateRegex.FindStringSubmatch(version); match != nil {
+		v := &Version{original: original, date: nonDigitRegex.ReplaceAllString(match[1], ".")}
+		first, _ := strconv.ParseInt(strings.SplitN(v.date, ".", 2)[0], 10, 64)
+		v.parts[0] = first
+		v.setModifier(match[2], match[3], match[4])
+		return v, nil
+	}
+
+	// 1.x-dev 这类的分支，或者 feature-dev 这类的特性分支
+	if match := devSuffixRegex.FindStringSubmatch(version); match != nil {
+		if v := parseNumericBranch(match[1]); v != nil {
+			v.original = original
+			return v, nil
+		}
+		return &Version{original: original, branch: match[1], modifier: modifierDev}, nil
+	}
+
+	return nil, fmt.Errorf("invalid version string %q", original)
+}
+
+// MustParse 解析版本号，失败时 panic，一般用于测试或者常量
+func MustParse(version string) *Version {
+	v, err := Parse(version)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Normalize 把版本号规范化为 composer 中 version_normalized 的格式，比如 v1.2 会被规范化为 1.2.0.0
+func Normalize(version string) (string, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return "", err
+	}
+	return v.Normalized(), nil
+}
+
+func parseNumericBranch(name string) *Version {
+	match := branchRegex.FindStringSubmatch(name)
+	if match == nil {
+		return nil
+	}
+	v := &Version{modifier: modifierDev}
+	for i := 0; i < 4; i++ {
+		part := strings.TrimPrefix(match[i+1], ".")
+		switch strings.ToLower(part) {
+		case "", "x", "*":
+			v.parts[i] = branchWildcard
+		default:
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil
+			}
+			v.parts[i] = n
+		}
+	}
+	return v
+}
+
+func (x *Version) setModifier(modifier, number, dev string) {
+	x.modifier = modifierStable
+	switch strings.ToLower(modifier) {
+	case "alpha", "a":
+		x.modifier = modifierAlpha
+	case "beta", "b":
+		x.modifier = modifierBeta
+	case "rc":
+		x.modifier = modifierRC
+	case "patch", "pl", "p":
+		x.modifier = modifierPatch
+	}
+	for _, part := range digitsRegex.FindAllString(number, -1) {
+		n, _ := strconv.ParseInt(part, 10, 64)
+		x.modifierNumber = append(x.modifierNumber, n)
+	}
+	if dev != "" {
+		if modifier == "" {
+			x.modifier = modifierDev
+		} else {
+			x.devSuffix = true
+		}
+	}
+}
+
+// Original 返回解析前的版本字符串
+func (x *Version) Original() string {
+	return x.original
+}
+
+// IsBranch 是否是 dev-main 这种分支版本
+func (x *Version) IsBranch() bool {
+	return x.branch != ""
+}
+
+// Branch 返回分支版本的分支名，比如 dev-main 返回 main
+func (x *Version) Branch() string {
+	return x.branch
+}
+
+// IsNumericBranch 是否是 1.x-dev 这种可以比较大小的分支版本
+func (x *Version) IsNumericBranch() bool {
+	return x.branch == "" && x.modifier == modifierDev && x.parts[len(x.parts)-1] == branchWildcard
+}
+
+// Major 主版本号
+func (x *Version) Major() int64 {
+	return x.parts[0]
+}
+
+// Minor 次版本号
+func (x *Version) Minor() int64 {
+	return x.parts[1]
+}
+
+// Patch 修订号
+func (x *Version) Patch() int64 {
+	return x.parts[2]
+}
+
+// Stability 返回版本的稳定性
+func (x *Version) Stability() Stability {
+	if x.devSuffix {
+		return StabilityDev
+	}
+	switch x.modifier {
+	case modifierDev:
+		return StabilityDev
+	case modifierAlpha:
+		return StabilityAlpha
+	case modifierBeta:
+		return StabilityBeta
+	case modifierRC:
+		return StabilityRC
+	default:
+		return StabilityStable
+	}
+}
+
+// Normalized 返回规范化后的版本字符串
+func (x *Version) Normalized() string {
+	if x.branch != "" {
+		return "dev-" + x.branch
+	}
+	builder := strings.Builder{}
+	if x.date != "" {
+		builder.WriteString(x.date)
+	} else {
+		for i, part := range x.parts {
+			if i > 0 {
+				builder.WriteString(".")
+			}
+			builder.WriteString(strconv.FormatInt(part, 10))
+		}
+	}
+	switch x.modifier {
+	case modifierDev:
+		builder.WriteString("-dev")
+		return builder.String()
+	case modifierAlpha:
+		builder.WriteString("-alpha")
+	case modifierBeta:
+		builder.WriteString("-beta")
+	case modifierRC:
+		builder.WriteString("-RC")
+	case modifierPatch:
+		builder.WriteString("-patch")
+	}
+	for i, n := range x.modifierNumber {
+		if i > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(strconv.FormatInt(n, 10))
+	}
+	if x.devSuffix {
+		builder.WriteString("-dev")
+	}
+	return builder.String()
+}
+
+func (x *Version) String() string {
+	if x.original != "" {
+		return x.original
+	}
+	return x.Normalized()
+}
+
+// Compare 比较两个版本的大小，a < b 返回 -1，相等返回 0，a > b 返回 1
+// 分支版本无法比较大小，认为比所有数字版本都小，分支之间按名字排序
+func Compare(a, b *Version) int {
+	if a.branch != "" || b.branch != "" {
+		switch {
+		case a.branch != "" && b.branch != "":
+			return strings.Compare(a.branch, b.branch)
+		case a.branch != "":
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if a.date != "" || b.date != "" {
+		if r := compareNumbers(splitNumbers(a.dateOrParts()), splitNumbers(b.dateOrParts())); r != 0 {
+			return r
+		}
+	} else {
+		for i := range a.parts {
+			if r := compareInt(a.parts[i], b.parts[i]); r != 0 {
+				return r
+			}
+		}
+	}
+
+	if r := compareInt(int64(a.modifier), int64(b.modifier)); r != 0 {
+		return r
+	}
+	if r := compareNumbers(a.modifierNumber, b.modifierNumber); r != 0 {
+		return r
+	}
+	// 1.0.0-beta2-dev 比 1.0.0-beta2 要小
+	switch {
+	case a.devSuffix && !b.devSuffix:
+		return -1
+	case !a.devSuffix && b.devSuffix:
+		return 1
+	}
+	return 0
+}
+
+func (x *Version) dateOrParts() string {
+	if x.date != "" {
+		return x.date
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", x.parts[0], x.parts[1], x.parts[2], x.parts[3])
+}
+
+func splitNumbers(s string) []int64 {
+	result := make([]int64, 0)
+	for _, part := range strings.Split(s, ".") {
+		n, _ := strconv.ParseInt(part, 10, 64)
+		result = append(result, n)
+	}
+	return result
+}
+
+func compareNumbers(a, b []int64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if r := compareInt(x, y); r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LessThan a < b
+func (x *Version) LessThan(other *Version) bool {
+	return Compare(x, other) < 0
+}
+
+// Equal 两个版本规范化之后是否相等
+func (x *Version) Equal(other *Version) bool {
+	return Compare(x, other) == 0
+}
+
+// Sort 把版本从小到大排序
+func Sort(versions []*Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
@@ -0,0 +1,127 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "1.0.0", want: "1.0.0.0"},
+		{version: "v1.2", want: "1.2.0.0"},
+		{version: "1.2.3.4", want: "1.2.3.4"},
+		{version: "1.0.0-beta2", want: "1.0.0.0-beta2"},
+		{version: "1.0.0b2", want: "1.0.0.0-beta2"},
+		{version: "1.0.0-RC1", want: "1.0.0.0-RC1"},
+		{version: "1.0.0-alpha.1", want: "1.0.0.0-alpha1"},
+		{version: "1.0.0-p1", want: "1.0.0.0-patch1"},
+		{version: "1.0-dev", want: "1.0.0.0-dev"},
+		{version: "1.0.0-beta2-dev", want: "1.0.0.0-beta2-dev"},
+		{version: "1.0.0+build.5", want: "1.0.0.0"},
+		{version: "1.x-dev", want: "1.9999999.9999999.9999999-dev"},
+		{version: "2.1.x-dev", want: "2.1.9999999.9999999-dev"},
+		{version: "dev-main", want: "dev-main"},
+		{version: "master", want: "dev-master"},
+		{version: "feature-foo-dev", want: "dev-feature-foo"},
+		{version: "dev-main as 1.0.x-dev", want: "dev-main"},
+		{version: "20230102", want: "20230102"},
+		{version: "", wantErr: true},
+		{version: "not a version", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := Normalize(tt.version)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ordered := []string{
+		"dev-main",
+		"1.0.0-dev",
+		"1.0.0-alpha1",
+		"1.0.0-alpha2",
+		"1.0.0-beta1",
+		"1.0.0-RC1",
+		"1.0.0",
+		"1.0.0-p1",
+		"1.0.1",
+		"1.1",
+		"1.x-dev",
+		"2.0.0",
+		"10.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, b := MustParse(ordered[i]), MustParse(ordered[i+1])
+		assert.Equal(t, -1, Compare(a, b), "%s < %s", a, b)
+		assert.Equal(t, 1, Compare(b, a), "%s > %s", b, a)
+	}
+	assert.Equal(t, 0, Compare(MustParse("v1.0"), MustParse("1.0.0.0")))
+	assert.True(t, MustParse("1.0").Equal(MustParse("1.0.0")))
+}
+
+func TestSort(t *testing.T) {
+	versions := []*Version{MustParse("2.0.0"), MustParse("1.0.0-beta1"), MustParse("1.0.0"), MustParse("1.10.0"), MustParse("1.9.0")}
+	Sort(versions)
+	got := make([]string, 0)
+	for _, v := range versions {
+		got = append(got, v.String())
+	}
+	assert.Equal(t, []string{"1.0.0-beta1", "1.0.0", "1.9.0", "1.10.0", "2.0.0"}, got)
+}
+
+func TestVersion_Stability(t *testing.T) {
+	tests := map[string]Stability{
+		"1.0.0":           StabilityStable,
+		"1.0.0-p2":        StabilityStable,
+		"1.0.0-RC1":       StabilityRC,
+		"1.0.0-beta":      StabilityBeta,
+		"1.0.0-alpha3":    StabilityAlpha,
+		"1.0.0-beta2-dev": StabilityDev,
+		"1.x-dev":         StabilityDev,
+		"dev-main":        StabilityDev,
+	}
+	for version, want := range tests {
+		assert.Equal(t, want, MustParse(version).Stability(), version)
+		assert.Equal(t, want, StabilityOf(version), version)
+	}
+	assert.Equal(t, StabilityDev, StabilityOf("???"))
+}
+
+func TestParseStability(t *testing.T) {
+	for _, s := range []string{"dev", "alpha", "beta", "RC", "stable"} {
+		stability, err := ParseStability(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, stability.String())
+	}
+	_, err := ParseStability("nightly")
+	assert.Error(t, err)
+}
+
+func TestVersion_Accessors(t *testing.T) {
+	v := MustParse("v3.4.5")
+	assert.Equal(t, int64(3), v.Major())
+	assert.Equal(t, int64(4), v.Minor())
+	assert.Equal(t, int64(5), v.Patch())
+	assert.Equal(t, "v3.4.5", v.Original())
+	assert.False(t, v.IsBranch())
+
+	branch := MustParse("dev-feature/foo")
+	assert.True(t, branch.IsBranch())
+	assert.Equal(t, "feature/foo", branch.Branch())
+
+	assert.True(t, MustParse("1.x-dev").IsNumericBranch())
+	assert.False(t, MustParse("1.0.0-dev").IsNumericBranch())
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// 包名 -> 包信息
+	boltPackagesBucket = []byte("packages")
+
+	// ChangeTime + 包名 -> 空，用来按变化时间查询
+	boltChangesBucket = []byte("changes")
+)
+
+// BoltStore 基于本地文件数据库 bbolt 的存储，适合单机使用
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ PackageStore = &BoltStore{}
+
+// OpenBoltStore 打开给定路径的数据库文件，不存在的时候会自动创建
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltPackagesBucket, boltChangesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (x *BoltStore) Get(ctx context.Context, packageName string) (*composer_crawler.ComposerPackageInfo, error) {
+	var info *composer_crawler.ComposerPackageInfo
+	err := x.db.View(func(tx *bolt.Tx) error {
+		var err error
+		info, err = boltGet(tx, packageKey(packageName))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrNotFound
+	}
+	return info, nil
+}
+
+func (x *BoltStore) Upsert(ctx context.Context, info *composer_crawler.ComposerPackageInfo) (*UpsertResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var result *UpsertResult
+	err := x.db.Update(func(tx *bolt.Tx) error {
+		key := packageKey(info.PackageName)
+		if key == "" {
+			key = packageKey(info.Package.Name)
+		}
+		previous, err := boltGet(tx, key)
+		if err != nil {
+			return err
+		}
+		result, err = prepareUpsert(previous, info, time.Now())
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+
+		changes := tx.Bucket(boltChangesBucket)
+		if previous != nil && previous.ChangeTime != nil && !previous.ChangeTime.Equal(*info.ChangeTime) {
+			if err := changes.Delete(boltChangeKey(*previous.ChangeTime, key)); err != nil {
+				return err
+			}
+		}
+		if err := changes.Put(boltChangeKey(*info.ChangeTime, key), []byte{}); err != nil {
+			return err
+		}
+		return tx.Bucket(boltPackagesBucket).Put([]byte(key), value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (x *BoltStore) Delete(ctx context.Context, packageName string) error {
+	return x.db.Update(func(tx *bolt.Tx) error {
+		key := packageKey(packageName)
+		previous, err := boltGet(tx, key)
+		if err != nil || previous == nil {
+			return err
+		}
+		if previous.ChangeTime != nil {
+			if err := tx.Bucket(boltChangesBucket).Delete(boltChangeKey(*previous.ChangeTime, key)); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(boltPackagesBucket).Delete([]byte(key))
+	})
+}
+
+func (x *BoltStore) Iterate(ctx context.Context, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	keys, err := x.keys(boltPackagesBucket, nil, func(key []byte) string {
+		return string(key)
+	})
+	if err != nil {
+		return err
+	}
+	return x.iterateKeys(ctx, keys, callback)
+}
+
+func (x *BoltStore) ChangedSince(ctx context.Context, since time.Time, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	var start []byte
+	if since.Unix() > 0 {
+		start = boltChangeKey(since, "")
+	}
+	keys, err := x.keys(boltChangesBucket, start, func(key []byte) string {
+		return string(key[8:])
+	})
+	if err != nil {
+		return err
+	}
+	return x.iterateKeys(ctx, keys, callback)
+}
+
+// 先在一个只读事务里把 key 都取出来，再逐个读取，这样回调里也可以写入存储
+func (x *BoltStore) keys(bucket, start []byte, toPackageKey func(key []byte) string) ([]string, error) {
+	keys := make([]string, 0)
+	err := x.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucket).Cursor()
+		key, _ := cursor.First()
+		if start != nil {
+			key, _ = cursor.Seek(start)
+		}
+		for ; key != nil; key, _ = cursor.Next() {
+			keys = append(keys, toPackageKey(key))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (x *BoltStore) iterateKeys(ctx context.Context, keys []string, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var info *composer_crawler.ComposerPackageInfo
+		err := x.db.View(func(tx *bolt.Tx) error {
+			var err error
+			info, err = boltGet(tx, key)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		// 遍历的过程中被删除了
+		if info == nil {
+			continue
+		}
+		if err := callback(info); err != nil {
+			if errors.Is(err, ErrStopIterate) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *BoltStore) Close() error {
+	return x.db.Close()
+}
+
+func boltGet(tx *bolt.Tx, key string) (*composer_crawler.ComposerPackageInfo, error) {
+	value := tx.Bucket(boltPackagesBucket).Get([]byte(key))
+	if value == nil {
+		return nil, nil
+	}
+	info := &composer_crawler.ComposerPackageInfo{}
+	if err := json.Unmarshal(value, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// 时间戳使用大端序，这样 key 的字典序就是时间顺序
+func boltChangeKey(changeTime time.Time, key string) []byte {
+	buff := bytes.NewBuffer(make([]byte, 0, 8+len(key)))
+	_ = binary.Write(buff, binary.BigEndian, uint64(changeTime.UnixNano()))
+	buff.WriteString(key)
+	return buff.Bytes()
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStore(t *testing.T) {
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "packages.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	testPackageStore(t, store)
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packages.db")
+	store, err := OpenBoltStore(path)
+	assert.NoError(t, err)
+	_, err = store.Upsert(context.Background(), newTestPackageInfo("vendor/a", "persisted"))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	store, err = OpenBoltStore(path)
+	assert.NoError(t, err)
+	defer store.Close()
+	info, err := store.Get(context.Background(), "vendor/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "persisted", info.Package.Description)
+}
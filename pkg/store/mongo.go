@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultMongoDatabase 默认的数据库名
+	DefaultMongoDatabase = "composer_crawler"
+
+	// DefaultMongoCollection 默认的集合名
+	DefaultMongoCollection = "packages"
+)
+
+// MongoStoreOptions MongoDB 存储的配置
+type MongoStoreOptions struct {
+
+	// 连接字符串，比如 mongodb://localhost:27017
+	URI string
+
+	// 数据库名，为空的时候使用 DefaultMongoDatabase
+	Database string
+
+	// 集合名，为空的时候使用 DefaultMongoCollection
+	Collection string
+}
+
+// MongoStore 基于 MongoDB 的存储，以 package_name_lowercase 作为唯一键
+type MongoStore struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+var _ PackageStore = &MongoStore{}
+
+// NewMongoStore 连接 MongoDB 并创建需要的索引
+func NewMongoStore(ctx context.Context, storeOptions *MongoStoreOptions) (*MongoStore, error) {
+	if storeOptions == nil || storeOptions.URI == "" {
+		return nil, errors.New("mongodb uri is empty")
+	}
+	database := storeOptions.Database
+	if database == "" {
+		database = DefaultMongoDatabase
+	}
+	collectionName := storeOptions.Collection
+	if collectionName == "" {
+		collectionName = DefaultMongoCollection
+	}
+
+	// 版本信息里只有 scripts 还是 interface{}，嵌套的文档解码成 map（primitive.M）而不是 primitive.D，
+	// 读取的时候使用 Version.ScriptNames 这种不依赖具体 map 类型的方法
+	clientOptions := options.Client().ApplyURI(storeOptions.URI).SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(database).Collection(collectionName)
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "package_name_lowercase", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "change_time", Value: 1}}},
+	})
+	if err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	return &MongoStore{client: client, collection: collection}, nil
+}
+
+func (x *MongoStore) Get(ctx context.Context, packageName string) (*composer_crawler.ComposerPackageInfo, error) {
+	info := &composer_crawler.ComposerPackageInfo{}
+	err := x.collection.FindOne(ctx, bson.M{"package_name_lowercase": packageKey(packageName)}).Decode(info)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// Upsert 先读出之前的版本再整体替换，同一个包被并发写入的时候以最后一次为准
+func (x *MongoStore) Upsert(ctx context.Context, info *composer_crawler.ComposerPackageInfo) (*UpsertResult, error) {
+	packageName := info.PackageName
+	if packageName == "" {
+		packageName = info.Package.Name
+	}
+	previous, err := x.Get(ctx, packageName)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	result, err := prepareUpsert(previous, info, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	_, err = x.collection.ReplaceOne(ctx,
+		bson.M{"package_name_lowercase": info.PackageNameLowercase},
+		info,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (x *MongoStore) Delete(ctx context.Context, packageName string) error {
+	_, err := x.collection.DeleteOne(ctx, bson.M{"package_name_lowercase": packageKey(packageName)})
+	return err
+}
+
+func (x *MongoStore) Iterate(ctx context.Context, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	findOptions := options.Find().SetSort(bson.D{{Key: "package_name_lowercase", Value: 1}})
+	return x.iterate(ctx, bson.M{}, findOptions, callback)
+}
+
+func (x *MongoStore) ChangedSince(ctx context.Context, since time.Time, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	findOptions := options.Find().SetSort(bson.D{{Key: "change_time", Value: 1}, {Key: "package_name_lowercase", Value: 1}})
+	return x.iterate(ctx, bson.M{"change_time": bson.M{"$gte": since}}, findOptions, callback)
+}
+
+func (x *MongoStore) iterate(ctx context.Context, filter interface{}, findOptions *options.FindOptions, callback func(info *composer_crawler.ComposerPackageInfo) error) error {
+	cursor, err := x.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+	for cursor.Next(ctx) {
+		info := &composer_crawler.ComposerPackageInfo{}
+		if err := cursor.Decode(info); err != nil {
+			return err
+		}
+		if err := callback(info); err != nil {
+			if errors.Is(err, ErrStopIterate) {
+				return nil
+			}
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (x *MongoStore) Close() error {
+	return x.client.Disconnect(context.Background())
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MongoDB tests need a running mongod, for example:
+// COMPOSER_CRAWLER_MONGODB_URI=mongodb://localhost:27017 go test ./pkg/store
+const mongoURIEnv = "COMPOSER_CRAWLER_MONGODB_URI"
+
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv(mongoURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set, skip MongoDB tests", mongoURIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := NewMongoStore(ctx, &MongoStoreOptions{
+		URI:        uri,
+		Database:   "composer_crawler_test",
+		Collection: fmt.Sprintf("packages_%d", time.Now().UnixNano()),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, store.collection.Drop(context.Background()))
+		assert.NoError(t, store.Close())
+	}()
+
+	testPackageStore(t, store)
+}
+
+func TestNewMongoStore_EmptyURI(t *testing.T) {
+	_, err := NewMongoStore(context.Background(), &MongoStoreOptions{})
+	assert.Error(t, err)
+}
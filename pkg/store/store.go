@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+)
+
+// ErrNotFound 存储中不存在给定的包
+var ErrNotFound = errors.New("package not found in store")
+
+// ErrStopIterate 在遍历的回调中返回这个错误可以提前结束遍历，遍历方法本身会返回 nil
+var ErrStopIterate = errors.New("stop iterate")
+
+// PackageStore 用来持久化爬取到的包信息
+type PackageStore interface {
+
+	// Get 根据包名获取包信息，包名不区分大小写，不存在的时候返回 ErrNotFound
+	Get(ctx context.Context, packageName string) (*composer_crawler.ComposerPackageInfo, error)
+
+	// Upsert 保存包信息，会根据md5设置 CreateTime、UpdateTime、ChangeTime 以及 PackageInfoMd5
+	Upsert(ctx context.Context, info *composer_crawler.ComposerPackageInfo) (*UpsertResult, error)
+
+	// Delete 删除包信息，包不存在的时候也返回 nil
+	Delete(ctx context.Context, packageName string) error
+
+	// Iterate 按包名顺序遍历所有的包，回调返回错误的时候停止遍历
+	Iterate(ctx context.Context, callback func(info *composer_crawler.ComposerPackageInfo) error) error
+
+	// ChangedSince 按 ChangeTime 升序遍历在给定时间（含）之后内容发生过变化的包
+	ChangedSince(ctx context.Context, since time.Time, callback func(info *composer_crawler.ComposerPackageInfo) error) error
+
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// UpsertResult 表示一次 Upsert 的结果
+type UpsertResult struct {
+
+	// 之前不存在，是新创建的
+	Created bool
+
+	// 之前已经存在，并且内容发生了变化
+	Changed bool
+
+	// 之前保存的包信息，新创建的时候为 nil
+	Previous *composer_crawler.ComposerPackageInfo
+}
+
+// 存储中使用的主键
+func packageKey(packageName string) string {
+	return strings.ToLower(packageName)
+}
+
+// 根据之前保存的包信息设置时间戳和md5，不同的存储实现共用这一套逻辑
+func prepareUpsert(previous, info *composer_crawler.ComposerPackageInfo, now time.Time) (*UpsertResult, error) {
+	if info.PackageName == "" {
+		info.PackageName = info.Package.Name
+	}
+	if info.PackageName == "" {
+		return nil, errors.New("package name is empty")
+	}
+	info.PackageNameLowercase = packageKey(info.PackageName)
+
+	md5, err := info.ComputePackageInfoMd5()
+	if err != nil {
+		return nil, err
+	}
+	info.PackageInfoMd5 = md5
+
+	// mongo 只保存到毫秒，统一截断，保证不同的实现读出来的时间是一样的
+	now = now.UTC().Truncate(time.Millisecond)
+	result := &UpsertResult{Previous: previous}
+	updateTime := now
+	info.UpdateTime = &updateTime
+	if previous == nil {
+		result.Created = true
+		createTime, changeTime := now, now
+		info.CreateTime = &createTime
+		info.ChangeTime = &changeTime
+		return result, nil
+	}
+
+	info.CreateTime = previous.CreateTime
+	if previous.PackageInfoMd5 != md5 || previous.ChangeTime == nil {
+		result.Changed = true
+		changeTime := now
+		info.ChangeTime = &changeTime
+	} else {
+		info.ChangeTime = previous.ChangeTime
+	}
+	if info.CreateTime == nil {
+		createTime := now
+		info.CreateTime = &createTime
+	}
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	composer_crawler "github.com/scagogogo/composer-crawler"
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPackageInfo(name, description string) *composer_crawler.ComposerPackageInfo {
+	info := &composer_crawler.ComposerPackageInfo{}
+	info.Package.Name = name
+	info.Package.Description = description
+	info.Package.Versions = map[string]*composer_crawler.Version{
+		"1.0.0": {
+			Name:     name,
+			Version:  "1.0.0",
+			Require:  map[string]string{"php": ">=8.1"},
+			Autoload: &manifest.Autoload{Psr4: map[string]manifest.StringList{"Vendor\\": {"src/"}}},
+		},
+	}
+	return info
+}
+
+// testPackageStore runs the behaviour every PackageStore implementation must share
+func testPackageStore(t *testing.T, store PackageStore) {
+	ctx := context.Background()
+
+	// Missing packages
+	_, err := store.Get(ctx, "vendor/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "vendor/missing"))
+
+	// Create
+	beforeCreate := time.Now().Add(-time.Second)
+	result, err := store.Upsert(ctx, newTestPackageInfo("Vendor/A", "first"))
+	assert.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.False(t, result.Changed)
+	assert.Nil(t, result.Previous)
+
+	created, err := store.Get(ctx, "vendor/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "Vendor/A", created.PackageName)
+	assert.Equal(t, "vendor/a", created.PackageNameLowercase)
+	assert.NotEmpty(t, created.PackageInfoMd5)
+	assert.True(t, created.CreateTime.After(beforeCreate))
+	assert.True(t, created.CreateTime.Equal(*created.UpdateTime))
+	assert.True(t, created.CreateTime.Equal(*created.ChangeTime))
+	assert.Equal(t, ">=8.1", created.Package.Versions["1.0.0"].Require["php"])
+
+	// Upsert with the same content only bumps the update time, even if the statistics changed
+	time.Sleep(5 * time.Millisecond)
+	unchanged := newTestPackageInfo("Vendor/A", "first")
+	unchanged.Package.Downloads.Total = 100
+	result, err = store.Upsert(ctx, unchanged)
+	assert.NoError(t, err)
+	assert.False(t, result.Created)
+	assert.False(t, result.Changed)
+	assert.Equal(t, created.PackageInfoMd5, result.Previous.PackageInfoMd5)
+
+	got, err := store.Get(ctx, "vendor/a")
+	assert.NoError(t, err)
+	assert.True(t, got.CreateTime.Equal(*created.CreateTime))
+	assert.True(t, got.ChangeTime.Equal(*created.ChangeTime))
+	assert.True(t, got.UpdateTime.After(*created.UpdateTime))
+	assert.Equal(t, 100, got.Package.Downloads.Total)
+
+	// Upsert with changed content bumps the change time
+	time.Sleep(5 * time.Millisecond)
+	checkpoint := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	result, err = store.Upsert(ctx, newTestPackageInfo("vendor/a", "second"))
+	assert.NoError(t, err)
+	assert.True(t, result.Changed)
+	assert.Equal(t, "first", result.Previous.Package.Description)
+
+	changed, err := store.Get(ctx, "VENDOR/A")
+	assert.NoError(t, err)
+	assert.True(t, changed.CreateTime.Equal(*created.CreateTime))
+	assert.True(t, changed.ChangeTime.After(checkpoint))
+	assert.NotEqual(t, created.PackageInfoMd5, changed.PackageInfoMd5)
+
+	_, err = store.Upsert(ctx, newTestPackageInfo("vendor/b", "other"))
+	assert.NoError(t, err)
+	_, err = store.Upsert(ctx, newTestPackageInfo("", ""))
+	assert.Error(t, err)
+
+	// Iterate in package name order
+	names := make([]string, 0)
+	assert.NoError(t, store.Iterate(ctx, func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return nil
+	}))
+	assert.Equal(t, []string{"vendor/a", "vendor/b"}, names)
+
+	// Stop iterating early
+	names = names[:0]
+	assert.NoError(t, store.Iterate(ctx, func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return ErrStopIterate
+	}))
+	assert.Len(t, names, 1)
+	callbackErr := errors.New("callback failed")
+	assert.ErrorIs(t, store.Iterate(ctx, func(info *composer_crawler.ComposerPackageInfo) error {
+		return callbackErr
+	}), callbackErr)
+
+	// Changed since, in change time order
+	names = names[:0]
+	assert.NoError(t, store.ChangedSince(ctx, checkpoint, func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return nil
+	}))
+	assert.Equal(t, []string{"vendor/a", "vendor/b"}, names)
+
+	names = names[:0]
+	assert.NoError(t, store.ChangedSince(ctx, time.Now().Add(time.Hour), func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return nil
+	}))
+	assert.Empty(t, names)
+
+	names = names[:0]
+	assert.NoError(t, store.ChangedSince(ctx, time.Time{}, func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return nil
+	}))
+	assert.Len(t, names, 2)
+
+	// Delete
+	assert.NoError(t, store.Delete(ctx, "vendor/a"))
+	_, err = store.Get(ctx, "vendor/a")
+	assert.ErrorIs(t, err, ErrNotFound)
+	names = names[:0]
+	assert.NoError(t, store.ChangedSince(ctx, time.Time{}, func(info *composer_crawler.ComposerPackageInfo) error {
+		names = append(names, info.PackageNameLowercase)
+		return nil
+	}))
+	assert.Equal(t, []string{"vendor/b"}, names)
+}
@@ -0,0 +1,41 @@
+package typosquat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"symfony", "symfony", 0},
+		{"symfony", "symfonny", 1},
+		{"symfony", "smyfony", 1},
+		{"monolog", "monolgo", 1},
+		{"kitten", "sitting", 3},
+		{"guzzle", "guzle", 1},
+		{"сrypto", "crypto", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"-"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, Distance(tt.a, tt.b))
+			assert.Equal(t, tt.want, Distance(tt.b, tt.a))
+		})
+	}
+}
+
+func TestSkeletons(t *testing.T) {
+	assert.Equal(t, HomoglyphSkeleton("monolog/monolog"), HomoglyphSkeleton("m0n0log/monoIog"))
+	assert.Equal(t, HomoglyphSkeleton("laravel/framework"), HomoglyphSkeleton("laravel/frarnework"))
+	assert.Equal(t, HomoglyphSkeleton("symfony/console"), HomoglyphSkeleton("symfony/cоnsole"))
+	assert.NotEqual(t, HomoglyphSkeleton("symfony/console"), HomoglyphSkeleton("symfony/process"))
+
+	assert.Equal(t, SeparatorSkeleton("php-http/client"), SeparatorSkeleton("php_http/client"))
+	assert.Equal(t, SeparatorSkeleton("phpunit/php-code-coverage"), SeparatorSkeleton("phpunit/phpcode.coverage"))
+}
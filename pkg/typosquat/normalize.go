@@ -0,0 +1,52 @@
+package typosquat
+
+import (
+	"strings"
+)
+
+// 容易被看混的字符，统一替换成同一个字符
+var homoglyphReplacer = strings.NewReplacer(
+	"rn", "m",
+	"vv", "w",
+	"cl", "d",
+	"0", "o",
+	"1", "l",
+	"i", "l",
+	"|", "l",
+	"5", "s",
+	"3", "e",
+	"4", "a",
+	"@", "a",
+	"$", "s",
+	// 常见的西里尔字母和希腊字母
+	"а", "a",
+	"е", "e",
+	"о", "o",
+	"р", "p",
+	"с", "c",
+	"х", "x",
+	"у", "y",
+	"ο", "o",
+	"ν", "v",
+)
+
+// 包名中可以互换的分隔符
+var separatorReplacer = strings.NewReplacer("-", "", "_", "", ".", "")
+
+// HomoglyphSkeleton 把容易看混的字符统一之后的包名，两个包名的骨架相同说明它们看起来很像
+func HomoglyphSkeleton(name string) string {
+	return homoglyphReplacer.Replace(strings.ToLower(name))
+}
+
+// SeparatorSkeleton 去掉 - _ . 这些分隔符之后的包名
+func SeparatorSkeleton(name string) string {
+	return separatorReplacer.Replace(strings.ToLower(name))
+}
+
+// 把包名拆成 vendor 和 package 两部分
+func splitName(name string) (string, string) {
+	if index := strings.Index(name, "/"); index >= 0 {
+		return name[:index], name[index+1:]
+	}
+	return "", name
+}
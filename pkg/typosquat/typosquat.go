@@ -0,0 +1,268 @@
+package typosquat
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+)
+
+// Technique 表示仿冒的手法
+type Technique string
+
+const (
+	// TechniqueEditDistance 包名只差了几个字符
+	TechniqueEditDistance Technique = "edit_distance"
+
+	// TechniqueHomoglyph 包名用了看起来很像的字符，比如 0 和 o、rn 和 m
+	TechniqueHomoglyph Technique = "homoglyph"
+
+	// TechniqueVendorSwap 包名相同，vendor 只差了几个字符，比如 symfony 和 symfonny
+	TechniqueVendorSwap Technique = "vendor_swap"
+
+	// TechniqueSeparator 只是分隔符不同，比如 - 和 _
+	TechniqueSeparator Technique = "separator"
+)
+
+// DefaultMaxDistance 默认的最大编辑距离
+const DefaultMaxDistance = 2
+
+// DownloadsFunc 用来获取包的下载量
+type DownloadsFunc func(ctx context.Context, packageName string) (int64, error)
+
+// Options 仿冒检测的配置
+type Options struct {
+
+	// 被仿冒的目标，一般是下载量比较高的包，为空的时候使用索引中所有的包
+	Popular []string
+
+	// 最大编辑距离，为空的时候使用 DefaultMaxDistance
+	MaxDistance int
+
+	// 用来给候选排序的下载量，为空的时候不排序
+	Downloads DownloadsFunc
+}
+
+// Candidate 表示一个可能被仿冒的包
+type Candidate struct {
+
+	// 被检查的包名
+	Suspect string `json:"suspect"`
+
+	// 和被检查的包很像的热门包
+	Target string `json:"target"`
+
+	Techniques []Technique `json:"techniques"`
+	Distance   int         `json:"distance"`
+
+	// 下载量，没有配置 Downloads 的时候为 0
+	SuspectDownloads int64 `json:"suspectDownloads"`
+	TargetDownloads  int64 `json:"targetDownloads"`
+
+	// 目标比被检查的包多出来的下载量，差距越大越可疑
+	DownloadGap int64 `json:"downloadGap"`
+}
+
+// Detector 在包名索引中寻找和给定的包名很像的热门包
+type Detector struct {
+	options *Options
+
+	targets     []string
+	targetSet   map[string]bool
+	homoglyphs  map[string][]string
+	separators  map[string][]string
+	byPackage   map[string][]string
+	downloads   map[string]int64
+	downloadsMu sync.Mutex
+}
+
+// NewDetector 使用包名索引创建检测器，packageNames 一般是 List 的结果
+func NewDetector(packageNames []string, options *Options) *Detector {
+	if options == nil {
+		options = &Options{}
+	}
+	targets := options.Popular
+	if len(targets) == 0 {
+		targets = packageNames
+	}
+	x := &Detector{
+		options:    options,
+		targets:    make([]string, 0, len(targets)),
+		targetSet:  make(map[string]bool),
+		homoglyphs: make(map[string][]string),
+		separators: make(map[string][]string),
+		byPackage:  make(map[string][]string),
+		downloads:  make(map[string]int64),
+	}
+	for _, name := range targets {
+		name = strings.ToLower(name)
+		if x.targetSet[name] {
+			continue
+		}
+		x.targetSet[name] = true
+		x.targets = append(x.targets, name)
+		x.homoglyphs[HomoglyphSkeleton(name)] = append(x.homoglyphs[HomoglyphSkeleton(name)], name)
+		x.separators[SeparatorSkeleton(name)] = append(x.separators[SeparatorSkeleton(name)], name)
+		_, packagePart := splitName(name)
+		x.byPackage[packagePart] = append(x.byPackage[packagePart], name)
+	}
+	return x
+}
+
+// RepositoryDownloads 使用仓库的单包统计作为下载量
+func RepositoryDownloads(repo *repository.Repository) DownloadsFunc {
+	return func(ctx context.Context, packageName string) (int64, error) {
+		statistics, err := repo.GetPackageStatistics(ctx, packageName)
+		if err != nil {
+			if errors.Is(err, repository.ErrPackageNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		return statistics.Downloads.Total, nil
+	}
+}
+
+// Check 检查一个包名，返回和它很像的热门包，按下载量差距从大到小排序
+func (x *Detector) Check(ctx context.Context, packageName string) ([]*Candidate, error) {
+	suspect := strings.ToLower(packageName)
+	candidates := make(map[string]*Candidate)
+	suspectVendor, _ := splitName(suspect)
+	add := func(target string, technique Technique) {
+		// Packagist 上 vendor 是有归属的，同一个 vendor 下的包不可能是别人仿冒的
+		if targetVendor, _ := splitName(target); target == suspect || targetVendor == suspectVendor {
+			return
+		}
+		candidate := candidates[target]
+		if candidate == nil {
+			candidate = &Candidate{Suspect: suspect, Target: target, Distance: Distance(suspect, target)}
+			candidates[target] = candidate
+		}
+		for _, t := range candidate.Techniques {
+			if t == technique {
+				return
+			}
+		}
+		candidate.Techniques = append(candidate.Techniques, technique)
+	}
+
+	for _, target := range x.homoglyphs[HomoglyphSkeleton(suspect)] {
+		add(target, TechniqueHomoglyph)
+	}
+	for _, target := range x.separators[SeparatorSkeleton(suspect)] {
+		add(target, TechniqueSeparator)
+	}
+	_, packagePart := splitName(suspect)
+	for _, target := range x.byPackage[packagePart] {
+		targetVendor, _ := splitName(target)
+		if Distance(suspectVendor, targetVendor) <= x.maxDistance() {
+			add(target, TechniqueVendorSwap)
+		}
+	}
+	suspectLength := utf8.RuneCountInString(suspect)
+	for _, target := range x.targets {
+		if abs(utf8.RuneCountInString(target)-suspectLength) > x.maxDistance() {
+			continue
+		}
+		if Distance(suspect, target) <= x.maxDistance() {
+			add(target, TechniqueEditDistance)
+		}
+	}
+
+	result := make([]*Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		sort.Slice(candidate.Techniques, func(i, j int) bool {
+			return candidate.Techniques[i] < candidate.Techniques[j]
+		})
+		result = append(result, candidate)
+	}
+	if err := x.rank(ctx, suspect, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckComposerJSON 检查 composer.json 中的所有依赖，返回有可疑候选的依赖，key 是依赖的包名
+func (x *Detector) CheckComposerJSON(ctx context.Context, composerJSON *manifest.ComposerJSON) (map[string][]*Candidate, error) {
+	result := make(map[string][]*Candidate)
+	for name := range composerJSON.AllRequires() {
+		if manifest.IsPlatformPackage(name) {
+			continue
+		}
+		candidates, err := x.Check(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) > 0 {
+			result[name] = candidates
+		}
+	}
+	return result, nil
+}
+
+// 按照下载量差距排序，没有下载量的时候按编辑距离排序
+func (x *Detector) rank(ctx context.Context, suspect string, candidates []*Candidate) error {
+	if x.options.Downloads != nil && len(candidates) > 0 {
+		suspectDownloads, err := x.downloadsOf(ctx, suspect)
+		if err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			targetDownloads, err := x.downloadsOf(ctx, candidate.Target)
+			if err != nil {
+				return err
+			}
+			candidate.SuspectDownloads = suspectDownloads
+			candidate.TargetDownloads = targetDownloads
+			candidate.DownloadGap = targetDownloads - suspectDownloads
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.DownloadGap != b.DownloadGap {
+			return a.DownloadGap > b.DownloadGap
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.Target < b.Target
+	})
+	return nil
+}
+
+// 下载量会被缓存，同一个包只查询一次
+func (x *Detector) downloadsOf(ctx context.Context, packageName string) (int64, error) {
+	x.downloadsMu.Lock()
+	downloads, ok := x.downloads[packageName]
+	x.downloadsMu.Unlock()
+	if ok {
+		return downloads, nil
+	}
+	downloads, err := x.options.Downloads(ctx, packageName)
+	if err != nil {
+		return 0, err
+	}
+	x.downloadsMu.Lock()
+	x.downloads[packageName] = downloads
+	x.downloadsMu.Unlock()
+	return downloads, nil
+}
+
+func (x *Detector) maxDistance() int {
+	if x.options.MaxDistance > 0 {
+		return x.options.MaxDistance
+	}
+	return DefaultMaxDistance
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
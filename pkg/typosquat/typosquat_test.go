@@ -0,0 +1,144 @@
+package typosquat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var testIndex = []string{
+	"symfony/console",
+	"symfony/process",
+	"monolog/monolog",
+	"guzzlehttp/guzzle",
+	"php-http/client",
+	"laravel/framework",
+	"symfonny/console",
+	"m0nolog/monolog",
+	"php_http/client",
+	"guzzlehttp/guzle",
+}
+
+var testDownloads = map[string]int64{
+	"symfony/console":   700000000,
+	"monolog/monolog":   800000000,
+	"guzzlehttp/guzzle": 750000000,
+	"php-http/client":   1000000,
+	"symfonny/console":  10,
+	"m0nolog/monolog":   5,
+}
+
+func testDownloadsFunc(ctx context.Context, packageName string) (int64, error) {
+	return testDownloads[packageName], nil
+}
+
+func candidateTargets(candidates []*Candidate) []string {
+	targets := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		targets = append(targets, candidate.Target)
+	}
+	return targets
+}
+
+func TestDetector_Check(t *testing.T) {
+	detector := NewDetector(testIndex, &Options{
+		Popular:   []string{"symfony/console", "symfony/process", "monolog/monolog", "guzzlehttp/guzzle", "php-http/client", "laravel/framework"},
+		Downloads: testDownloadsFunc,
+	})
+
+	tests := []struct {
+		name           string
+		suspect        string
+		wantTarget     string
+		wantTechniques []Technique
+	}{
+		{name: "vendor swap", suspect: "symfonny/console", wantTarget: "symfony/console", wantTechniques: []Technique{TechniqueEditDistance, TechniqueVendorSwap}},
+		{name: "homoglyph", suspect: "m0nolog/monolog", wantTarget: "monolog/monolog", wantTechniques: []Technique{TechniqueEditDistance, TechniqueHomoglyph, TechniqueVendorSwap}},
+		{name: "separator", suspect: "php_http/client", wantTarget: "php-http/client", wantTechniques: []Technique{TechniqueEditDistance, TechniqueSeparator, TechniqueVendorSwap}},
+		{name: "cyrillic homoglyph", suspect: "lаravel/framework", wantTarget: "laravel/framework", wantTechniques: []Technique{TechniqueEditDistance, TechniqueHomoglyph, TechniqueVendorSwap}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := detector.Check(context.Background(), tt.suspect)
+			assert.NoError(t, err)
+			if assert.Len(t, candidates, 1) {
+				assert.Equal(t, tt.wantTarget, candidates[0].Target)
+				assert.Equal(t, tt.wantTechniques, candidates[0].Techniques)
+				assert.Equal(t, testDownloads[tt.wantTarget]-testDownloads[tt.suspect], candidates[0].DownloadGap)
+			}
+		})
+	}
+
+	// Popular packages themselves and lookalikes from the same vendor are not flagged
+	for _, name := range []string{"symfony/console", "guzzlehttp/guzle", "vendor/unrelated"} {
+		candidates, err := detector.Check(context.Background(), name)
+		assert.NoError(t, err)
+		assert.Empty(t, candidates, name)
+	}
+}
+
+func TestDetector_CheckRanking(t *testing.T) {
+	detector := NewDetector([]string{"acme/logger", "acme/loger", "acmee/logger"}, &Options{
+		Downloads: func(ctx context.Context, packageName string) (int64, error) {
+			return map[string]int64{"acme/logger": 100, "acme/loger": 5000, "acmee/logger": 1}[packageName], nil
+		},
+	})
+	candidates, err := detector.Check(context.Background(), "acmee/loger")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/loger", "acme/logger"}, candidateTargets(candidates))
+	assert.Equal(t, int64(5000), candidates[0].DownloadGap)
+
+	failing := NewDetector([]string{"acme/logger"}, &Options{
+		Downloads: func(ctx context.Context, packageName string) (int64, error) {
+			return 0, errors.New("stats unavailable")
+		},
+	})
+	_, err = failing.Check(context.Background(), "acne/logger")
+	assert.Error(t, err)
+}
+
+func TestDetector_CheckComposerJSON(t *testing.T) {
+	composerJSON, err := manifest.ParseComposerJSON([]byte(`{
+		"require": {"php": ">=8.1", "ext-json": "*", "symfonny/console": "^6.0", "monolog/monolog": "^3.0"},
+		"require-dev": {"php_http/client": "^2.0"}
+	}`))
+	assert.NoError(t, err)
+
+	detector := NewDetector(testIndex, &Options{
+		Popular: []string{"symfony/console", "monolog/monolog", "php-http/client"},
+	})
+	result, err := detector.CheckComposerJSON(context.Background(), composerJSON)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, []string{"symfony/console"}, candidateTargets(result["symfonny/console"]))
+	assert.Equal(t, []string{"php-http/client"}, candidateTargets(result["php_http/client"]))
+}
+
+func TestRepositoryDownloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/packages/symfony/console/"):
+			w.Write([]byte(`{"downloads": {"total": 123, "monthly": 4, "daily": 1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status": "error", "message": "Package not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	downloads := RepositoryDownloads(repository.NewRepository(&repository.Options{ServerUrl: server.URL}))
+	total, err := downloads(context.Background(), "symfony/console")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), total)
+
+	total, err = downloads(context.Background(), "not/exists")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
@@ -0,0 +1,120 @@
+package composer_crawler
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/composer-crawler/pkg/manifest"
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+)
+
+// Stability 返回版本的稳定性，dev-main、1.x-dev 这类分支版本是 dev，无法解析的版本也当做 dev
+func (x *Version) Stability() semver.Stability {
+	return semver.StabilityOf(x.Version)
+}
+
+// IsDev 是否是分支版本，Composer v2 的元数据中这些版本在单独的 ~dev.json 中
+func (x *Version) IsDev() bool {
+	return x.Stability() == semver.StabilityDev
+}
+
+// ExtraValue 把 extra 中的某个键解析到 v 中，键不存在的时候返回 false，extra 不是对象的时候返回错误
+func (x *Version) ExtraValue(key string, v interface{}) (bool, error) {
+	if len(x.Extra) == 0 {
+		return false, nil
+	}
+	var extra manifest.Extra
+	if err := json.Unmarshal(x.Extra, &extra); err != nil {
+		return false, err
+	}
+	return extra.Get(key, v)
+}
+
+// ExtraKeys 返回 extra 中所有的键，按字母顺序排列
+func (x *Version) ExtraKeys() []string {
+	keys := make([]string, 0)
+	var extra manifest.Extra
+	if len(x.Extra) == 0 || json.Unmarshal(x.Extra, &extra) != nil {
+		return keys
+	}
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ScriptNames 返回 scripts 中所有的脚本名，按字母顺序排列。Scripts 从 JSON 解码的时候是 map[string]interface{}，
+// 从 MongoDB 解码的时候是 primitive.M，这里统一转换成 JSON 再解析，不是对象的时候返回空
+func (x *Version) ScriptNames() []string {
+	names := make([]string, 0)
+	if x.Scripts == nil {
+		return names
+	}
+	bytes, err := json.Marshal(x.Scripts)
+	if err != nil {
+		return names
+	}
+	var scripts map[string]json.RawMessage
+	if json.Unmarshal(bytes, &scripts) != nil {
+		return names
+	}
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BranchAliases 返回 extra.branch-alias，key 是分支版本，value 是别名，比如 "dev-main": "3.x-dev"
+func (x *Version) BranchAliases() map[string]string {
+	aliases := make(map[string]string)
+	var branchAlias map[string]interface{}
+	if _, err := x.ExtraValue("branch-alias", &branchAlias); err != nil {
+		return aliases
+	}
+	for branch, alias := range branchAlias {
+		if alias, ok := alias.(string); ok && alias != "" {
+			aliases[branch] = alias
+		}
+	}
+	return aliases
+}
+
+// BranchAlias 返回这个版本的分支别名，比如 dev-main 的别名是 3.x-dev，只是返回 extra 中声明的别名，版本约束的匹配不会使用它。
+// 和 Composer 一样，只有分支版本（dev-main、2.x-dev）才会使用别名，分支名不区分大小写，别名必须是以 -dev 结尾的数字分支，
+// 数字分支的别名还必须是它的子版本，比如 2.x-dev 可以是 2.1.x-dev，不能是 3.x-dev，不满足的时候返回空字符串
+func (x *Version) BranchAlias() string {
+	if !x.IsDev() {
+		return ""
+	}
+	for branch, alias := range x.BranchAliases() {
+		if !strings.EqualFold(branch, x.Version) {
+			continue
+		}
+		parsed, err := semver.Parse(alias)
+		if err != nil || !strings.HasSuffix(strings.ToLower(alias), "-dev") || parsed.IsBranch() {
+			continue
+		}
+		if branchPrefix, ok := numericAliasPrefix(x.Version); ok {
+			if aliasPrefix, ok := numericAliasPrefix(alias); !ok || !strings.HasPrefix(aliasPrefix, branchPrefix) {
+				continue
+			}
+		}
+		return alias
+	}
+	return ""
+}
+
+var numericAliasRegex = regexp.MustCompile(`(?i)^((?:\d+\.)*\d+)(?:\.x)?-dev$`)
+
+// numericAliasPrefix 返回数字分支的前缀，比如 2.x-dev 返回 "2."，2.1.x-dev 返回 "2.1."，不是数字分支的时候返回 false
+func numericAliasPrefix(branch string) (string, bool) {
+	match := numericAliasRegex.FindStringSubmatch(branch)
+	if match == nil {
+		return "", false
+	}
+	return match[1] + ".", true
+}
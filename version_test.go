@@ -0,0 +1,154 @@
+package composer_crawler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/composer-crawler/pkg/semver"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestVersion_Stability(t *testing.T) {
+	testCases := []struct {
+		version   string
+		stability semver.Stability
+	}{
+		{"3.5.0", semver.StabilityStable},
+		{"v2.0.0", semver.StabilityStable},
+		{"3.0.0-RC1", semver.StabilityRC},
+		{"2.0.0-beta.2", semver.StabilityBeta},
+		{"1.0.0-alpha1", semver.StabilityAlpha},
+		{"dev-main", semver.StabilityDev},
+		{"3.x-dev", semver.StabilityDev},
+		{"not a version", semver.StabilityDev},
+	}
+	for _, testCase := range testCases {
+		version := &Version{Version: testCase.version}
+		assert.Equal(t, testCase.stability, version.Stability(), testCase.version)
+		assert.Equal(t, testCase.stability == semver.StabilityDev, version.IsDev(), testCase.version)
+	}
+}
+
+func TestVersion_BranchAlias(t *testing.T) {
+	testCases := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{"alias", `{"version": "dev-main", "extra": {"branch-alias": {"dev-main": "3.x-dev"}}}`, "3.x-dev"},
+		{"other branch", `{"version": "dev-feature", "extra": {"branch-alias": {"dev-main": "3.x-dev"}}}`, ""},
+		{"no extra", `{"version": "dev-main"}`, ""},
+		{"not a dev alias", `{"version": "dev-main", "extra": {"branch-alias": {"dev-main": "3.0.0"}}}`, ""},
+		{"alias to branch", `{"version": "dev-main", "extra": {"branch-alias": {"dev-main": "dev-other"}}}`, ""},
+		{"tagged version", `{"version": "3.0.0", "extra": {"branch-alias": {"3.0.0": "3.x-dev"}}}`, ""},
+		{"extra is a list", `{"version": "dev-main", "extra": []}`, ""},
+		{"numeric branch", `{"version": "2.x-dev", "extra": {"branch-alias": {"2.x-dev": "2.1.x-dev"}}}`, "2.1.x-dev"},
+		{"numeric branch alias outside the branch", `{"version": "2.x-dev", "extra": {"branch-alias": {"2.x-dev": "3.x-dev"}}}`, ""},
+		{"numeric branch alias with a longer prefix", `{"version": "2.x-dev", "extra": {"branch-alias": {"2.x-dev": "21.x-dev"}}}`, ""},
+		{"case insensitive branch", `{"version": "dev-Main", "extra": {"branch-alias": {"dev-main": "3.x-dev"}}}`, "3.x-dev"},
+		{"case insensitive key", `{"version": "dev-main", "extra": {"branch-alias": {"DEV-MAIN": "3.x-DEV"}}}`, "3.x-DEV"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			version := &Version{}
+			assert.NoError(t, json.Unmarshal([]byte(testCase.json), version))
+			assert.Equal(t, testCase.expected, version.BranchAlias())
+		})
+	}
+}
+
+func TestVersion_ScriptNames(t *testing.T) {
+	version := &Version{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"scripts": {"post-install-cmd": "make", "build": ["a", "b"]}}`), version))
+	assert.Equal(t, []string{"build", "post-install-cmd"}, version.ScriptNames())
+
+	// Versions loaded from MongoDB carry primitive.M instead of map[string]interface{}
+	version.Scripts = bson.M{"post-update-cmd": "make", "test": bson.A{"phpunit"}}
+	assert.Equal(t, []string{"post-update-cmd", "test"}, version.ScriptNames())
+
+	version.Scripts = "not an object"
+	assert.Empty(t, version.ScriptNames())
+	version.Scripts = nil
+	assert.Empty(t, version.ScriptNames())
+}
+
+func TestVersion_BranchAliases(t *testing.T) {
+	version := &Version{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"extra": {"branch-alias": {"dev-main": "3.x-dev", "dev-2.x": "2.x-dev", "dev-bad": 1}}}`), version))
+	assert.Equal(t, map[string]string{"dev-main": "3.x-dev", "dev-2.x": "2.x-dev"}, version.BranchAliases())
+}
+
+func TestVersion_TypedFields(t *testing.T) {
+	data := `{
+		"name": "monolog/monolog",
+		"version": "3.5.0",
+		"require": {"php": ">=8.1"},
+		"require-dev": {"phpunit/phpunit": "^10.1"},
+		"conflict": {"elasticsearch/elasticsearch": "<7 || >=8"},
+		"replace": {"psr/log-implementation": "self.version"},
+		"provide": {"psr/log-implementation": "3.0.0"},
+		"suggest": {"ext-mbstring": "Allow to work properly with unicode symbols"},
+		"autoload": {
+			"psr-4": {"Monolog\\": "src/Monolog", "Monolog\\Test\\": ["tests/", "fixtures/"]},
+			"psr-0": {"Legacy_": "lib/"},
+			"classmap": ["classes/"],
+			"files": ["src/functions.php"],
+			"exclude-from-classmap": ["/tests/"]
+		},
+		"extra": {"branch-alias": {"dev-main": "3.x-dev"}, "class": ["A\\Plugin", "B\\Plugin"]}
+	}`
+	version := &Version{}
+	assert.NoError(t, json.Unmarshal([]byte(data), version))
+
+	assert.Equal(t, "^10.1", version.RequireDev["phpunit/phpunit"])
+	assert.Equal(t, "<7 || >=8", version.Conflict["elasticsearch/elasticsearch"])
+	assert.Equal(t, "self.version", version.Replace["psr/log-implementation"])
+	assert.Equal(t, "3.0.0", version.Provide["psr/log-implementation"])
+	assert.Contains(t, version.Suggest["ext-mbstring"], "unicode")
+
+	if assert.NotNil(t, version.Autoload) {
+		assert.Equal(t, []string{"src/Monolog"}, []string(version.Autoload.Psr4["Monolog\\"]))
+		assert.Equal(t, []string{"tests/", "fixtures/"}, []string(version.Autoload.Psr4["Monolog\\Test\\"]))
+		assert.Equal(t, []string{"lib/"}, []string(version.Autoload.Psr0["Legacy_"]))
+		assert.Equal(t, []string{"classes/"}, version.Autoload.Classmap)
+		assert.Equal(t, []string{"src/functions.php"}, version.Autoload.Files)
+		assert.Equal(t, []string{"/tests/"}, version.Autoload.ExcludeFromClassmap)
+	}
+
+	assert.Equal(t, []string{"branch-alias", "class"}, version.ExtraKeys())
+	var classes []string
+	ok, err := version.ExtraValue("class", &classes)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A\\Plugin", "B\\Plugin"}, classes)
+	ok, err = version.ExtraValue("laravel", &classes)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	// The typed fields survive a round trip
+	encoded, err := json.Marshal(version)
+	assert.NoError(t, err)
+	decoded := &Version{}
+	assert.NoError(t, json.Unmarshal(encoded, decoded))
+	// Marshaling compacts the raw extra
+	assert.JSONEq(t, string(version.Extra), string(decoded.Extra))
+	decoded.Extra = version.Extra
+	assert.Equal(t, version, decoded)
+}
+
+func TestVersion_TypedFields_EmptyArrays(t *testing.T) {
+	// PHP serializes empty objects as []
+	version := &Version{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"version": "1.0.0", "autoload": [], "suggest": [], "provide": [], "extra": []}`), version))
+	assert.True(t, version.Autoload.IsEmpty())
+	assert.Empty(t, version.Suggest)
+	assert.Empty(t, version.Provide)
+	assert.Empty(t, version.ExtraKeys())
+	assert.Empty(t, version.BranchAliases())
+
+	// extra is not an object
+	version.Extra = json.RawMessage(`"oops"`)
+	_, err := version.ExtraValue("class", new(string))
+	assert.Error(t, err)
+}